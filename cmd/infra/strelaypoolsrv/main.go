@@ -43,6 +43,7 @@ type location struct {
 	City      string  `json:"city"`
 	Country   string  `json:"country"`
 	Continent string  `json:"continent"`
+	ASN       uint    `json:"asn,omitempty"`
 }
 
 type relay struct {
@@ -117,6 +118,11 @@ var (
 
 	requests chan request
 
+	// The GeoIP providers; set in main. The ASN provider is optional and
+	// may be nil.
+	cityProvider *geoip.Provider
+	asnProvider  *geoip.Provider
+
 	mut             sync.RWMutex
 	knownRelays     = make([]*relay, 0)
 	permanentRelays = make([]*relay, 0)
@@ -152,11 +158,18 @@ func main() {
 	log.Println(build.LongVersionFor("strelaypoolsrv"))
 
 	requests = make(chan request, requestQueueLen)
+	if asn, err := geoip.NewGeoLite2ASNProvider(context.Background(), geoipAccountID, geoipLicenseKey, os.TempDir()); err != nil {
+		log.Println("Failed to create GeoIP ASN provider, continuing without:", err)
+	} else {
+		go asn.Serve(context.TODO())
+		asnProvider = asn
+	}
 	geoip, err := geoip.NewGeoLite2CityProvider(context.Background(), geoipAccountID, geoipLicenseKey, os.TempDir())
 	if err != nil {
 		log.Fatalln("Failed to create GeoIP provider:", err)
 	}
 	go geoip.Serve(context.TODO())
+	cityProvider = geoip
 
 	var listener net.Listener
 
@@ -328,24 +341,36 @@ func handleEndpointFull(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write(bs)
 }
 
-// handleEndpointShort returns the relay list with only the URL.
+// handleEndpointShort returns the relay list with only the URL. When the
+// request carries near= hints the relays are ranked by proximity to the
+// requester and the hinted addresses, and by load, best first. Otherwise
+// they are in random order.
 func handleEndpointShort(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 	rw.Header().Set("Access-Control-Allow-Origin", "*")
 
-	mut.RLock()
-	relays := make([]relayShort, 0, len(permanentRelays)+len(knownRelays))
-	for _, r := range permanentRelays {
-		relays = append(relays, relayShort{URL: slimURL(r.URL)})
+	var near []location
+	if r.URL.Query().Has("near") {
+		near = nearHints(r)
 	}
-	for _, r := range knownRelays {
+
+	mut.RLock()
+	all := make([]*relay, 0, len(permanentRelays)+len(knownRelays))
+	all = append(all, permanentRelays...)
+	all = append(all, knownRelays...)
+	if len(near) > 0 {
+		all = rankRelays(all, near)
+	} else if len(all) > maxRelaysReturned {
+		rand.Shuffle(all)
+	}
+	if len(all) > maxRelaysReturned {
+		all = all[:maxRelaysReturned]
+	}
+	relays := make([]relayShort, 0, len(all))
+	for _, r := range all {
 		relays = append(relays, relayShort{URL: slimURL(r.URL)})
 	}
 	mut.RUnlock()
-	if len(relays) > maxRelaysReturned {
-		rand.Shuffle(relays)
-		relays = relays[:maxRelaysReturned]
-	}
 
 	_ = json.NewEncoder(rw).Encode(map[string][]relayShort{
 		"relays": relays,
@@ -354,17 +379,7 @@ func handleEndpointShort(rw http.ResponseWriter, r *http.Request) {
 
 func handleRegister(w http.ResponseWriter, r *http.Request) {
 	// Get the IP address of the client
-	rhost := r.RemoteAddr
-	if ipHeader != "" {
-		hdr := r.Header.Get(ipHeader)
-		fields := strings.Split(hdr, ",")
-		if len(fields) > 0 {
-			rhost = strings.TrimSpace(fields[len(fields)-1])
-		}
-	}
-	if host, _, err := net.SplitHostPort(rhost); err == nil {
-		rhost = host
-	}
+	rhost := clientHost(r)
 
 	// Check the black list. A client is blacklisted if their last 10
 	// attempts to join have all failed. The "Unauthorized" status return
@@ -655,18 +670,37 @@ func getLocation(host string, geoip *geoip.Provider) location {
 		return location{}
 	}
 
-	city, err := geoip.City(addr.IP)
+	return lookupLocation(addr.IP, geoip)
+}
+
+// locateIP returns the location of the given IP address, using the global
+// GeoIP providers.
+func locateIP(ip net.IP) location {
+	if cityProvider == nil {
+		return location{}
+	}
+	return lookupLocation(ip, cityProvider)
+}
+
+func lookupLocation(ip net.IP, geoip *geoip.Provider) location {
+	city, err := geoip.City(ip)
 	if err != nil {
 		return location{}
 	}
 
-	return location{
+	loc := location{
 		Longitude: city.Location.Longitude,
 		Latitude:  city.Location.Latitude,
 		City:      city.City.Names["en"],
 		Country:   city.Country.IsoCode,
 		Continent: city.Continent.Code,
 	}
+	if asnProvider != nil {
+		if asn, err := asnProvider.ASN(ip); err == nil {
+			loc.ASN = asn.AutonomousSystemNumber
+		}
+	}
+	return loc
 }
 
 type loggingResponseWriter struct {
@@ -732,6 +766,10 @@ func slimURL(u string) string {
 	if id := p.Query().Get("id"); id != "" {
 		newQuery.Set("id", id)
 	}
+	if p.Query().Get("quic") == "1" {
+		// Clients need this to know they can connect over QUIC.
+		newQuery.Set("quic", "1")
+	}
 	p.RawQuery = newQuery.Encode()
 	return p.String()
 }
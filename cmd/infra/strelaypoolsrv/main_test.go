@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -104,3 +105,83 @@ func TestSlimURL(t *testing.T) {
 		}
 	}
 }
+
+func TestRankRelays(t *testing.T) {
+	var (
+		berlin    = location{Latitude: 52.52, Longitude: 13.40, Country: "DE"}
+		paris     = location{Latitude: 48.86, Longitude: 2.35, Country: "FR"}
+		amsterdam = location{Latitude: 52.37, Longitude: 4.90, Country: "NL", ASN: 64500}
+		newYork   = location{Latitude: 40.71, Longitude: -74.01, Country: "US"}
+		tokyo     = location{Latitude: 35.68, Longitude: 139.69, Country: "JP"}
+	)
+
+	idle := &stats{}
+	busy := &stats{ActiveSessions: 1000}
+
+	relays := []*relay{
+		{URL: "tokyo", Location: tokyo, Stats: idle},
+		{URL: "unknown", Stats: idle},
+		{URL: "newyork", Location: newYork, Stats: idle},
+		{URL: "paris-busy", Location: paris, Stats: busy},
+		{URL: "amsterdam", Location: amsterdam, Stats: idle},
+	}
+
+	cases := []struct {
+		near   []location
+		expect []string
+	}{
+		// Two devices in Europe get the idle European relay first, the
+		// busy one after relays further away but idle.
+		{[]location{berlin, paris}, []string{"amsterdam", "paris-busy", "newyork", "tokyo", "unknown"}},
+		// Sharing the AS tips the balance.
+		{[]location{{Latitude: 48.86, Longitude: 2.35, ASN: 64500}}, []string{"amsterdam", "paris-busy", "newyork", "tokyo", "unknown"}},
+		// A device in New York
+		{[]location{newYork}, []string{"newyork", "amsterdam", "paris-busy", "tokyo", "unknown"}},
+	}
+
+	for _, tc := range cases {
+		ranked := rankRelays(relays, tc.near)
+		var got []string
+		for _, r := range ranked {
+			got = append(got, r.URL)
+		}
+		if !slices.Equal(got, tc.expect) {
+			t.Errorf("rankRelays(%v) = %v, expected %v", tc.near, got, tc.expect)
+		}
+	}
+}
+
+func TestRankRelaysShufflesTies(t *testing.T) {
+	berlin := location{Latitude: 52.52, Longitude: 13.40, Country: "DE"}
+	potsdam := location{Latitude: 52.39, Longitude: 13.06, Country: "DE"}
+	tokyo := location{Latitude: 35.68, Longitude: 139.69, Country: "JP"}
+
+	relays := []*relay{
+		{URL: "berlin", Location: berlin, Stats: &stats{}},
+		{URL: "potsdam", Location: potsdam, Stats: &stats{}},
+		{URL: "tokyo", Location: tokyo, Stats: &stats{}},
+	}
+
+	first := make(map[string]int)
+	for range 100 {
+		ranked := rankRelays(relays, []location{berlin})
+		if ranked[2].URL != "tokyo" {
+			t.Fatalf("Distant relay ranked %v", ranked)
+		}
+		first[ranked[0].URL]++
+	}
+	if first["berlin"] == 0 || first["potsdam"] == 0 {
+		t.Errorf("Equally good relays not shuffled: %v", first)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	berlin := location{Latitude: 52.52, Longitude: 13.40}
+	paris := location{Latitude: 48.86, Longitude: 2.35}
+	if d := distanceKm(berlin, paris); d < 870 || d > 890 {
+		t.Errorf("unexpected distance Berlin-Paris %v", d)
+	}
+	if d := distanceKm(paris, paris); d != 0 {
+		t.Errorf("unexpected distance Paris-Paris %v", d)
+	}
+}
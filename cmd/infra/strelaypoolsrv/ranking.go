@@ -0,0 +1,177 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	// maxNearHints is the maximum number of near= hints we consider per
+	// request.
+	maxNearHints = 8
+
+	// The relay score is the average distance to the hinted locations, in
+	// kilometers, adjusted for load and network proximity. A fully loaded
+	// relay is considered as bad as one loadPenaltyKm further away, while
+	// a relay sharing the AS with all hinted locations is considered
+	// sameASBonusKm closer.
+	loadPenaltyKm = 2000
+	sameASBonusKm = 500
+
+	// Relays scoring within tieMarginKm of each other are as good as each
+	// other, and handed out in random order so that clients in the same
+	// area don't all pile onto the same relay between stats updates.
+	tieMarginKm = 100
+
+	// unknownDistanceKm is used for relays without a known location; half
+	// way around the planet.
+	unknownDistanceKm = 20000
+
+	earthRadiusKm = 6371
+)
+
+func (l location) known() bool {
+	return l.Country != "" || l.Latitude != 0 || l.Longitude != 0
+}
+
+// nearHints returns the locations to rank relays against: the requester
+// itself, plus the IP addresses given as near= parameters. These are
+// typically the relay addresses advertised by the requester's peers, so
+// that the chosen relay is close to both ends.
+func nearHints(r *http.Request) []location {
+	var ips []net.IP
+	if ip := net.ParseIP(clientHost(r)); ip != nil {
+		ips = append(ips, ip)
+	}
+	for _, hint := range r.URL.Query()["near"] {
+		if host, _, err := net.SplitHostPort(hint); err == nil {
+			hint = host
+		}
+		if ip := net.ParseIP(hint); ip != nil {
+			ips = append(ips, ip)
+		}
+		if len(ips) > maxNearHints {
+			break
+		}
+	}
+
+	var locs []location
+	for _, ip := range ips {
+		if loc := locateIP(ip); loc.known() {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+// clientHost returns the IP address of the client making the request,
+// taking the IP header into account if set.
+func clientHost(r *http.Request) string {
+	rhost := r.RemoteAddr
+	if ipHeader != "" {
+		hdr := r.Header.Get(ipHeader)
+		fields := strings.Split(hdr, ",")
+		if len(fields) > 0 {
+			rhost = strings.TrimSpace(fields[len(fields)-1])
+		}
+	}
+	if host, _, err := net.SplitHostPort(rhost); err == nil {
+		rhost = host
+	}
+	return rhost
+}
+
+// rankRelays returns the relays ordered from best to worst for clients at
+// the given locations, with those about as good as each other shuffled.
+func rankRelays(relays []*relay, near []location) []*relay {
+	type scored struct {
+		relay *relay
+		score float64
+	}
+	scores := make([]scored, len(relays))
+	for i, r := range relays {
+		scores[i] = scored{r, relayScore(r, near)}
+	}
+	slices.SortStableFunc(scores, func(a, b scored) int {
+		switch {
+		case a.score < b.score:
+			return -1
+		case a.score > b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+	res := make([]*relay, len(scores))
+	for i, s := range scores {
+		res[i] = s.relay
+	}
+	for start := 0; start < len(scores); {
+		end := start + 1
+		for end < len(scores) && scores[end].score-scores[start].score <= tieMarginKm {
+			end++
+		}
+		rand.Shuffle(res[start:end])
+		start = end
+	}
+	return res
+}
+
+func relayScore(r *relay, near []location) float64 {
+	if len(near) == 0 {
+		return 0
+	}
+
+	var dist float64
+	var sameAS int
+	for _, loc := range near {
+		if r.Location.known() {
+			dist += distanceKm(r.Location, loc)
+		} else {
+			dist += unknownDistanceKm
+		}
+		if r.Location.ASN != 0 && r.Location.ASN == loc.ASN {
+			sameAS++
+		}
+	}
+	n := float64(len(near))
+	return dist/n + loadPenaltyKm*relayLoad(r) - sameASBonusKm*float64(sameAS)/n
+}
+
+// relayLoad returns the current load of the relay, from zero (idle) to one
+// (fully loaded), based on the last retrieved statistics.
+func relayLoad(r *relay) float64 {
+	if r.Stats == nil {
+		// We don't know; assume middling.
+		return 0.5
+	}
+
+	// Sessions are cheap by themselves, but a thousand of them is a busy
+	// relay.
+	load := float64(r.Stats.ActiveSessions) / 1000
+	if global := r.Stats.Options.GlobalRate; global > 0 && len(r.Stats.Rates) > 1 {
+		// One minute rate in kbps vs the global limit in bytes/s.
+		rate := float64(r.Stats.Rates[1]) * 1000 / 8
+		load = max(load, rate/float64(global))
+	}
+	return min(load, 1)
+}
+
+// distanceKm returns the great circle distance between the two locations.
+func distanceKm(a, b location) float64 {
+	lat1, lon1 := a.Latitude*math.Pi/180, a.Longitude*math.Pi/180
+	lat2, lon2 := b.Latitude*math.Pi/180, b.Longitude*math.Pi/180
+	h := math.Pow(math.Sin((lat2-lat1)/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(min(h, 1)))
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// The accessControl restricts a discovery server to a private set of
+// devices. Announcements and lookups are accepted from devices presenting
+// a certificate for an allowed device ID, or presenting a certificate
+// together with the access token issued for that device ID as a bearer
+// token. Device tokens are derived from the server's access secret, so
+// that a leaked token is useless without the device's private key. A nil
+// *accessControl allows everything.
+type accessControl struct {
+	devices map[protocol.DeviceID]struct{}
+	secret  string
+}
+
+// newAccessControl returns an access control for the given device IDs,
+// device ID file (one ID per line, # for comments) and access secret, or
+// nil if none are given.
+func newAccessControl(ids []string, idsFile, secret string) (*accessControl, error) {
+	if len(ids) == 0 && idsFile == "" && secret == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	if idsFile != "" {
+		fd, err := os.Open(idsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		sc := bufio.NewScanner(fd)
+		for sc.Scan() {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			if line = strings.TrimSpace(line); line != "" {
+				ids = append(ids, line)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	acl := &accessControl{
+		devices: make(map[protocol.DeviceID]struct{}, len(ids)),
+		secret:  secret,
+	}
+	for _, idStr := range ids {
+		id, err := protocol.DeviceIDFromString(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("allowed device %q: %w", idStr, err)
+		}
+		acl.devices[id] = struct{}{}
+	}
+	return acl, nil
+}
+
+// allowedDevice returns true if the given device ID is on the allowlist.
+func (a *accessControl) allowedDevice(id protocol.DeviceID) bool {
+	if a == nil {
+		return true
+	}
+	_, ok := a.devices[id]
+	return ok
+}
+
+// allowedToken returns true if the request carries the access token
+// issued for the given device ID.
+func (a *accessControl) allowedToken(req *http.Request, id protocol.DeviceID) bool {
+	if a == nil {
+		return true
+	}
+	if a.secret == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && hmac.Equal([]byte(token), []byte(deviceToken(a.secret, id)))
+}
+
+// deviceToken returns the access token for the given device ID, derived
+// from the access secret.
+func deviceToken(secret string, id protocol.DeviceID) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(id[:])
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/tlsutil"
+)
+
+func TestNewAccessControl(t *testing.T) {
+	acl, err := newAccessControl(nil, "", "")
+	if err != nil || acl != nil {
+		t.Fatal("expected no access control", acl, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "allowed")
+	content := "# comment\n" + protocol.GlobalDeviceID.String() + " # trailing\n\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	acl, err = newAccessControl([]string{protocol.LocalDeviceID.String()}, file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acl.allowedDevice(protocol.LocalDeviceID) || !acl.allowedDevice(protocol.GlobalDeviceID) {
+		t.Error("allowed devices not allowed")
+	}
+	if acl.allowedDevice(protocol.EmptyDeviceID) {
+		t.Error("unknown device allowed")
+	}
+
+	if _, err := newAccessControl([]string{"foo"}, "", ""); err == nil {
+		t.Error("expected error for invalid device ID")
+	}
+}
+
+func TestAccessControlledAPI(t *testing.T) {
+	allowed, err := tlsutil.NewCertificateInMemory("allowed", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tlsutil.NewCertificateInMemory("other", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedID := protocol.NewDeviceID(allowed.Certificate[0])
+	otherID := protocol.NewDeviceID(other.Certificate[0])
+
+	acl, err := newAccessControl([]string{allowedID.String()}, "", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := newInMemoryStore(t.TempDir(), 0, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go db.Serve(ctx)
+	api := newAPISrv("127.0.0.1:0", tls.Certificate{}, db, nil, acl, true, false, 1000, 1000)
+	srv := httptest.NewServer(http.HandlerFunc(api.handler))
+	defer srv.Close()
+
+	do := func(method string, cert *tls.Certificate, token string) int {
+		t.Helper()
+		var body *strings.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(`{"addresses":["tcp://192.0.2.42:22000"]}`)
+		} else {
+			body = strings.NewReader("")
+		}
+		req, _ := http.NewRequest(method, srv.URL+"/?device="+allowedID.String(), body)
+		if cert != nil {
+			req.Header.Set("X-Tls-Client-Cert-Der-Base64", base64.StdEncoding.EncodeToString(cert.Certificate[0]))
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := do(http.MethodPost, &other, ""); code != http.StatusForbidden {
+		t.Error("announce from other device should be forbidden, got", code)
+	}
+	if code := do(http.MethodPost, &allowed, ""); code != http.StatusNoContent {
+		t.Error("announce from allowed device should succeed, got", code)
+	}
+	if code := do(http.MethodGet, nil, ""); code != http.StatusForbidden {
+		t.Error("anonymous lookup should be forbidden, got", code)
+	}
+	if code := do(http.MethodGet, &other, "wrong"); code != http.StatusForbidden {
+		t.Error("lookup from other device should be forbidden, got", code)
+	}
+	if code := do(http.MethodGet, &allowed, ""); code != http.StatusOK {
+		t.Error("lookup from allowed device should succeed, got", code)
+	}
+	if code := do(http.MethodGet, &other, deviceToken("s3cret", otherID)); code != http.StatusOK {
+		t.Error("lookup with device token should succeed, got", code)
+	}
+	if code := do(http.MethodPost, &other, deviceToken("s3cret", otherID)); code != http.StatusNoContent {
+		t.Error("announce with device token should succeed, got", code)
+	}
+
+	// The token is bound to the device ID it was issued for; it is
+	// useless without a certificate or with another device's certificate.
+	if code := do(http.MethodGet, nil, deviceToken("s3cret", otherID)); code != http.StatusForbidden {
+		t.Error("lookup with token but no certificate should be forbidden, got", code)
+	}
+	third, err := tlsutil.NewCertificateInMemory("third", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := do(http.MethodPost, &third, deviceToken("s3cret", otherID)); code != http.StatusForbidden {
+		t.Error("announce with another device's token should be forbidden, got", code)
+	}
+	if code := do(http.MethodGet, &other, "s3cret"); code != http.StatusForbidden {
+		t.Error("lookup with the raw secret should be forbidden, got", code)
+	}
+}
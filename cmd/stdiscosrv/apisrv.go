@@ -45,7 +45,8 @@ type apiSrv struct {
 	cert           tls.Certificate
 	db             database
 	listener       net.Listener
-	repl           replicator // optional
+	repl           replicator     // optional
+	acl            *accessControl // optional
 	useHTTP        bool
 	compression    bool
 	gzipWriters    sync.Pool
@@ -67,12 +68,13 @@ type contextKey int
 
 const idKey contextKey = iota
 
-func newAPISrv(addr string, cert tls.Certificate, db database, repl replicator, useHTTP, compression bool, desiredUnseenNotFoundRate, desiredSeenNotFoundRate float64) *apiSrv {
+func newAPISrv(addr string, cert tls.Certificate, db database, repl replicator, acl *accessControl, useHTTP, compression bool, desiredUnseenNotFoundRate, desiredSeenNotFoundRate float64) *apiSrv {
 	return &apiSrv{
 		addr:        addr,
 		cert:        cert,
 		db:          db,
 		repl:        repl,
+		acl:         acl,
 		useHTTP:     useHTTP,
 		compression: compression,
 		seenTracker: &retryAfterTracker{
@@ -194,6 +196,14 @@ func (s *apiSrv) handler(w http.ResponseWriter, req *http.Request) {
 func (s *apiSrv) handleGET(w http.ResponseWriter, req *http.Request) {
 	reqID := req.Context().Value(idKey).(requestID)
 
+	if !s.lookupAllowed(req) {
+		slog.Debug("Lookup not allowed", "id", reqID)
+		lookupRequestsTotal.WithLabelValues("forbidden").Inc()
+		w.Header().Set("Retry-After", errorRetryAfterString())
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	deviceID, err := protocol.DeviceIDFromString(req.URL.Query().Get("device"))
 	if err != nil {
 		slog.Debug("Request with bad device param", "id", reqID, "error", err)
@@ -274,6 +284,14 @@ func (s *apiSrv) handlePOST(remoteAddr *net.TCPAddr, w http.ResponseWriter, req
 
 	deviceID := protocol.NewDeviceID(rawCert)
 
+	if !s.acl.allowedDevice(deviceID) && !s.acl.allowedToken(req, deviceID) {
+		slog.Debug("Announcement not allowed", "id", reqID, "device", deviceID)
+		announceRequestsTotal.WithLabelValues("forbidden").Inc()
+		w.Header().Set("Retry-After", errorRetryAfterString())
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	addresses := fixupAddresses(remoteAddr, ann.Addresses)
 	if len(addresses) == 0 {
 		slog.Debug("Request without addresses", "id", reqID, "error", err)
@@ -326,6 +344,21 @@ func (s *apiSrv) handleAnnounce(deviceID protocol.DeviceID, addresses []string)
 	return s.db.merge(&deviceID, dbAddrs, seen)
 }
 
+// lookupAllowed returns true if the request is from an allowed device, or
+// from a device carrying its access token. Lookups are always allowed when
+// there is no access control.
+func (s *apiSrv) lookupAllowed(req *http.Request) bool {
+	if s.acl == nil {
+		return true
+	}
+	rawCert, err := s.certificateBytes(req)
+	if err != nil {
+		return false
+	}
+	deviceID := protocol.NewDeviceID(rawCert)
+	return s.acl.allowedDevice(deviceID) || s.acl.allowedToken(req, deviceID)
+}
+
 func handlePing(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -45,6 +45,7 @@ type database interface {
 	put(key *protocol.DeviceID, rec *discosrv.DatabaseRecord) error
 	merge(key *protocol.DeviceID, addrs []*discosrv.DatabaseAddress, seen int64) error
 	get(key *protocol.DeviceID) (*discosrv.DatabaseRecord, error)
+	iterate(fn func(key protocol.DeviceID, rec *discosrv.DatabaseRecord) bool) error
 }
 
 type inMemoryStore struct {
@@ -146,6 +147,23 @@ func (s *inMemoryStore) get(key *protocol.DeviceID) (*discosrv.DatabaseRecord, e
 	return rec, nil
 }
 
+// iterate calls fn for each record with unexpired addresses, until fn
+// returns false.
+func (s *inMemoryStore) iterate(fn func(key protocol.DeviceID, rec *discosrv.DatabaseRecord) bool) error {
+	now := s.clock.Now()
+	s.m.Range(func(key protocol.DeviceID, rec *discosrv.DatabaseRecord) bool {
+		addresses, _ := expire(rec.Addresses, now)
+		if len(addresses) == 0 {
+			return true
+		}
+		return fn(key, &discosrv.DatabaseRecord{
+			Addresses: addresses,
+			Seen:      rec.Seen,
+		})
+	})
+	return nil
+}
+
 func (s *inMemoryStore) Serve(ctx context.Context) error {
 	if s.flushInterval <= 0 {
 		<-ctx.Done()
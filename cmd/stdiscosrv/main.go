@@ -69,8 +69,9 @@ type CLI struct {
 
 	ShutdownDelay float64 `help:"Time to wait before shutdown after receiving a shutdown signal (s)" env:"DISCOVERY_SHUTDOWN_DELAY"`
 
+	DBBackend       string        `group:"Database" help:"Database backend (memory, sqlite)" default:"memory" enum:"memory,sqlite" env:"DISCOVERY_DB_BACKEND"`
 	DBDir           string        `group:"Database" help:"Database directory" default:"." env:"DISCOVERY_DB_DIR"`
-	DBFlushInterval time.Duration `group:"Database" help:"Interval between database flushes" default:"5m" env:"DISCOVERY_DB_FLUSH_INTERVAL"`
+	DBFlushInterval time.Duration `group:"Database" help:"Interval between database flushes (memory backend)" default:"5m" env:"DISCOVERY_DB_FLUSH_INTERVAL"`
 
 	DBS3Endpoint    string `name:"db-s3-endpoint" group:"Database (S3 backup)" hidden:"true" help:"S3 endpoint for database" env:"DISCOVERY_DB_S3_ENDPOINT"`
 	DBS3Region      string `name:"db-s3-region" group:"Database (S3 backup)" hidden:"true" help:"S3 region for database" env:"DISCOVERY_DB_S3_REGION"`
@@ -78,8 +79,16 @@ type CLI struct {
 	DBS3AccessKeyID string `name:"db-s3-access-key-id" group:"Database (S3 backup)" hidden:"true" help:"S3 access key ID for database" env:"DISCOVERY_DB_S3_ACCESS_KEY_ID"`
 	DBS3SecretKey   string `name:"db-s3-secret-key" group:"Database (S3 backup)" hidden:"true" help:"S3 secret key for database" env:"DISCOVERY_DB_S3_SECRET_KEY"`
 
+	AllowedDevices     []string `group:"Access control" help:"Device IDs allowed to announce and look up, comma separated" env:"DISCOVERY_ALLOWED_DEVICES"`
+	AllowedDevicesFile string   `group:"Access control" help:"File with device IDs allowed to announce and look up, one per line" env:"DISCOVERY_ALLOWED_DEVICES_FILE"`
+	AccessSecret       string   `group:"Access control" help:"Secret from which per-device access tokens are derived" env:"DISCOVERY_ACCESS_SECRET"`
+	IssueToken         string   `group:"Access control" help:"Print the access token for the given device ID and exit" placeholder:"DEVICEID"`
+
 	AMQPAddress string `group:"AMQP replication" hidden:"true" help:"Address to AMQP broker" env:"DISCOVERY_AMQP_ADDRESS"`
 
+	Replicate         []string `group:"Peer replication" help:"Replication peers, as deviceID@host:port, comma separated" env:"DISCOVERY_REPLICATE"`
+	ReplicationListen string   `group:"Peer replication" help:"Replication listen address" default:":19200" env:"DISCOVERY_REPLICATION_LISTEN"`
+
 	Debug   bool `short:"d" help:"Print debug output" env:"DISCOVERY_DEBUG"`
 	Version bool `short:"v" help:"Print version and exit"`
 }
@@ -97,10 +106,35 @@ func main() {
 		fmt.Println(build.LongVersionFor("stdiscosrv"))
 		return
 	}
+	if cli.IssueToken != "" {
+		id, err := protocol.DeviceIDFromString(cli.IssueToken)
+		if err != nil {
+			slog.Error("Failed to parse device ID", "error", err)
+			os.Exit(1)
+		}
+		if cli.AccessSecret == "" {
+			slog.Error("Issuing access tokens requires an access secret")
+			os.Exit(1)
+		}
+		fmt.Println(deviceToken(cli.AccessSecret, id))
+		return
+	}
 	slog.Info(build.LongVersionFor("stdiscosrv"))
 
+	replPeers, err := parseReplicationPeers(cli.Replicate)
+	if err != nil {
+		slog.Error("Failed to parse replication peers", "error", err)
+		os.Exit(1)
+	}
+	if len(replPeers) > 0 && cli.AMQPAddress != "" {
+		slog.Error("Peer replication and AMQP replication are mutually exclusive")
+		os.Exit(1)
+	}
+
+	// The certificate is needed for HTTPS and for authenticating to
+	// replication peers.
 	var cert tls.Certificate
-	if !cli.HTTP {
+	if !cli.HTTP || len(replPeers) > 0 {
 		var err error
 		cert, err = tls.LoadX509KeyPair(cli.Cert, cli.Key)
 		if os.IsNotExist(err) {
@@ -118,6 +152,13 @@ func main() {
 		slog.Info("Loaded certificate keypair", "deviceId", devID.String())
 	}
 
+	// If configured, restrict access to a private set of devices.
+	acl, err := newAccessControl(cli.AllowedDevices, cli.AllowedDevicesFile, cli.AccessSecret)
+	if err != nil {
+		slog.Error("Failed to set up access control", "error", err)
+		os.Exit(1)
+	}
+
 	// Root of the service tree.
 	main := suture.New("main", suture.Spec{
 		PassThroughPanics: true,
@@ -126,7 +167,6 @@ func main() {
 
 	// If configured, use blob storage for database backups.
 	var blobs blob.Store
-	var err error
 	if cli.DBS3Endpoint != "" {
 		blobs, err = s3.NewSession(cli.DBS3Endpoint, cli.DBS3Region, cli.DBS3Bucket, cli.DBS3AccessKeyID, cli.DBS3SecretKey)
 	}
@@ -136,8 +176,21 @@ func main() {
 	}
 
 	// Start the database.
-	db := newInMemoryStore(cli.DBDir, cli.DBFlushInterval, blobs)
-	main.Add(db)
+	var db database
+	switch cli.DBBackend {
+	case "sqlite":
+		sdb, err := newSQLiteStore(cli.DBDir)
+		if err != nil {
+			slog.Error("Failed to open database", "error", err)
+			os.Exit(1)
+		}
+		main.Add(sdb)
+		db = sdb
+	default:
+		mdb := newInMemoryStore(cli.DBDir, cli.DBFlushInterval, blobs)
+		main.Add(mdb)
+		db = mdb
+	}
 
 	// If we have an AMQP broker for replication, start that
 	var repl replicator
@@ -148,8 +201,15 @@ func main() {
 		repl = kr
 	}
 
+	// If we have replication peers, replicate directly to and from them
+	if len(replPeers) > 0 {
+		pr := newPeerReplicator(cli.ReplicationListen, replPeers, cert, db)
+		main.Add(pr)
+		repl = pr
+	}
+
 	// Start the main API server.
-	qs := newAPISrv(cli.Listen, cert, db, repl, cli.HTTP, cli.Compression, cli.DesiredUnseenNotFoundRate, cli.DesiredSeenNotFoundRate)
+	qs := newAPISrv(cli.Listen, cert, db, repl, acl, cli.HTTP, cli.Compression, cli.DesiredUnseenNotFoundRate, cli.DesiredSeenNotFoundRate)
 	main.Add(qs)
 
 	// If we have a metrics port configured, start a metrics handler.
@@ -0,0 +1,385 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thejerf/suture/v4"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/syncthing/syncthing/internal/gen/discosrv"
+	"github.com/syncthing/syncthing/internal/protoutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	replicationWriteTimeout      = 30 * time.Second
+	replicationMaxRecordLen      = 1 << 20
+	replicationHeartbeatInterval = time.Minute
+
+	// The receiving side doesn't wait forever for a peer to complete the
+	// handshake or to send the next record, heartbeats included.
+	replicationHandshakeTimeout = 10 * time.Second
+	replicationReadTimeout      = 3 * replicationHeartbeatInterval
+)
+
+// A replicationPeer is another discovery server that we replicate to and
+// accept replication from.
+type replicationPeer struct {
+	id   protocol.DeviceID
+	addr string
+}
+
+// parseReplicationPeers parses a list of "deviceID@host:port" strings.
+func parseReplicationPeers(peers []string) ([]replicationPeer, error) {
+	var res []replicationPeer
+	for _, p := range peers {
+		idStr, addr, ok := strings.Cut(p, "@")
+		if !ok {
+			return nil, fmt.Errorf("replication peer %q: missing device ID", p)
+		}
+		id, err := protocol.DeviceIDFromString(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("replication peer %q: %w", p, err)
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("replication peer %q: %w", p, err)
+		}
+		res = append(res, replicationPeer{id: id, addr: addr})
+	}
+	return res, nil
+}
+
+// The peerReplicator streams announcements to a set of peer discovery
+// servers and accepts the same from them, without an intermediate message
+// broker. Connections are TLS with both sides presenting their discovery
+// server certificate; only the configured peer device IDs are accepted.
+// Each time a sender (re)connects it first sends everything currently in
+// the database, so that a peer that was down or unreachable catches up.
+type peerReplicator struct {
+	suture.Service
+
+	senders []*replicationSender
+}
+
+func newPeerReplicator(listen string, peers []replicationPeer, cert tls.Certificate, db database) *peerReplicator {
+	svc := suture.New("peerReplicator", suture.Spec{PassThroughPanics: true})
+
+	var senders []*replicationSender
+	ids := make([]protocol.DeviceID, 0, len(peers))
+	for _, peer := range peers {
+		sender := &replicationSender{
+			peer:   peer,
+			cert:   cert,
+			db:     db,
+			outbox: make(chan *discosrv.ReplicationRecord, replicationOutboxSize),
+		}
+		svc.Add(sender)
+		senders = append(senders, sender)
+		ids = append(ids, peer.id)
+	}
+
+	svc.Add(&replicationListener{
+		addr:             listen,
+		cert:             cert,
+		allowed:          ids,
+		db:               db,
+		handshakeTimeout: replicationHandshakeTimeout,
+		readTimeout:      replicationReadTimeout,
+	})
+
+	return &peerReplicator{
+		Service: svc,
+		senders: senders,
+	}
+}
+
+func (r *peerReplicator) send(key *protocol.DeviceID, ps []*discosrv.DatabaseAddress, seen int64) {
+	item := &discosrv.ReplicationRecord{
+		Key:       key[:],
+		Addresses: ps,
+		Seen:      seen,
+	}
+	for _, s := range r.senders {
+		s.send(item)
+	}
+}
+
+type replicationSender struct {
+	peer   replicationPeer
+	cert   tls.Certificate
+	db     database
+	outbox chan *discosrv.ReplicationRecord
+}
+
+func (s *replicationSender) Serve(ctx context.Context) error {
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			Certificates:       []tls.Certificate{s.cert},
+			MinVersion:         tls.VersionTLS13,
+			InsecureSkipVerify: true, // we verify the device ID below
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", s.peer.addr)
+	if err != nil {
+		return fmt.Errorf("replication dial: %w", err)
+	}
+	defer conn.Close()
+	tlsConn := conn.(*tls.Conn)
+	if err := checkPeerID(tlsConn, []protocol.DeviceID{s.peer.id}); err != nil {
+		return fmt.Errorf("replication dial: %w", err)
+	}
+	slog.InfoContext(ctx, "Connected to replication peer", "peer", s.peer.id.Short(), "address", s.peer.addr)
+
+	// Close the connection when we're cancelled, to interrupt any pending
+	// write.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	bw := bufio.NewWriter(conn)
+	var buf []byte
+
+	// Catch up the peer on everything we know.
+	nr := 0
+	var writeErr error
+	err = s.db.iterate(func(key protocol.DeviceID, rec *discosrv.DatabaseRecord) bool {
+		buf, writeErr = writeReplicationRecord(bw, conn, buf, &discosrv.ReplicationRecord{
+			Key:       key[:],
+			Addresses: rec.Addresses,
+			Seen:      rec.Seen,
+		})
+		nr++
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		replicationSendsTotal.WithLabelValues("error").Inc()
+		return fmt.Errorf("replication catch-up: %w", err)
+	}
+	slog.InfoContext(ctx, "Sent replication catch-up", "peer", s.peer.id.Short(), "records", nr)
+
+	heartbeat := time.NewTicker(replicationHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-heartbeat.C:
+			// An empty record keeps the peer from timing out the
+			// connection when there's nothing to replicate.
+			if err := writeReplicationHeartbeat(bw, conn); err != nil {
+				replicationSendsTotal.WithLabelValues("error").Inc()
+				return fmt.Errorf("replication heartbeat: %w", err)
+			}
+
+		case rec := <-s.outbox:
+			buf, err = writeReplicationRecord(bw, conn, buf, rec)
+			// Flush when we've drained the queue, so that bursts are
+			// batched but nothing lingers in the buffer.
+			if err == nil && len(s.outbox) == 0 {
+				err = bw.Flush()
+			}
+			if err != nil {
+				replicationSendsTotal.WithLabelValues("error").Inc()
+				return fmt.Errorf("replication send: %w", err)
+			}
+			replicationSendsTotal.WithLabelValues("success").Inc()
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *replicationSender) String() string {
+	return fmt.Sprintf("replicationSender(%s@%s)", s.peer.id.Short(), s.peer.addr)
+}
+
+func (s *replicationSender) send(rec *discosrv.ReplicationRecord) {
+	// The send should never block. Anything dropped while the peer is
+	// unreachable is sent as part of the catch-up on reconnect.
+	select {
+	case s.outbox <- rec:
+	default:
+		replicationSendsTotal.WithLabelValues("drop").Inc()
+	}
+}
+
+type replicationListener struct {
+	addr    string
+	cert    tls.Certificate
+	allowed []protocol.DeviceID
+	db      database
+
+	handshakeTimeout time.Duration // can be overridden for testing
+	readTimeout      time.Duration // can be overridden for testing
+}
+
+func (l *replicationListener) Serve(ctx context.Context) error {
+	tlsCfg := &tls.Config{
+		Certificates:       []tls.Certificate{l.cert},
+		ClientAuth:         tls.RequireAnyClientCert,
+		MinVersion:         tls.VersionTLS13,
+		InsecureSkipVerify: true, // we verify the device ID on accept
+	}
+	lc := &net.ListenConfig{}
+	rawList, err := lc.Listen(ctx, "tcp", l.addr)
+	if err != nil {
+		return fmt.Errorf("replication listen: %w", err)
+	}
+	list := tls.NewListener(rawList, tlsCfg)
+	defer list.Close()
+	stop := context.AfterFunc(ctx, func() { list.Close() })
+	defer stop()
+	slog.InfoContext(ctx, "Replication listener started", "address", rawList.Addr())
+
+	for {
+		conn, err := list.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("replication accept: %w", err)
+		}
+		go l.handle(ctx, conn.(*tls.Conn))
+	}
+}
+
+func (l *replicationListener) handle(ctx context.Context, conn *tls.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	hctx, cancel := context.WithTimeout(ctx, l.handshakeTimeout)
+	err := conn.HandshakeContext(hctx)
+	cancel()
+	if err != nil {
+		slog.WarnContext(ctx, "Replication handshake failed", "remote", conn.RemoteAddr(), "error", err)
+		return
+	}
+	if err := checkPeerID(conn, l.allowed); err != nil {
+		slog.WarnContext(ctx, "Rejected replication connection", "remote", conn.RemoteAddr(), "error", err)
+		return
+	}
+
+	br := bufio.NewReader(conn)
+	var buf []byte
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(l.readTimeout)); err != nil {
+			return
+		}
+		var n uint32
+		if err := binary.Read(br, binary.BigEndian, &n); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Replication read failed", "remote", conn.RemoteAddr(), "error", err)
+			}
+			return
+		}
+		if n == 0 {
+			// Heartbeat
+			continue
+		}
+		if n > replicationMaxRecordLen {
+			slog.WarnContext(ctx, "Replication record too large", "remote", conn.RemoteAddr(), "size", n)
+			return
+		}
+		if int(n) > len(buf) {
+			buf = make([]byte, n)
+		}
+		if _, err := io.ReadFull(br, buf[:n]); err != nil {
+			slog.WarnContext(ctx, "Replication read failed", "remote", conn.RemoteAddr(), "error", err)
+			return
+		}
+
+		var rec discosrv.ReplicationRecord
+		if err := proto.Unmarshal(buf[:n], &rec); err != nil {
+			replicationRecvsTotal.WithLabelValues("error").Inc()
+			slog.WarnContext(ctx, "Replication unmarshal failed", "remote", conn.RemoteAddr(), "error", err)
+			return
+		}
+		id, err := protocol.DeviceIDFromBytes(rec.Key)
+		if err != nil {
+			slog.Warn("Failed to parse replication device ID", "error", err)
+			replicationRecvsTotal.WithLabelValues("error").Inc()
+			continue
+		}
+
+		// The merge requires sorted addresses.
+		slices.SortFunc(rec.Addresses, Cmp)
+		if err := l.db.merge(&id, rec.Addresses, rec.Seen); err != nil {
+			replicationRecvsTotal.WithLabelValues("error").Inc()
+			slog.ErrorContext(ctx, "Replication database merge failed", "error", err)
+			return
+		}
+
+		replicationRecvsTotal.WithLabelValues("success").Inc()
+	}
+}
+
+func (l *replicationListener) String() string {
+	return fmt.Sprintf("replicationListener(%q)", l.addr)
+}
+
+// checkPeerID verifies that the remote side of the connection presented a
+// certificate for one of the allowed device IDs.
+func checkPeerID(conn *tls.Conn, allowed []protocol.DeviceID) error {
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no peer certificate")
+	}
+	id := protocol.NewDeviceID(certs[0].Raw)
+	if !slices.Contains(allowed, id) {
+		return fmt.Errorf("unknown peer device ID %s", id)
+	}
+	return nil
+}
+
+// writeReplicationRecord writes the length prefixed record to bw, using
+// and returning buf as the marshalling buffer.
+func writeReplicationRecord(bw *bufio.Writer, conn net.Conn, buf []byte, rec *discosrv.ReplicationRecord) ([]byte, error) {
+	size := proto.Size(rec)
+	if size+4 > len(buf) {
+		buf = make([]byte, size+4)
+	}
+	n, err := protoutil.MarshalTo(buf[4:], rec)
+	if err != nil {
+		return buf, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(n))
+	if err := conn.SetWriteDeadline(time.Now().Add(replicationWriteTimeout)); err != nil {
+		return buf, err
+	}
+	_, err = bw.Write(buf[:n+4])
+	return buf, err
+}
+
+// writeReplicationHeartbeat writes and flushes an empty record.
+func writeReplicationHeartbeat(bw *bufio.Writer, conn net.Conn) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(replicationWriteTimeout)); err != nil {
+		return err
+	}
+	var empty [4]byte
+	if _, err := bw.Write(empty[:]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,190 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/gen/discosrv"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/tlsutil"
+)
+
+func TestParseReplicationPeers(t *testing.T) {
+	id := protocol.LocalDeviceID.String()
+	peers, err := parseReplicationPeers([]string{id + "@192.0.2.42:19200"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].id != protocol.LocalDeviceID || peers[0].addr != "192.0.2.42:19200" {
+		t.Error("unexpected result", peers)
+	}
+
+	for _, bad := range []string{"192.0.2.42:19200", "foo@192.0.2.42:19200", id + "@192.0.2.42"} {
+		if _, err := parseReplicationPeers([]string{bad}); err == nil {
+			t.Error("expected error for", bad)
+		}
+	}
+}
+
+func TestPeerReplication(t *testing.T) {
+	certA, err := tlsutil.NewCertificateInMemory("a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certB, err := tlsutil.NewCertificateInMemory("b", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idA := protocol.NewDeviceID(certA.Certificate[0])
+	idB := protocol.NewDeviceID(certB.Certificate[0])
+
+	addrA, addrB := freeAddr(t), freeAddr(t)
+	dbA := newInMemoryStore(t.TempDir(), 0, nil)
+	dbB := newInMemoryStore(t.TempDir(), 0, nil)
+
+	// A record that exists before the peers connect, to be sent in the
+	// catch-up.
+	expires := time.Now().Add(time.Hour).UnixNano()
+	before := []*discosrv.DatabaseAddress{{Address: "tcp://192.0.2.1:22000", Expires: expires}}
+	if err := dbA.put(&protocol.LocalDeviceID, &discosrv.DatabaseRecord{Addresses: before, Seen: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	replA := newPeerReplicator(addrA, []replicationPeer{{id: idB, addr: addrB}}, certA, dbA)
+	replB := newPeerReplicator(addrB, []replicationPeer{{id: idA, addr: addrA}}, certB, dbB)
+	go replA.Serve(ctx)
+	go replB.Serve(ctx)
+
+	// A new announcement on A, sent as it happens.
+	after := []*discosrv.DatabaseAddress{{Address: "tcp://192.0.2.2:22000", Expires: expires}}
+	waitFor(t, func() bool {
+		replA.send(&protocol.GlobalDeviceID, after, 2)
+		rec, _ := dbB.get(&protocol.GlobalDeviceID)
+		return len(rec.Addresses) == 1
+	})
+
+	rec, err := dbB.get(&protocol.LocalDeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 1 || rec.Addresses[0].Address != "tcp://192.0.2.1:22000" {
+		t.Error("catch-up record not replicated", rec.Addresses)
+	}
+}
+
+func TestReplicationListenerTimeouts(t *testing.T) {
+	certA, err := tlsutil.NewCertificateInMemory("a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certB, err := tlsutil.NewCertificateInMemory("b", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	l := &replicationListener{
+		addr:    addr,
+		cert:    certA,
+		allowed: []protocol.DeviceID{protocol.NewDeviceID(certB.Certificate[0])},
+		db:      newInMemoryStore(t.TempDir(), 0, nil),
+
+		handshakeTimeout: 200 * time.Millisecond,
+		readTimeout:      200 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Serve(ctx)
+
+	dial := func() net.Conn {
+		t.Helper()
+		var conn net.Conn
+		waitFor(t, func() bool {
+			conn, err = net.Dial("tcp", addr)
+			return err == nil
+		})
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	dialTLS := func() *tls.Conn {
+		t.Helper()
+		conn := tls.Client(dial(), &tls.Config{
+			Certificates:       []tls.Certificate{certB},
+			MinVersion:         tls.VersionTLS13,
+			InsecureSkipVerify: true,
+		})
+		if err := conn.Handshake(); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	// closedByPeer returns true if the connection is closed from the other
+	// side within a few seconds.
+	closedByPeer := func(conn net.Conn) bool {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err := conn.Read(make([]byte, 1))
+		var netErr net.Error
+		return err != nil && !(errors.As(err, &netErr) && netErr.Timeout())
+	}
+
+	t.Run("no handshake", func(t *testing.T) {
+		if !closedByPeer(dial()) {
+			t.Error("connection without handshake kept open")
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		if !closedByPeer(dialTLS()) {
+			t.Error("idle connection kept open")
+		}
+	})
+
+	t.Run("heartbeats", func(t *testing.T) {
+		conn := dialTLS()
+		var empty [4]byte
+		for range 5 {
+			if _, err := conn.Write(empty[:]); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		var netErr net.Error
+		if _, err := conn.Read(make([]byte, 1)); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Error("connection with heartbeats closed:", err)
+		}
+	})
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func waitFor(t *testing.T, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/gen/discosrv"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const sqliteExpireInterval = time.Minute
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS devices (
+		device BLOB NOT NULL PRIMARY KEY,
+		seen INTEGER NOT NULL
+	) STRICT`,
+	`CREATE INDEX IF NOT EXISTS devices_seen ON devices (seen)`,
+	`CREATE TABLE IF NOT EXISTS addresses (
+		device BLOB NOT NULL REFERENCES devices(device) ON DELETE CASCADE,
+		address TEXT NOT NULL,
+		expires INTEGER NOT NULL,
+		PRIMARY KEY (device, address)
+	) STRICT, WITHOUT ROWID`,
+	`CREATE INDEX IF NOT EXISTS addresses_expires ON addresses (expires)`,
+}
+
+// The sqliteStore is a database backed by an SQLite file on disk. Every
+// announcement is persisted as it happens, so there is no flushing and
+// nothing is lost on a crash. Expired addresses and devices not seen for a
+// week are removed periodically using the indexes on expiry and last seen
+// time.
+type sqliteStore struct {
+	sql   *sql.DB
+	clock clock
+}
+
+func newSQLiteStore(dir string) (*sqliteStore, error) {
+	pathURL := url.URL{
+		Scheme:   "file",
+		Path:     filepath.ToSlash(filepath.Join(dir, "records.sqlite")),
+		RawQuery: sqliteOptions,
+	}
+	db, err := sql.Open(sqliteDriver, pathURL.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	s := &sqliteStore{
+		sql:   db,
+		clock: defaultClock{},
+	}
+	var nr int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM devices`).Scan(&nr); err != nil {
+		db.Close()
+		return nil, err
+	}
+	slog.Info("Loaded database", "records", nr)
+	if err := s.expireAndCalculateStatistics(); err != nil {
+		slog.Error("Failed to expire database", "error", err)
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) put(key *protocol.DeviceID, rec *discosrv.DatabaseRecord) (err error) {
+	t0 := time.Now()
+	defer func() {
+		databaseOperations.WithLabelValues(dbOpPut, dbResult(err)).Inc()
+		databaseOperationSeconds.WithLabelValues(dbOpPut).Observe(time.Since(t0).Seconds())
+	}()
+
+	tx, err := s.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO devices (device, seen) VALUES (?, ?)
+		ON CONFLICT (device) DO UPDATE SET seen = excluded.seen`, key[:], rec.Seen); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM addresses WHERE device = ?`, key[:]); err != nil {
+		return err
+	}
+	for _, addr := range rec.Addresses {
+		if _, err := tx.Exec(`INSERT INTO addresses (device, address, expires) VALUES (?, ?, ?)
+			ON CONFLICT (device, address) DO UPDATE SET expires = max(expires, excluded.expires)`, key[:], addr.Address, addr.Expires); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) merge(key *protocol.DeviceID, addrs []*discosrv.DatabaseAddress, seen int64) (err error) {
+	t0 := time.Now()
+	defer func() {
+		databaseOperations.WithLabelValues(dbOpMerge, dbResult(err)).Inc()
+		databaseOperationSeconds.WithLabelValues(dbOpMerge).Observe(time.Since(t0).Seconds())
+	}()
+
+	tx, err := s.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO devices (device, seen) VALUES (?, ?)
+		ON CONFLICT (device) DO UPDATE SET seen = max(seen, excluded.seen)`, key[:], seen); err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if _, err := tx.Exec(`INSERT INTO addresses (device, address, expires) VALUES (?, ?, ?)
+			ON CONFLICT (device, address) DO UPDATE SET expires = max(expires, excluded.expires)`, key[:], addr.Address, addr.Expires); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) get(key *protocol.DeviceID) (*discosrv.DatabaseRecord, error) {
+	t0 := time.Now()
+	defer func() {
+		databaseOperationSeconds.WithLabelValues(dbOpGet).Observe(time.Since(t0).Seconds())
+	}()
+
+	rec := &discosrv.DatabaseRecord{}
+	err := s.sql.QueryRow(`SELECT seen FROM devices WHERE device = ?`, key[:]).Scan(&rec.Seen)
+	if errors.Is(err, sql.ErrNoRows) {
+		databaseOperations.WithLabelValues(dbOpGet, dbResNotFound).Inc()
+		return rec, nil
+	} else if err != nil {
+		databaseOperations.WithLabelValues(dbOpGet, dbResError).Inc()
+		return nil, err
+	}
+
+	rows, err := s.sql.Query(`SELECT address, expires FROM addresses
+		WHERE device = ? AND expires >= ? ORDER BY address`, key[:], s.clock.Now().UnixNano())
+	if err != nil {
+		databaseOperations.WithLabelValues(dbOpGet, dbResError).Inc()
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		addr := &discosrv.DatabaseAddress{}
+		if err := rows.Scan(&addr.Address, &addr.Expires); err != nil {
+			databaseOperations.WithLabelValues(dbOpGet, dbResError).Inc()
+			return nil, err
+		}
+		rec.Addresses = append(rec.Addresses, addr)
+	}
+	if err := rows.Err(); err != nil {
+		databaseOperations.WithLabelValues(dbOpGet, dbResError).Inc()
+		return nil, err
+	}
+
+	databaseOperations.WithLabelValues(dbOpGet, dbResSuccess).Inc()
+	return rec, nil
+}
+
+// iterate calls fn for each record with unexpired addresses, until fn
+// returns false.
+func (s *sqliteStore) iterate(fn func(key protocol.DeviceID, rec *discosrv.DatabaseRecord) bool) error {
+	rows, err := s.sql.Query(`SELECT d.device, d.seen, a.address, a.expires FROM devices d
+		INNER JOIN addresses a ON a.device = d.device
+		WHERE a.expires >= ? ORDER BY d.device, a.address`, s.clock.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var cur protocol.DeviceID
+	var rec *discosrv.DatabaseRecord
+	for rows.Next() {
+		var keyBs []byte
+		var seen int64
+		addr := &discosrv.DatabaseAddress{}
+		if err := rows.Scan(&keyBs, &seen, &addr.Address, &addr.Expires); err != nil {
+			return err
+		}
+		key, err := protocol.DeviceIDFromBytes(keyBs)
+		if err != nil {
+			return err
+		}
+		if rec != nil && key != cur {
+			if !fn(cur, rec) {
+				return nil
+			}
+			rec = nil
+		}
+		if rec == nil {
+			cur = key
+			rec = &discosrv.DatabaseRecord{Seen: seen}
+		}
+		rec.Addresses = append(rec.Addresses, addr)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if rec != nil {
+		fn(cur, rec)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Serve(ctx context.Context) error {
+	defer s.sql.Close()
+
+	t := time.NewTicker(sqliteExpireInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := s.expireAndCalculateStatistics(); err != nil {
+				slog.ErrorContext(ctx, "Failed to expire database", "error", err)
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// expireAndCalculateStatistics removes expired addresses and devices not
+// seen within the last week, then updates the key statistics. The
+// categories match those of the in-memory store.
+func (s *sqliteStore) expireAndCalculateStatistics() error {
+	now := s.clock.Now()
+	cutoff24h := now.Add(-24 * time.Hour).UnixNano()
+	cutoff1w := now.Add(-7 * 24 * time.Hour).UnixNano()
+
+	if _, err := s.sql.Exec(`DELETE FROM addresses WHERE expires < ?`, now.UnixNano()); err != nil {
+		return err
+	}
+	if _, err := s.sql.Exec(`DELETE FROM devices WHERE seen < ?
+		AND NOT EXISTS (SELECT 1 FROM addresses a WHERE a.device = devices.device)`, cutoff1w); err != nil {
+		return err
+	}
+
+	// The same fast and loose matching on address strings as in the
+	// in-memory store.
+	var current, currentIPv4, currentIPv6, currentIPv6GUA, last24h, last1w int
+	err := s.sql.QueryRow(`SELECT
+			(SELECT COUNT(DISTINCT device) FROM addresses),
+			(SELECT COUNT(DISTINCT device) FROM addresses WHERE instr(address, '[') = 0),
+			(SELECT COUNT(DISTINCT device) FROM addresses WHERE instr(address, '[') > 0),
+			(SELECT COUNT(DISTINCT device) FROM addresses WHERE instr(address, '[2') > 0),
+			(SELECT COUNT(*) FROM devices d WHERE d.seen > ?1
+				AND NOT EXISTS (SELECT 1 FROM addresses a WHERE a.device = d.device)),
+			(SELECT COUNT(*) FROM devices d WHERE d.seen > ?2 AND d.seen <= ?1
+				AND NOT EXISTS (SELECT 1 FROM addresses a WHERE a.device = d.device))`,
+		cutoff24h, cutoff1w).Scan(&current, &currentIPv4, &currentIPv6, &currentIPv6GUA, &last24h, &last1w)
+	if err != nil {
+		return err
+	}
+
+	databaseKeys.WithLabelValues("current").Set(float64(current))
+	databaseKeys.WithLabelValues("currentIPv4").Set(float64(currentIPv4))
+	databaseKeys.WithLabelValues("currentIPv6").Set(float64(currentIPv6))
+	databaseKeys.WithLabelValues("currentIPv6GUA").Set(float64(currentIPv6GUA))
+	databaseKeys.WithLabelValues("last24h").Set(float64(last24h))
+	databaseKeys.WithLabelValues("last1w").Set(float64(last1w))
+	databaseStatisticsSeconds.Set(time.Since(now).Seconds())
+	return nil
+}
+
+func dbResult(err error) string {
+	if err != nil {
+		return dbResError
+	}
+	return dbResSuccess
+}
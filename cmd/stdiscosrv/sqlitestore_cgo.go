@@ -0,0 +1,18 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build cgo
+
+package main
+
+import (
+	_ "github.com/mattn/go-sqlite3" // register sqlite3 database driver
+)
+
+const (
+	sqliteDriver  = "sqlite3"
+	sqliteOptions = "_fk=true&_journal_mode=WAL&_busy_timeout=5000&_sync=1&_txlock=immediate"
+)
@@ -0,0 +1,104 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/gen/discosrv"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestSQLiteDatabaseGetSet(t *testing.T) {
+	dir := t.TempDir()
+	db, err := newSQLiteStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.sql.Close()
+
+	tc := &testClock{time.Now()}
+	db.clock = tc
+
+	// Check missing record
+
+	rec, err := db.get(&protocol.EmptyDeviceID)
+	if err != nil {
+		t.Error("not found should not be an error")
+	}
+	if len(rec.Addresses) != 0 {
+		t.Error("addresses should be empty")
+	}
+
+	// Put a record, then merge in another address
+
+	rec.Addresses = []*discosrv.DatabaseAddress{
+		{Address: "tcp://1.2.3.4:5", Expires: tc.Now().Add(time.Minute).UnixNano()},
+	}
+	if err := db.put(&protocol.EmptyDeviceID, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	tc.wind(30 * time.Second)
+
+	addrs := []*discosrv.DatabaseAddress{
+		{Address: "tcp://6.7.8.9:0", Expires: tc.Now().Add(time.Minute).UnixNano()},
+	}
+	if err := db.merge(&protocol.EmptyDeviceID, addrs, tc.Now().UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = db.get(&protocol.EmptyDeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 2 {
+		t.Fatal("should have two addresses", rec.Addresses)
+	}
+	if rec.Addresses[0].Address != "tcp://1.2.3.4:5" || rec.Addresses[1].Address != "tcp://6.7.8.9:0" {
+		t.Error("incorrect addresses", rec.Addresses)
+	}
+
+	// Pass the first expiry time and reopen the database; the remaining
+	// address must survive and the expired one must be gone.
+
+	tc.wind(45 * time.Second)
+	if err := db.expireAndCalculateStatistics(); err != nil {
+		t.Fatal(err)
+	}
+	db.sql.Close()
+
+	db, err = newSQLiteStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.sql.Close()
+	db.clock = tc
+
+	rec, err = db.get(&protocol.EmptyDeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Addresses) != 1 || rec.Addresses[0].Address != "tcp://6.7.8.9:0" {
+		t.Error("incorrect addresses", rec.Addresses)
+	}
+
+	// Devices not seen for a week and without addresses are dropped
+
+	tc.wind(8 * 24 * time.Hour)
+	if err := db.expireAndCalculateStatistics(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.sql.QueryRow(`SELECT COUNT(*) FROM devices`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Error("expected no devices, got", n)
+	}
+}
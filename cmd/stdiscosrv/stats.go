@@ -120,6 +120,7 @@ const (
 	dbOpMerge     = "merge"
 	dbResSuccess  = "success"
 	dbResNotFound = "not_found"
+	dbResError    = "error"
 )
 
 func init() {
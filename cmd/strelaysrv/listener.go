@@ -80,7 +80,12 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 		return
 	}
 
-	state := conn.ConnectionState()
+	serveProtocolConnection(conn, conn.ConnectionState(), token)
+}
+
+// serveProtocolConnection handles a relay protocol connection after the
+// TLS handshake, whether over TCP or QUIC.
+func serveProtocolConnection(conn net.Conn, state tls.ConnectionState, token string) {
 	if debug && state.NegotiatedProtocol != protocol.ProtocolName {
 		log.Println("Protocol negotiation error")
 	}
@@ -128,12 +133,37 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 					if debug {
 						log.Printf("invalid token %s\n", msg.Token)
 					}
+					metricJoinRequests.WithLabelValues(resultWrongToken).Inc()
 					protocol.WriteMessage(conn, protocol.ResponseWrongToken)
 					conn.Close()
 					continue
 				}
 
+				var ten *tenant
+				if tenants != nil {
+					ten = tenants.lookup(id, msg.Token)
+					if ten == nil {
+						if debug {
+							log.Println("No tenant for", id)
+						}
+						metricJoinRequests.WithLabelValues(resultWrongToken).Inc()
+						protocol.WriteMessage(conn, protocol.ResponseWrongToken)
+						conn.Close()
+						continue
+					}
+					if ten.overQuota() {
+						metricJoinRequests.WithLabelValues(resultOverQuota).Inc()
+						protocol.WriteMessage(conn, protocol.RelayFull{})
+						if debug {
+							log.Println("Refusing join request from", id, "due to tenant", ten.name, "being over quota")
+						}
+						conn.Close()
+						continue
+					}
+				}
+
 				if overLimit.Load() {
+					metricJoinRequests.WithLabelValues(resultOverLimit).Inc()
 					protocol.WriteMessage(conn, protocol.RelayFull{})
 					if debug {
 						log.Println("Refusing join request from", id, "due to being over limits")
@@ -147,6 +177,7 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 				_, ok := outboxes[id]
 				outboxesMut.RUnlock()
 				if ok {
+					metricJoinRequests.WithLabelValues(resultAlreadyConnected).Inc()
 					protocol.WriteMessage(conn, protocol.ResponseAlreadyConnected)
 					if debug {
 						log.Println("Already have a peer with the same ID", id, conn.RemoteAddr())
@@ -158,16 +189,33 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 				outboxesMut.Lock()
 				outboxes[id] = outbox
 				outboxesMut.Unlock()
+				if ten != nil {
+					joinTenant(id, ten, msg.Token)
+				}
 				joined = true
 
+				metricJoinRequests.WithLabelValues(resultSuccess).Inc()
 				protocol.WriteMessage(conn, protocol.ResponseSuccess)
 
 			case protocol.ConnectRequest:
+				// With tenants, only devices on a tenant's device list, or
+				// joined with a tenant's token, may connect to others.
+				if tenants != nil && tenants.lookup(id, "") == nil && joinedTenant(id) == nil {
+					if debug {
+						log.Println("No tenant for", id)
+					}
+					metricConnectRequests.WithLabelValues(resultWrongToken).Inc()
+					protocol.WriteMessage(conn, protocol.ResponseWrongToken)
+					conn.Close()
+					continue
+				}
+
 				requestedPeer, err := syncthingprotocol.DeviceIDFromBytes(msg.ID)
 				if err != nil {
 					if debug {
 						log.Println(id, "is looking for an invalid peer ID")
 					}
+					metricConnectRequests.WithLabelValues(resultNotFound).Inc()
 					protocol.WriteMessage(conn, protocol.ResponseNotFound)
 					conn.Close()
 					continue
@@ -179,13 +227,37 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 					if debug {
 						log.Println(id, "is looking for", requestedPeer, "which does not exist")
 					}
+					metricConnectRequests.WithLabelValues(resultNotFound).Inc()
+					protocol.WriteMessage(conn, protocol.ResponseNotFound)
+					conn.Close()
+					continue
+				}
+				// Sessions are accounted to the tenant of the joined peer.
+				ten := joinedTenant(requestedPeer)
+				if tenants != nil && ten == nil {
+					// The peer was dropped from its tenant on reload and
+					// is about to be disconnected.
+					if debug {
+						log.Println(id, "is looking for", requestedPeer, "which no longer belongs to a tenant")
+					}
+					metricConnectRequests.WithLabelValues(resultNotFound).Inc()
 					protocol.WriteMessage(conn, protocol.ResponseNotFound)
 					conn.Close()
 					continue
 				}
+				if ten != nil && ten.overQuota() {
+					if debug {
+						log.Println(id, "is looking for", requestedPeer, "whose tenant", ten.name, "is over quota")
+					}
+					metricConnectRequests.WithLabelValues(resultOverQuota).Inc()
+					protocol.WriteMessage(conn, protocol.RelayFull{})
+					conn.Close()
+					continue
+				}
 				// requestedPeer is the server, id is the client
-				ses := newSession(requestedPeer, id, sessionLimitBps, globalLimiter)
+				ses := newSession(requestedPeer, id, sessionLimitBps, globalLimiter, ten)
 
+				metricConnectRequests.WithLabelValues(resultSuccess).Inc()
 				go ses.Serve()
 
 				clientInvitation := ses.GetClientInvitationMessage()
@@ -246,6 +318,7 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 				outboxesMut.Lock()
 				delete(outboxes, id)
 				outboxesMut.Unlock()
+				leaveTenant(id)
 				// Also, kill all sessions related to this node, as it probably
 				// went offline. This is for the other end to realize the client
 				// is no longer there faster. This also helps resolve
@@ -265,6 +338,15 @@ func protocolConnectionHandler(tcpConn net.Conn, config *tls.Config, token strin
 				continue
 			}
 
+			if tenants != nil && joinedTenant(id) == nil {
+				if debug {
+					log.Println("Dropping", id, "as it no longer belongs to a tenant")
+				}
+				protocol.WriteMessage(conn, protocol.ResponseWrongToken)
+				conn.Close()
+				continue
+			}
+
 			if err := protocol.WriteMessage(conn, protocol.Ping{}); err != nil {
 				if debug {
 					log.Println(id, err)
@@ -27,6 +27,7 @@ import (
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/nat"
 	"github.com/syncthing/syncthing/lib/osutil"
+	_ "github.com/syncthing/syncthing/lib/pcp"
 	_ "github.com/syncthing/syncthing/lib/pmp"
 	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/relay/protocol"
@@ -55,12 +56,15 @@ var (
 	networkBufferSize int
 
 	statusAddr       string
+	statusToken      string
 	token            string
 	poolAddrs        string
 	pools            []string
 	providedBy       string
 	defaultPoolAddrs = "https://relays.syncthing.net/endpoint"
 
+	quicEnabled bool
+
 	natEnabled bool
 	natLease   int
 	natRenewal int
@@ -82,7 +86,7 @@ var (
 func main() {
 	log.SetFlags(log.Lshortfile | log.LstdFlags)
 
-	var dir, extAddress, proto string
+	var dir, extAddress, proto, tenantsFile, tenantsUsageFile string
 
 	flag.StringVar(&listen, "listen", ":22067", "Protocol listen address")
 	flag.StringVar(&dir, "keys", ".", "Directory where cert.pem and key.pem is stored")
@@ -93,11 +97,15 @@ func main() {
 	flag.IntVar(&globalLimitBps, "global-rate", globalLimitBps, "Global rate limit, in bytes/s")
 	flag.BoolVar(&debug, "debug", debug, "Enable debug output")
 	flag.StringVar(&statusAddr, "status-srv", ":22070", "Listen address for status service (blank to disable)")
+	flag.StringVar(&statusToken, "status-token", "", "Bearer token required for listing and terminating sessions on the status service (blank to disable)")
 	flag.StringVar(&token, "token", "", "Token to restrict access to the relay (optional). Disables joining any pools.")
+	flag.StringVar(&tenantsFile, "tenants", "", "JSON file with tenants (tokens, devices, rate limits and quotas) to restrict access to the relay (optional).\n\tReloaded when changed. Disables joining any pools.")
+	flag.StringVar(&tenantsUsageFile, "tenants-usage", "", "File where tenant usage in the current month is persisted (optional)")
 	flag.StringVar(&poolAddrs, "pools", defaultPoolAddrs, "Comma separated list of relay pool addresses to join")
 	flag.StringVar(&providedBy, "provided-by", "", "An optional description about who provides the relay")
 	flag.StringVar(&extAddress, "ext-address", "", "An optional address to advertise as being available on.\n\tAllows listening on an unprivileged port with port forwarding from e.g. 443, and be connected to on port 443.")
 	flag.StringVar(&proto, "protocol", "tcp", "Protocol used for listening. 'tcp' for IPv4 and IPv6, 'tcp4' for IPv4, 'tcp6' for IPv6")
+	flag.BoolVar(&quicEnabled, "quic", false, "Also accept connections over QUIC on the listen address (UDP port), advertised to clients that support it")
 	flag.BoolVar(&natEnabled, "nat", false, "Use UPnP/NAT-PMP to acquire external port mapping")
 	flag.IntVar(&natLease, "nat-lease", 60, "NAT lease length in minutes")
 	flag.IntVar(&natRenewal, "nat-renewal", 30, "NAT renewal frequency in minutes")
@@ -208,7 +216,12 @@ func main() {
 	} else {
 		ipVersion = nat.IPvAny
 	}
-	mapping := mapping{natSvc.NewMapping(nat.TCP, ipVersion, addr.IP, addr.Port)}
+	mapping := mapping{Mapping: natSvc.NewMapping(nat.TCP, ipVersion, addr.IP, addr.Port)}
+	if quicEnabled {
+		// QUIC is advertised on the same port, which then needs mapping
+		// for UDP as well.
+		mapping.udp = natSvc.NewMapping(nat.UDP, ipVersion, addr.IP, addr.Port)
+	}
 
 	if natEnabled {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -267,9 +280,23 @@ func main() {
 	}
 	uri.RawQuery = query.Encode()
 
-	log.Println("URI:", uri.String())
+	log.Println("URI:", mapping.advertisedURI(uri).String())
+	if quicEnabled && !mapping.quicReachable() {
+		log.Println("Not advertising QUIC, as the external UDP address differs from the TCP one:", externalAddress(mapping.udp))
+	}
+
+	if token != "" && tenantsFile != "" {
+		log.Fatal("Only one of -token and -tenants may be given")
+	}
+	if tenantsFile != "" {
+		tenants, err = newTenantRegistry(tenantsFile, tenantsUsageFile)
+		if err != nil {
+			log.Fatalln("Failed to load tenants:", err)
+		}
+		go tenants.serve()
+	}
 
-	if token != "" {
+	if token != "" || tenants != nil {
 		poolAddrs = ""
 	}
 
@@ -289,6 +316,9 @@ func main() {
 	}
 
 	go listener(proto, listen, tlsCfg, token)
+	if quicEnabled {
+		go quicListener(listen, tlsCfg, token)
+	}
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -331,12 +361,37 @@ func monitorLimits() {
 
 type mapping struct {
 	*nat.Mapping
+	udp *nat.Mapping // for QUIC, when enabled
 }
 
 func (m *mapping) Address() nat.Address {
+	return externalAddress(m.Mapping)
+}
+
+// quicReachable returns true if QUIC is enabled and reachable on the same
+// external address as TCP.
+func (m *mapping) quicReachable() bool {
+	return m.udp != nil && externalAddress(m.udp).Equal(m.Address())
+}
+
+// advertisedURI returns the relay URI with the current external address.
+// QUIC is advertised only when it's reachable on that same address, as
+// the URI has room for only one.
+func (m *mapping) advertisedURI(uri *url.URL) *url.URL {
+	res := *uri
+	res.Host = m.Address().String()
+	if m.quicReachable() {
+		query := res.Query()
+		query.Set("quic", "1")
+		res.RawQuery = query.Encode()
+	}
+	return &res
+}
+
+func externalAddress(m *nat.Mapping) nat.Address {
 	ext := m.ExternalAddresses()
 	if len(ext) > 0 {
 		return ext[0]
 	}
-	return m.Mapping.Address()
+	return m.Address()
 }
@@ -21,8 +21,7 @@ func poolHandler(pool string, uri *url.URL, mapping mapping) {
 		log.Println("Joining", pool)
 	}
 	for {
-		uriCopy := *uri
-		uriCopy.Host = mapping.Address().String()
+		uriCopy := mapping.advertisedURI(uri)
 
 		var b bytes.Buffer
 		json.NewEncoder(&b).Encode(struct {
@@ -33,6 +32,7 @@ func poolHandler(pool string, uri *url.URL, mapping mapping) {
 
 		resp, err := httpClient.Post(pool, "application/json", &b) //nolint:noctx
 		if err != nil {
+			poolRegistrationFailed(pool)
 			log.Printf("Error joining pool %v: HTTP request: %v", pool, err)
 			time.Sleep(time.Minute)
 			continue
@@ -41,11 +41,19 @@ func poolHandler(pool string, uri *url.URL, mapping mapping) {
 		bs, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
+			poolRegistrationFailed(pool)
 			log.Printf("Error joining pool %v: reading response: %v", pool, err)
 			time.Sleep(time.Minute)
 			continue
 		}
 
+		if resp.StatusCode == http.StatusOK {
+			metricPoolRegistered.WithLabelValues(pool).Set(1)
+			metricPoolRegistrations.WithLabelValues(pool, resultSuccess).Inc()
+		} else {
+			poolRegistrationFailed(pool)
+		}
+
 		switch resp.StatusCode {
 		case http.StatusOK:
 			var x struct {
@@ -91,3 +99,8 @@ func poolHandler(pool string, uri *url.URL, mapping mapping) {
 		time.Sleep(time.Hour)
 	}
 }
+
+func poolRegistrationFailed(pool string) {
+	metricPoolRegistered.WithLabelValues(pool).Set(0)
+	metricPoolRegistrations.WithLabelValues(pool, resultError).Inc()
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+
+	"github.com/quic-go/quic-go"
+
+	"github.com/syncthing/syncthing/lib/relay/protocol"
+	"github.com/syncthing/syncthing/lib/relay/quicconn"
+)
+
+// quicListener accepts relay protocol and session connections over QUIC.
+// Each QUIC connection carries a single stream and the negotiated
+// protocol tells which kind of connection it is. As QUIC connections
+// survive address changes, clients moving between networks keep both
+// their relay membership and their sessions.
+func quicListener(addr string, config *tls.Config, token string) {
+	cfg := config.Clone()
+	cfg.NextProtos = []string{protocol.ProtocolName, protocol.SessionProtocolName}
+
+	list, err := quic.ListenAddr(addr, cfg, quicconn.Config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for {
+		conn, err := list.Accept(context.Background())
+		if err != nil {
+			log.Fatalln("QUIC listener failed to accept:", err)
+		}
+
+		if debug {
+			log.Println("QUIC listener accepted connection from", conn.RemoteAddr())
+		}
+
+		go quicConnectionHandler(conn, token)
+	}
+}
+
+func quicConnectionHandler(conn *quic.Conn, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+	qc, err := quicconn.Accept(ctx, conn)
+	cancel()
+	if err != nil {
+		if debug {
+			log.Println("QUIC accept stream:", conn.RemoteAddr(), err)
+		}
+		return
+	}
+
+	state := qc.ConnectionState()
+	if state.NegotiatedProtocol == protocol.SessionProtocolName {
+		sessionConnectionHandler(qc)
+		return
+	}
+	serveProtocolConnection(qc, state, token)
+}
@@ -5,6 +5,7 @@ package main
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -19,6 +20,8 @@ import (
 	"github.com/syncthing/syncthing/lib/relay/protocol"
 )
 
+var errTenantOverQuota = errors.New("tenant over quota")
+
 var (
 	sessionMut      = sync.RWMutex{}
 	activeSessions  = make([]*session, 0)
@@ -27,7 +30,7 @@ var (
 	bytesProxied    atomic.Int64
 )
 
-func newSession(serverid, clientid syncthingprotocol.DeviceID, sessionLimitBps int, globalRateLimit *rate.Limiter) *session {
+func newSession(serverid, clientid syncthingprotocol.DeviceID, sessionLimitBps int, globalRateLimit *rate.Limiter, tenant *tenant) *session {
 	serverkey := make([]byte, 32)
 	_, err := rand.Read(serverkey)
 	if err != nil {
@@ -40,17 +43,25 @@ func newSession(serverid, clientid syncthingprotocol.DeviceID, sessionLimitBps i
 		return nil
 	}
 
+	id := make([]byte, 8)
+	_, err = rand.Read(id)
+	if err != nil {
+		return nil
+	}
+
 	var sessionRateLimit *rate.Limiter
 	if sessionLimitBps > 0 {
 		sessionRateLimit = rate.NewLimiter(rate.Limit(sessionLimitBps), 2*sessionLimitBps)
 	}
 	ses := &session{
+		id:        hex.EncodeToString(id),
 		serverkey: serverkey,
 		serverid:  serverid,
 		clientkey: clientkey,
 		clientid:  clientid,
-		rateLimit: makeRateLimitFunc(sessionRateLimit, globalRateLimit),
+		rateLimit: makeRateLimitFunc(sessionRateLimit, globalRateLimit, tenantLimiter(tenant)),
 		limiter:   sessionRateLimit,
+		tenant:    tenant,
 		connsChan: make(chan net.Conn),
 		conns:     make([]net.Conn, 0, 2),
 	}
@@ -104,9 +115,52 @@ func hasSessions(id syncthingprotocol.DeviceID) bool {
 	return has
 }
 
+// sessionInfo is the operator's view of an active session.
+type sessionInfo struct {
+	ID             string    `json:"id"`
+	Server         string    `json:"server"`
+	Client         string    `json:"client"`
+	Addresses      []string  `json:"addresses"`
+	Tenant         string    `json:"tenant,omitempty"`
+	Started        time.Time `json:"started"`
+	BytesProxied   int64     `json:"bytesProxied"`
+	AvgBytesPerSec int64     `json:"avgBytesPerSec"`
+	RateLimitBps   int       `json:"rateLimitBps,omitempty"`
+}
+
+// listSessions returns information about all active sessions.
+func listSessions() []sessionInfo {
+	sessionMut.RLock()
+	defer sessionMut.RUnlock()
+	res := make([]sessionInfo, 0, len(activeSessions))
+	for _, s := range activeSessions {
+		res = append(res, s.info())
+	}
+	return res
+}
+
+// terminateSession closes the connections of the active session with the
+// given ID, returning false if there is no such session.
+func terminateSession(id string) bool {
+	sessionMut.RLock()
+	defer sessionMut.RUnlock()
+	for _, s := range activeSessions {
+		if s.id == id {
+			log.Println("Terminating session", s, "between", s.serverid, "and", s.clientid)
+			s.CloseConns()
+			return true
+		}
+	}
+	return false
+}
+
 type session struct {
 	mut sync.Mutex
 
+	id      string // for the session listing; unrelated to the keys
+	started time.Time
+	bytes   atomic.Int64
+
 	serverkey []byte
 	serverid  syncthingprotocol.DeviceID
 
@@ -115,6 +169,7 @@ type session struct {
 
 	rateLimit func(bytes int)
 	limiter   *rate.Limiter
+	tenant    *tenant // optional
 
 	connsChan chan net.Conn
 	conns     []net.Conn
@@ -166,10 +221,20 @@ func (s *session) Serve() {
 			wg.Go(func() { err1 = s.proxy(s.conns[1], s.conns[0]) })
 
 			sessionMut.Lock()
+			s.started = time.Now()
 			activeSessions = append(activeSessions, s)
 			sessionMut.Unlock()
 
+			metricSessionsActive.Inc()
+			if s.tenant != nil {
+				s.tenant.activeSessions.Add(1)
+			}
 			wg.Wait()
+			if s.tenant != nil {
+				s.tenant.activeSessions.Add(-1)
+			}
+			metricSessionsActive.Dec()
+			metricSessionSeconds.Observe(time.Since(s.started).Seconds())
 
 			if debug {
 				log.Println("Session", s, "ended, outcomes:", err0, "and", err1)
@@ -261,6 +326,14 @@ func (s *session) proxy(c1, c2 net.Conn) error {
 		}
 
 		bytesProxied.Add(int64(n))
+		s.bytes.Add(int64(n))
+		metricBytesProxied.Add(float64(n))
+		if s.tenant != nil {
+			s.tenant.addBytes(n)
+			if s.tenant.overQuota() {
+				return errTenantOverQuota
+			}
+		}
 
 		if debug {
 			log.Printf("%d bytes from %s to %s", n, c1.RemoteAddr(), c2.RemoteAddr())
@@ -278,15 +351,56 @@ func (s *session) proxy(c1, c2 net.Conn) error {
 	}
 }
 
+// info returns information about the session. The caller must hold
+// sessionMut.
+func (s *session) info() sessionInfo {
+	info := sessionInfo{
+		ID:           s.id,
+		Server:       s.serverid.String(),
+		Client:       s.clientid.String(),
+		Started:      s.started,
+		BytesProxied: s.bytes.Load(),
+	}
+	if secs := time.Since(s.started).Seconds(); secs > 0 {
+		info.AvgBytesPerSec = int64(float64(info.BytesProxied) / secs)
+	}
+	if s.limiter != nil {
+		info.RateLimitBps = int(s.limiter.Limit())
+	}
+	if s.tenant != nil {
+		info.Tenant = s.tenant.name
+	}
+	s.mut.Lock()
+	for _, conn := range s.conns {
+		info.Addresses = append(info.Addresses, conn.RemoteAddr().String())
+	}
+	s.mut.Unlock()
+	return info
+}
+
 func (s *session) String() string {
 	return fmt.Sprintf("<%s/%s>", hex.EncodeToString(s.clientkey)[:5], hex.EncodeToString(s.serverkey)[:5])
 }
 
-func makeRateLimitFunc(sessionRateLimit, globalRateLimit *rate.Limiter) func(int) {
+func makeRateLimitFunc(sessionRateLimit, globalRateLimit, tenantRateLimit *rate.Limiter) func(int) {
 	// This may be a case of super duper premature optimization... We build an
 	// optimized function to do the rate limiting here based on what we need
 	// to do and then use it in the loop.
 
+	if tenantRateLimit != nil {
+		// Tenant limits can change on reload, so there's no point in
+		// optimizing; just queue on all the limiters we have.
+		ls := []*rate.Limiter{tenantRateLimit}
+		for _, l := range []*rate.Limiter{sessionRateLimit, globalRateLimit} {
+			if l != nil {
+				ls = append(ls, l)
+			}
+		}
+		return func(bytes int) {
+			take(bytes, ls...)
+		}
+	}
+
 	if sessionRateLimit == nil && globalRateLimit == nil {
 		// No limiting needed. We could equally well return a func(int64){} and
 		// not do a nil check were we use it, but I think the nil check there
@@ -323,6 +437,10 @@ func take(tokens int, ls ...*rate.Limiter) {
 	// minBurst is the smallest burst size supported by all limiters.
 	minBurst := int(math.MaxInt32)
 	for _, l := range ls {
+		if l.Limit() == rate.Inf {
+			// Unlimited, the burst size doesn't matter
+			continue
+		}
 		if burst := l.Burst(); burst < minBurst {
 			minBurst = burst
 		}
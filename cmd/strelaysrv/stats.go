@@ -0,0 +1,123 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metricSessionsActive = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "sessions_active",
+			Help:      "Number of sessions currently proxying data.",
+		})
+	metricSessionsPending = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "sessions_pending",
+			Help:      "Number of sessions waiting for both sides to connect.",
+		}, func() float64 {
+			sessionMut.RLock()
+			defer sessionMut.RUnlock()
+			// Each pending session has two keys, one for each side.
+			return float64(len(pendingSessions) / 2)
+		})
+	metricSessionSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "session_duration_seconds",
+			Help:      "Duration of completed sessions.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10), // 1s to ~3d
+		})
+	metricBytesProxied = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "bytes_proxied_total",
+			Help:      "Number of bytes proxied between session participants.",
+		})
+	metricConnections = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "connections",
+			Help:      "Number of protocol connections.",
+		}, func() float64 {
+			return float64(numConnections.Load())
+		})
+
+	metricJoinRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "join_requests_total",
+			Help:      "Number of join relay requests.",
+		}, []string{"result"})
+	metricConnectRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "connect_requests_total",
+			Help:      "Number of connect requests.",
+		}, []string{"result"})
+	metricSessionsTerminated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "sessions_terminated_total",
+			Help:      "Number of sessions terminated by the operator.",
+		})
+
+	metricPoolRegistered = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "pool_registered",
+			Help:      "Whether the last registration with the pool succeeded (1) or not (0).",
+		}, []string{"pool"})
+	metricPoolRegistrations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "syncthing",
+			Subsystem: "relaysrv",
+			Name:      "pool_registrations_total",
+			Help:      "Number of pool registration attempts.",
+		}, []string{"pool", "result"})
+)
+
+const (
+	resultSuccess          = "success"
+	resultWrongToken       = "wrong_token"
+	resultOverLimit        = "over_limit"
+	resultOverQuota        = "over_quota"
+	resultAlreadyConnected = "already_connected"
+	resultNotFound         = "not_found"
+	resultError            = "error"
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricSessionsActive, metricSessionsPending,
+		metricSessionSeconds, metricBytesProxied,
+		metricConnections,
+		metricJoinRequests, metricConnectRequests,
+		metricSessionsTerminated,
+		metricPoolRegistered, metricPoolRegistrations)
+
+	// Prewarm the request counters so they're available with zero values
+	// at startup.
+	for _, res := range []string{resultSuccess, resultWrongToken, resultOverLimit, resultOverQuota, resultAlreadyConnected} {
+		metricJoinRequests.WithLabelValues(res)
+	}
+	for _, res := range []string{resultSuccess, resultWrongToken, resultNotFound, resultOverQuota} {
+		metricConnectRequests.WithLabelValues(res)
+	}
+}
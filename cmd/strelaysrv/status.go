@@ -3,14 +3,18 @@
 package main
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"log"
 	"net/http"
 	"net/http/pprof"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"github.com/syncthing/syncthing/lib/build"
 )
 
@@ -19,15 +23,9 @@ var rc *rateCalculator
 func statusService(addr string) {
 	rc = newRateCalculator(360, 10*time.Second, &bytesProxied)
 
-	handler := http.NewServeMux()
-	handler.HandleFunc("/status", getStatus)
-	if pprofEnabled {
-		handler.HandleFunc("/debug/pprof/", pprof.Index)
-	}
-
 	srv := http.Server{
 		Addr:        addr,
-		Handler:     handler,
+		Handler:     statusHandler(),
 		ReadTimeout: 15 * time.Second,
 	}
 	srv.SetKeepAlivesEnabled(false)
@@ -36,6 +34,20 @@ func statusService(addr string) {
 	}
 }
 
+func statusHandler() http.Handler {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/status", getStatus)
+	handler.Handle("/metrics", promhttp.Handler())
+	if statusToken != "" {
+		handler.HandleFunc("GET /sessions", requireStatusToken(getSessions))
+		handler.HandleFunc("DELETE /sessions/{id}", requireStatusToken(deleteSession))
+	}
+	if pprofEnabled {
+		handler.HandleFunc("/debug/pprof/", pprof.Index)
+	}
+	return handler
+}
+
 func getStatus(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	status := make(map[string]interface{})
@@ -67,6 +79,9 @@ func getStatus(w http.ResponseWriter, _ *http.Request) {
 		rc.rate(30*60/10) * 8 / 1000,
 		rc.rate(60*60/10) * 8 / 1000,
 	}
+	if tenants != nil {
+		status["tenants"] = tenants.status()
+	}
 	status["options"] = map[string]interface{}{
 		"network-timeout":  networkTimeout / time.Second,
 		"ping-interval":    pingInterval / time.Second,
@@ -87,6 +102,39 @@ func getStatus(w http.ResponseWriter, _ *http.Request) {
 	w.Write(bs)
 }
 
+// requireStatusToken wraps the handler to require the status token as a
+// bearer token.
+func requireStatusToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(statusToken)) != 1 {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func getSessions(w http.ResponseWriter, _ *http.Request) {
+	bs, err := json.MarshalIndent(listSessions(), "", "    ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bs)
+}
+
+func deleteSession(w http.ResponseWriter, r *http.Request) {
+	if !terminateSession(r.PathValue("id")) {
+		http.Error(w, "No such session", http.StatusNotFound)
+		return
+	}
+	metricSessionsTerminated.Inc()
+	w.WriteHeader(http.StatusNoContent)
+}
+
 type rateCalculator struct {
 	counter   *atomic.Int64
 	rates     []int64
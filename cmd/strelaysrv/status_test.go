@@ -0,0 +1,180 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addTestSession adds an active session between device A and B, returning
+// the remote ends of its connections.
+func addTestSession(t *testing.T, id string) (net.Conn, net.Conn) {
+	t.Helper()
+	c1, r1 := net.Pipe()
+	c2, r2 := net.Pipe()
+	ses := &session{
+		id:        id,
+		started:   time.Now(),
+		serverid:  deviceA,
+		serverkey: []byte("serverkey"),
+		clientid:  deviceB,
+		clientkey: []byte("clientkey"),
+		conns:     []net.Conn{c1, c2},
+	}
+	ses.bytes.Store(42)
+
+	sessionMut.Lock()
+	activeSessions = append(activeSessions, ses)
+	sessionMut.Unlock()
+	t.Cleanup(func() {
+		sessionMut.Lock()
+		activeSessions = slices.DeleteFunc(activeSessions, func(s *session) bool { return s == ses })
+		sessionMut.Unlock()
+		ses.CloseConns()
+		r1.Close()
+		r2.Close()
+	})
+	return r1, r2
+}
+
+func testStatusRequest(t *testing.T, srv *httptest.Server, method, path, token string) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, bs
+}
+
+func TestStatusMetrics(t *testing.T) {
+	srv := httptest.NewServer(statusHandler())
+	defer srv.Close()
+
+	code, body := testStatusRequest(t, srv, http.MethodGet, "/metrics", "")
+	if code != http.StatusOK {
+		t.Fatal("unexpected status", code)
+	}
+	for _, metric := range []string{
+		"syncthing_relaysrv_sessions_active",
+		"syncthing_relaysrv_bytes_proxied_total",
+		`syncthing_relaysrv_join_requests_total{result="success"}`,
+		`syncthing_relaysrv_connect_requests_total{result="not_found"}`,
+		"syncthing_relaysrv_sessions_terminated_total",
+	} {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("metric %s missing", metric)
+		}
+	}
+}
+
+func TestStatusSessions(t *testing.T) {
+	oldToken := statusToken
+	statusToken = "s3cret"
+	defer func() { statusToken = oldToken }()
+
+	srv := httptest.NewServer(statusHandler())
+	defer srv.Close()
+
+	r1, r2 := addTestSession(t, "abc123")
+
+	for _, token := range []string{"", "wrong", "s3cret2", "Bearer s3cret"} {
+		if code, _ := testStatusRequest(t, srv, http.MethodGet, "/sessions", token); code != http.StatusForbidden {
+			t.Errorf("listing with token %q: got %d, expected forbidden", token, code)
+		}
+		if code, _ := testStatusRequest(t, srv, http.MethodDelete, "/sessions/abc123", token); code != http.StatusForbidden {
+			t.Errorf("terminating with token %q: got %d, expected forbidden", token, code)
+		}
+	}
+
+	code, body := testStatusRequest(t, srv, http.MethodGet, "/sessions", "s3cret")
+	if code != http.StatusOK {
+		t.Fatal("unexpected status", code)
+	}
+	var sessions []sessionInfo
+	if err := json.Unmarshal(body, &sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatal("expected one session, got", sessions)
+	}
+	if s := sessions[0]; s.ID != "abc123" || s.Server != deviceA.String() || s.Client != deviceB.String() || s.BytesProxied != 42 || len(s.Addresses) != 2 {
+		t.Error("unexpected session", s)
+	}
+
+	if code, _ := testStatusRequest(t, srv, http.MethodDelete, "/sessions/nonexistent", "s3cret"); code != http.StatusNotFound {
+		t.Error("terminating unknown session: got", code)
+	}
+
+	before := testCounterValue(t, srv, "syncthing_relaysrv_sessions_terminated_total")
+	if code, _ := testStatusRequest(t, srv, http.MethodDelete, "/sessions/abc123", "s3cret"); code != http.StatusNoContent {
+		t.Fatal("terminating session: got", code)
+	}
+	for _, conn := range []net.Conn{r1, r2} {
+		if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+			t.Error("session connection not closed:", err)
+		}
+	}
+	if after := testCounterValue(t, srv, "syncthing_relaysrv_sessions_terminated_total"); after != before+1 {
+		t.Errorf("terminated counter not increased: %v -> %v", before, after)
+	}
+}
+
+func TestStatusSessionsDisabled(t *testing.T) {
+	oldToken := statusToken
+	statusToken = ""
+	defer func() { statusToken = oldToken }()
+
+	srv := httptest.NewServer(statusHandler())
+	defer srv.Close()
+
+	addTestSession(t, "abc123")
+
+	if code, _ := testStatusRequest(t, srv, http.MethodGet, "/sessions", ""); code != http.StatusNotFound {
+		t.Error("listing without status token configured: got", code)
+	}
+	if code, _ := testStatusRequest(t, srv, http.MethodDelete, "/sessions/abc123", ""); code == http.StatusNoContent {
+		t.Error("terminating without status token configured should fail")
+	}
+}
+
+// testCounterValue returns the value of the given unlabeled metric.
+func testCounterValue(t *testing.T, srv *httptest.Server, name string) float64 {
+	t.Helper()
+	_, body := testStatusRequest(t, srv, http.MethodGet, "/metrics", "")
+	for _, line := range strings.Split(string(body), "\n") {
+		if val, ok := strings.CutPrefix(line, name+" "); ok {
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return f
+		}
+	}
+	t.Fatalf("metric %s missing", name)
+	return 0
+}
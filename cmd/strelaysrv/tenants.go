@@ -0,0 +1,425 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+)
+
+const tenantsCheckInterval = 10 * time.Second
+
+var tenants *tenantRegistry // nil when not running multi-tenant
+
+// tenantsConfig is the format of the tenants file, e.g.
+//
+//	{
+//	    "tenants": [
+//	        {
+//	            "name": "team-a",
+//	            "tokens": ["s3cret"],
+//	            "devices": ["MFZWI3D-BONSGYC-YLTMRWG-C43ENR5-QXGZDMM-FZWI3DP-BONSGYY-LTMRWAD"],
+//	            "rateBps": 1000000,
+//	            "monthlyQuotaBytes": 100000000000
+//	        }
+//	    ]
+//	}
+type tenantsConfig struct {
+	Tenants []tenantConfig `json:"tenants"`
+}
+
+type tenantConfig struct {
+	Name              string   `json:"name"`
+	Tokens            []string `json:"tokens"`
+	Devices           []string `json:"devices"`
+	RateBps           int      `json:"rateBps"`           // zero for unlimited
+	MonthlyQuotaBytes int64    `json:"monthlyQuotaBytes"` // zero for unlimited
+}
+
+// A tenant is a named group of devices sharing a bandwidth cap and a
+// monthly byte quota. Devices belong to a tenant by presenting one of its
+// tokens when joining, or by being on its device list.
+type tenant struct {
+	name    string
+	limiter *rate.Limiter // rate.Inf when unlimited
+
+	mut     sync.RWMutex
+	tokens  []string
+	devices map[syncthingprotocol.DeviceID]struct{}
+	quota   int64
+
+	bytesProxied   atomic.Int64 // since start
+	monthBytes     atomic.Int64 // in the current accounting month
+	activeSessions atomic.Int64
+}
+
+func (t *tenant) matchesToken(token string) bool {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	for _, tok := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(tok), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tenant) matchesDevice(id syncthingprotocol.DeviceID) bool {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	_, ok := t.devices[id]
+	return ok
+}
+
+// overQuota returns true if the tenant has used up its monthly quota.
+func (t *tenant) overQuota() bool {
+	t.mut.RLock()
+	quota := t.quota
+	t.mut.RUnlock()
+	return quota > 0 && t.monthBytes.Load() >= quota
+}
+
+func (t *tenant) addBytes(n int) {
+	t.bytesProxied.Add(int64(n))
+	t.monthBytes.Add(int64(n))
+}
+
+func (t *tenant) status() map[string]interface{} {
+	t.mut.RLock()
+	quota := t.quota
+	t.mut.RUnlock()
+	var rateBps int
+	if lim := t.limiter.Limit(); lim != rate.Inf {
+		rateBps = int(lim)
+	}
+	return map[string]interface{}{
+		"bytesProxied":      t.bytesProxied.Load(),
+		"monthBytes":        t.monthBytes.Load(),
+		"monthlyQuotaBytes": quota,
+		"rateBps":           rateBps,
+		"numActiveSessions": t.activeSessions.Load(),
+	}
+}
+
+// update applies the given configuration, keeping usage counters.
+func (t *tenant) update(cfg tenantConfig, devices map[syncthingprotocol.DeviceID]struct{}) {
+	t.mut.Lock()
+	t.tokens = cfg.Tokens
+	t.devices = devices
+	t.quota = cfg.MonthlyQuotaBytes
+	t.mut.Unlock()
+	if cfg.RateBps > 0 {
+		t.limiter.SetBurst(2 * cfg.RateBps)
+		t.limiter.SetLimit(rate.Limit(cfg.RateBps))
+	} else {
+		t.limiter.SetLimit(rate.Inf)
+	}
+}
+
+// The tenantRegistry holds the set of tenants loaded from the tenants
+// file, reloading it when it changes. Usage in the current month is
+// persisted to a separate file so that quotas survive restarts.
+type tenantRegistry struct {
+	path      string
+	usagePath string
+	timeNow   func() time.Time // can be overridden for testing
+
+	mut     sync.RWMutex
+	tenants map[string]*tenant
+	month   string
+	modTime time.Time
+}
+
+type tenantsUsage struct {
+	Month string           `json:"month"`
+	Bytes map[string]int64 `json:"bytes"`
+}
+
+func newTenantRegistry(path, usagePath string) (*tenantRegistry, error) {
+	r := &tenantRegistry{
+		path:      path,
+		usagePath: usagePath,
+		timeNow:   time.Now,
+		tenants:   make(map[string]*tenant),
+	}
+	r.month = r.currentMonth()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.loadUsage()
+	return r, nil
+}
+
+func (r *tenantRegistry) currentMonth() string {
+	return r.timeNow().UTC().Format("2006-01")
+}
+
+// load (re)reads the tenants file. Tenants are matched by name, so
+// existing tenants keep their usage counters and rate limiters. Joined
+// devices that no longer belong to a tenant are dropped.
+func (r *tenantRegistry) load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	bs, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	var cfg tenantsConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return fmt.Errorf("parsing %s: %w", r.path, err)
+	}
+
+	next := make(map[string]*tenant, len(cfg.Tenants))
+	r.mut.RLock()
+	for _, tc := range cfg.Tenants {
+		if tc.Name == "" {
+			r.mut.RUnlock()
+			return errors.New("tenant without name")
+		}
+		if _, ok := next[tc.Name]; ok {
+			r.mut.RUnlock()
+			return fmt.Errorf("duplicate tenant %q", tc.Name)
+		}
+		devices := make(map[syncthingprotocol.DeviceID]struct{}, len(tc.Devices))
+		for _, s := range tc.Devices {
+			id, err := syncthingprotocol.DeviceIDFromString(s)
+			if err != nil {
+				r.mut.RUnlock()
+				return fmt.Errorf("tenant %q: %w", tc.Name, err)
+			}
+			devices[id] = struct{}{}
+		}
+
+		t, ok := r.tenants[tc.Name]
+		if !ok {
+			t = &tenant{name: tc.Name}
+			// Tenants always get a limiter so that the rate can be changed
+			// on reload without affecting sessions already set up.
+			t.limiter = rate.NewLimiter(rate.Inf, 0)
+		}
+		t.update(tc, devices)
+		next[tc.Name] = t
+	}
+	r.mut.RUnlock()
+
+	r.mut.Lock()
+	r.tenants = next
+	r.modTime = info.ModTime()
+	r.mut.Unlock()
+
+	r.recheckJoined()
+	return nil
+}
+
+// recheckJoined resolves the tenant of each joined device again, using the
+// token it joined with, and drops the devices that no longer have one.
+// They get disconnected at their next ping.
+func (r *tenantRegistry) recheckJoined() {
+	var dropped []syncthingprotocol.DeviceID
+	joinedTenantsMut.Lock()
+	for id, j := range joinedTenants {
+		if t := r.lookup(id, j.token); t != nil {
+			j.tenant = t
+			joinedTenants[id] = j
+			continue
+		}
+		delete(joinedTenants, id)
+		dropped = append(dropped, id)
+	}
+	joinedTenantsMut.Unlock()
+
+	for _, id := range dropped {
+		if debug {
+			log.Println("Device", id, "no longer belongs to a tenant")
+		}
+		dropSessions(id)
+	}
+}
+
+// loadUsage restores the usage in the current month from the usage file,
+// if any.
+func (r *tenantRegistry) loadUsage() {
+	if r.usagePath == "" {
+		return
+	}
+	bs, err := os.ReadFile(r.usagePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("Failed to read tenant usage:", err)
+		}
+		return
+	}
+	var usage tenantsUsage
+	if err := json.Unmarshal(bs, &usage); err != nil {
+		log.Println("Failed to parse tenant usage:", err)
+		return
+	}
+	if usage.Month != r.month {
+		return
+	}
+	r.mut.RLock()
+	for name, n := range usage.Bytes {
+		if t, ok := r.tenants[name]; ok {
+			t.monthBytes.Store(n)
+		}
+	}
+	r.mut.RUnlock()
+}
+
+func (r *tenantRegistry) saveUsage() error {
+	if r.usagePath == "" {
+		return nil
+	}
+	usage := tenantsUsage{Bytes: make(map[string]int64)}
+	r.mut.RLock()
+	usage.Month = r.month
+	for name, t := range r.tenants {
+		usage.Bytes[name] = t.monthBytes.Load()
+	}
+	r.mut.RUnlock()
+
+	bs, err := json.MarshalIndent(usage, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.usagePath+".tmp", bs, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(r.usagePath+".tmp", r.usagePath)
+}
+
+// serve reloads the tenants file when it changes, rolls over the
+// accounting month and persists the usage counters.
+func (r *tenantRegistry) serve() {
+	for range time.Tick(tenantsCheckInterval) {
+		r.check()
+	}
+}
+
+func (r *tenantRegistry) check() {
+	if info, err := os.Stat(r.path); err != nil {
+		log.Println("Failed to check tenants file:", err)
+	} else if r.changed(info.ModTime()) {
+		if err := r.load(); err != nil {
+			log.Println("Failed to reload tenants:", err)
+		} else {
+			log.Println("Reloaded tenants from", r.path)
+		}
+	}
+
+	if month, ok := r.rollover(); ok {
+		log.Println("Reset tenant quotas for", month)
+	}
+
+	if err := r.saveUsage(); err != nil {
+		log.Println("Failed to save tenant usage:", err)
+	}
+}
+
+// rollover resets the monthly usage counters when a new accounting month
+// has started, returning the new month and true if so.
+func (r *tenantRegistry) rollover() (string, bool) {
+	month := r.currentMonth()
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if month == r.month {
+		return "", false
+	}
+	r.month = month
+	for _, t := range r.tenants {
+		t.monthBytes.Store(0)
+	}
+	return month, true
+}
+
+func (r *tenantRegistry) changed(modTime time.Time) bool {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return !modTime.Equal(r.modTime)
+}
+
+// lookup returns the tenant the device belongs to, given the token it
+// presented, or nil.
+func (r *tenantRegistry) lookup(id syncthingprotocol.DeviceID, token string) *tenant {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	for _, t := range r.tenants {
+		if token != "" && t.matchesToken(token) {
+			return t
+		}
+	}
+	for _, t := range r.tenants {
+		if t.matchesDevice(id) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *tenantRegistry) status() map[string]interface{} {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	res := make(map[string]interface{}, len(r.tenants))
+	for name, t := range r.tenants {
+		res[name] = t.status()
+	}
+	return res
+}
+
+// A joinedDevice is the tenant of a joined device, and the token it joined
+// with so that the tenant can be resolved again on reload.
+type joinedDevice struct {
+	tenant *tenant
+	token  string
+}
+
+var (
+	joinedTenantsMut = sync.RWMutex{}
+	joinedTenants    = make(map[syncthingprotocol.DeviceID]joinedDevice)
+)
+
+// joinTenant records the tenant of the given joined device.
+func joinTenant(id syncthingprotocol.DeviceID, t *tenant, token string) {
+	joinedTenantsMut.Lock()
+	joinedTenants[id] = joinedDevice{tenant: t, token: token}
+	joinedTenantsMut.Unlock()
+}
+
+// leaveTenant forgets the tenant of the given device.
+func leaveTenant(id syncthingprotocol.DeviceID) {
+	joinedTenantsMut.Lock()
+	delete(joinedTenants, id)
+	joinedTenantsMut.Unlock()
+}
+
+// joinedTenant returns the tenant of the given joined device, or nil.
+func joinedTenant(id syncthingprotocol.DeviceID) *tenant {
+	joinedTenantsMut.RLock()
+	defer joinedTenantsMut.RUnlock()
+	return joinedTenants[id].tenant
+}
+
+// tenantLimiter returns the rate limiter of the tenant, if any.
+func tenantLimiter(t *tenant) *rate.Limiter {
+	if t == nil {
+		return nil
+	}
+	return t.limiter
+}
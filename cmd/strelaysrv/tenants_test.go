@@ -0,0 +1,280 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+)
+
+var (
+	deviceA = syncthingprotocol.DeviceID{1}
+	deviceB = syncthingprotocol.DeviceID{2}
+	deviceC = syncthingprotocol.DeviceID{3}
+)
+
+// testRegistry returns a tenant registry for the given tenants file
+// contents, using the given clock.
+func testRegistry(t *testing.T, content string, now *time.Time) *tenantRegistry {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tenants.json")
+	writeTenants(t, path, content, time.Unix(1, 0))
+	r := &tenantRegistry{
+		path:      path,
+		usagePath: filepath.Join(dir, "usage.json"),
+		timeNow:   func() time.Time { return *now },
+		tenants:   make(map[string]*tenant),
+	}
+	r.month = r.currentMonth()
+	if err := r.load(); err != nil {
+		t.Fatal(err)
+	}
+	r.loadUsage()
+	return r
+}
+
+func writeTenants(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTenantLoad(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		ok      bool
+	}{
+		{"empty", `{}`, true},
+		{"valid", `{"tenants":[{"name":"a","tokens":["x"],"devices":["` + deviceA.String() + `"]}]}`, true},
+		{"no name", `{"tenants":[{"tokens":["x"]}]}`, false},
+		{"duplicate", `{"tenants":[{"name":"a"},{"name":"a"}]}`, false},
+		{"bad device", `{"tenants":[{"name":"a","devices":["foo"]}]}`, false},
+		{"bad json", `{"tenants":`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tenants.json")
+			writeTenants(t, path, tc.content, time.Now())
+			_, err := newTenantRegistry(path, "")
+			if (err == nil) != tc.ok {
+				t.Errorf("unexpected error state %v", err)
+			}
+		})
+	}
+}
+
+func TestTenantLookup(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	r := testRegistry(t, `{"tenants":[
+		{"name":"a","tokens":["ta"],"devices":["`+deviceA.String()+`"]},
+		{"name":"b","tokens":["tb"]}
+	]}`, &now)
+
+	cases := []struct {
+		id     syncthingprotocol.DeviceID
+		token  string
+		tenant string
+	}{
+		{deviceA, "", "a"},
+		{deviceA, "tb", "b"}, // the token takes precedence
+		{deviceB, "tb", "b"},
+		{deviceB, "", ""},
+		{deviceB, "wrong", ""},
+		{deviceC, "ta", "a"},
+	}
+
+	for _, tc := range cases {
+		var name string
+		if ten := r.lookup(tc.id, tc.token); ten != nil {
+			name = ten.name
+		}
+		if name != tc.tenant {
+			t.Errorf("lookup(%v, %q) = %q, expected %q", tc.id, tc.token, name, tc.tenant)
+		}
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	r := testRegistry(t, `{"tenants":[
+		{"name":"limited","tokens":["x"],"monthlyQuotaBytes":1000,"rateBps":500},
+		{"name":"unlimited","tokens":["y"]}
+	]}`, &now)
+
+	cases := []struct {
+		tenant    string
+		add       []int
+		overQuota bool
+	}{
+		{"limited", nil, false},
+		{"limited", []int{999}, false},
+		{"limited", []int{1}, true},
+		{"limited", []int{500}, true},
+		{"unlimited", []int{1 << 30}, false},
+	}
+
+	for _, tc := range cases {
+		ten := r.tenants[tc.tenant]
+		for _, n := range tc.add {
+			ten.addBytes(n)
+		}
+		if ten.overQuota() != tc.overQuota {
+			t.Errorf("%s at %d bytes: overQuota = %v, expected %v", tc.tenant, ten.monthBytes.Load(), !tc.overQuota, tc.overQuota)
+		}
+	}
+
+	st := r.tenants["limited"].status()
+	if st["monthBytes"] != int64(1500) || st["bytesProxied"] != int64(1500) || st["rateBps"] != 500 {
+		t.Error("unexpected status", st)
+	}
+	if st := r.tenants["unlimited"].status(); st["rateBps"] != 0 {
+		t.Error("unexpected status", st)
+	}
+}
+
+func TestTenantMonthRollover(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+	const content = `{"tenants":[{"name":"a","tokens":["x"],"monthlyQuotaBytes":1000}]}`
+	r := testRegistry(t, content, &now)
+
+	r.tenants["a"].addBytes(1000)
+	if !r.tenants["a"].overQuota() {
+		t.Fatal("should be over quota")
+	}
+	if err := r.saveUsage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Usage is restored on restart within the same month.
+	restarted := &tenantRegistry{path: r.path, usagePath: r.usagePath, timeNow: r.timeNow, tenants: make(map[string]*tenant)}
+	restarted.month = restarted.currentMonth()
+	if err := restarted.load(); err != nil {
+		t.Fatal(err)
+	}
+	restarted.loadUsage()
+	if n := restarted.tenants["a"].monthBytes.Load(); n != 1000 {
+		t.Errorf("restored usage %d, expected 1000", n)
+	}
+
+	steps := []struct {
+		now      time.Time
+		rollover bool
+		month    string
+	}{
+		{time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC), false, ""},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), true, "2026-04"},
+		{time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC), false, ""},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true, "2027-01"},
+	}
+	for _, step := range steps {
+		r.tenants["a"].addBytes(10)
+		now = step.now
+		month, ok := r.rollover()
+		if ok != step.rollover || month != step.month {
+			t.Errorf("at %v: rollover = %q, %v, expected %q, %v", now, month, ok, step.month, step.rollover)
+		}
+		if step.rollover && r.tenants["a"].monthBytes.Load() != 0 {
+			t.Errorf("at %v: usage not reset", now)
+		}
+	}
+	if r.tenants["a"].overQuota() {
+		t.Error("should not be over quota in a new month")
+	}
+	if n := r.tenants["a"].bytesProxied.Load(); n != 1040 {
+		t.Errorf("total bytes %d, expected 1040", n)
+	}
+
+	// Usage from a previous month is not restored.
+	if err := r.saveUsage(); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC)
+	restarted.tenants = make(map[string]*tenant)
+	restarted.month = restarted.currentMonth()
+	if err := restarted.load(); err != nil {
+		t.Fatal(err)
+	}
+	restarted.loadUsage()
+	if n := restarted.tenants["a"].monthBytes.Load(); n != 0 {
+		t.Errorf("restored usage %d from previous month", n)
+	}
+}
+
+func TestTenantReload(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	r := testRegistry(t, `{"tenants":[
+		{"name":"a","tokens":["ta"],"devices":["`+deviceA.String()+`"],"rateBps":1000},
+		{"name":"b","tokens":["tb"]}
+	]}`, &now)
+	tenantA := r.tenants["a"]
+	tenantA.addBytes(100)
+
+	t.Cleanup(func() {
+		leaveTenant(deviceA)
+		leaveTenant(deviceB)
+		leaveTenant(deviceC)
+	})
+	joinTenant(deviceA, r.lookup(deviceA, ""), "")
+	joinTenant(deviceB, r.lookup(deviceB, "tb"), "tb")
+	joinTenant(deviceC, r.lookup(deviceC, "ta"), "ta")
+
+	// An unchanged file isn't reloaded.
+	r.check()
+	if r.tenants["a"] != tenantA {
+		t.Fatal("tenant replaced without reload")
+	}
+
+	// Device A moves to tenant b, tenant a loses its token and tenant b
+	// keeps its own.
+	writeTenants(t, r.path, `{"tenants":[
+		{"name":"a","rateBps":2000,"monthlyQuotaBytes":50},
+		{"name":"b","tokens":["tb"],"devices":["`+deviceA.String()+`"]}
+	]}`, time.Unix(2, 0))
+	r.check()
+
+	if r.tenants["a"] != tenantA {
+		t.Error("existing tenant should be kept on reload")
+	}
+	if n := tenantA.monthBytes.Load(); n != 100 {
+		t.Errorf("usage %d lost on reload", n)
+	}
+	if tenantA.limiter.Limit() != 2000 || !tenantA.overQuota() {
+		t.Error("limits not updated on reload")
+	}
+
+	cases := []struct {
+		id     syncthingprotocol.DeviceID
+		tenant *tenant
+	}{
+		{deviceA, r.tenants["b"]},
+		{deviceB, r.tenants["b"]},
+		{deviceC, nil}, // its token is gone
+	}
+	for _, tc := range cases {
+		if ten := joinedTenant(tc.id); ten != tc.tenant {
+			t.Errorf("joined tenant of %v is %v, expected %v", tc.id, ten, tc.tenant)
+		}
+	}
+
+	// A broken file keeps the previous tenants.
+	writeTenants(t, r.path, `{"tenants":[{"name":""}]}`, time.Unix(3, 0))
+	r.check()
+	if r.lookup(deviceA, "") != r.tenants["b"] {
+		t.Error("tenants changed on failed reload")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+type apiKeysCommand struct {
+	List   struct{}             `cmd:"" help:"List scoped API keys, without the keys themselves"`
+	Add    apiKeysAddCommand    `cmd:"" help:"Add a scoped API key and show it"`
+	Remove apiKeysRemoveCommand `cmd:"" help:"Remove a scoped API key"`
+}
+
+type apiKeysAddCommand struct {
+	Name    string        `arg:""`
+	Scope   []string      `required:"" help:"Scope of the key: read, folder, events or metrics (repeatable)"`
+	Folder  []string      `help:"Folder ID the folder scope gives access to (repeatable)"`
+	Expires time.Duration `help:"Let the key expire after the given duration, e.g. 720h"`
+}
+
+type apiKeysRemoveCommand struct {
+	Name string `arg:""`
+}
+
+func (*apiKeysCommand) Run(ctx Context, kongCtx *kong.Context) error {
+	switch kongCtx.Selected().Name {
+	case "list":
+		return indexDumpOutput("system/apikeys", ctx.clientFactory)
+	}
+	return nil
+}
+
+func (a *apiKeysAddCommand) Run(ctx Context) error {
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	req := map[string]any{
+		"name":    a.Name,
+		"scopes":  a.Scope,
+		"folders": a.Folder,
+	}
+	if a.Expires > 0 {
+		req["expires"] = time.Now().Add(a.Expires)
+	}
+	bs, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	response, err := client.Post("system/apikeys", string(bs))
+	if err != nil {
+		return err
+	}
+	return prettyPrintResponse(response)
+}
+
+func (a *apiKeysRemoveCommand) Run(ctx Context) error {
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Delete("system/apikeys?name=" + url.QueryEscape(a.Name))
+	return err
+}
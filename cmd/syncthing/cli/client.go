@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/locations"
@@ -28,6 +29,7 @@ type APIClient interface {
 	Get(url string) (*http.Response, error)
 	Post(url, body string) (*http.Response, error)
 	PutJSON(url string, o interface{}) (*http.Response, error)
+	Delete(url string) (*http.Response, error)
 }
 
 type apiClient struct {
@@ -115,6 +117,8 @@ func (c *apiClient) Endpoint() string {
 
 func (c *apiClient) Do(req *http.Request) (*http.Response, error) {
 	req.Header.Set("X-Api-Key", c.apikey)
+	// Lets the configuration history tell our changes apart.
+	req.Header.Set("User-Agent", "syncthing-cli/"+build.Version)
 	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, err
@@ -154,6 +158,10 @@ func (c *apiClient) PutJSON(url string, o interface{}) (*http.Response, error) {
 	return c.RequestJSON(url, "PUT", o)
 }
 
+func (c *apiClient) Delete(url string) (*http.Response, error) {
+	return c.RequestString(url, "DELETE", "")
+}
+
 var errNotFound = errors.New("invalid endpoint or API call")
 
 func checkResponse(response *http.Response) error {
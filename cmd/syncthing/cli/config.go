@@ -106,7 +106,7 @@ func (c *configCommand) Run(ctx Context, outerCtx *kong.Context) error {
 		return fmt.Errorf("config reflect: %w", err)
 	}
 
-	app.Commands = commands
+	app.Commands = append(commands, h.applyCommand())
 	app.HideHelp = true
 	// Explicitly re-add help only as flags, not as commands
 	app.Flags = []cli.Flag{cli.HelpFlag}
@@ -0,0 +1,129 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/urfave/cli"
+)
+
+func (h *configHandler) applyCommand() cli.Command {
+	return cli.Command{
+		Name:      "apply",
+		Usage:     "Reconcile the configuration with a declarative YAML or JSON document",
+		ArgsUsage: "FILE",
+		Description: "The document holds folders, devices and options, with the field names of the JSON\n" +
+			"configuration. Listed folders and devices replace the existing ones, options are\n" +
+			"merged. The plan of changes is shown; use - to read the document from stdin.",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "dry-run", Usage: "Show the plan without applying it"},
+		},
+		Action: h.apply,
+	}
+}
+
+func (h *configHandler) apply(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("expected one document to apply")
+	}
+	var bs []byte
+	var err error
+	if name := c.Args().First(); name == "-" {
+		bs, err = io.ReadAll(os.Stdin)
+	} else {
+		bs, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	url := "config/apply"
+	if c.Bool("dry-run") {
+		url += "?dryRun=true"
+	}
+	resp, err := h.client.Post(url, string(bs))
+	if err != nil {
+		return err
+	}
+	body, err := responseToBArray(resp)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(strings.TrimSpace(string(body)))
+	}
+	var plan config.ApplyPlan
+	if err := json.Unmarshal(body, &plan); err != nil {
+		return err
+	}
+
+	if plan.Empty() {
+		fmt.Println("No changes.")
+		return nil
+	}
+	printPlan(plan)
+	if c.Bool("dry-run") {
+		fmt.Println("Dry run, nothing was applied.")
+	} else {
+		fmt.Printf("Applied %d changes.\n", len(plan.Changes))
+	}
+	return nil
+}
+
+func printPlan(plan config.ApplyPlan) {
+	printList := func(what string, list []string) {
+		if len(list) > 0 {
+			fmt.Printf("%s: %s\n", what, strings.Join(list, ", "))
+		}
+	}
+	deviceStrings := func(list []protocol.DeviceID) []string {
+		strs := make([]string, len(list))
+		for i, id := range list {
+			strs[i] = id.String()
+		}
+		return strs
+	}
+	printList("Folders to add", plan.AddedFolders)
+	printList("Folders to change", plan.ChangedFolders)
+	printList("Folders to remove", plan.RemovedFolders)
+	printList("Devices to add", deviceStrings(plan.AddedDevices))
+	printList("Devices to change", deviceStrings(plan.ChangedDevices))
+	printList("Devices to remove", deviceStrings(plan.RemovedDevices))
+	if plan.ChangedOptions {
+		fmt.Println("Options change")
+	}
+
+	fmt.Println()
+	for _, ch := range plan.Changes {
+		switch {
+		case ch.From == nil:
+			fmt.Printf("+ %s: %s\n", ch.Path, planValue(ch.To))
+		case ch.To == nil:
+			fmt.Printf("- %s\n", ch.Path)
+		default:
+			fmt.Printf("~ %s: %s -> %s\n", ch.Path, planValue(ch.From), planValue(ch.To))
+		}
+	}
+	fmt.Println()
+}
+
+func planValue(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bs)
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"net/url"
+	"strconv"
+)
+
+type journalCommand struct {
+	Folder string `arg:"" required:"" help:"Folder ID"`
+	Since  string `placeholder:"TIME" help:"Show changes at or after this time (RFC 3339)"`
+	Until  string `placeholder:"TIME" help:"Show changes before this time (RFC 3339)"`
+	Prefix string `placeholder:"PATH" help:"Show changes to files whose path starts with this"`
+	Device string `placeholder:"ID" help:"Show changes made by this device"`
+	Limit  int    `help:"Show at most this many changes"`
+}
+
+func (j *journalCommand) Run(ctx Context) error {
+	query := make(url.Values)
+	query.Set("folder", j.Folder)
+	for key, value := range map[string]string{"since": j.Since, "until": j.Until, "prefix": j.Prefix, "device": j.Device} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if j.Limit > 0 {
+		query.Set("limit", strconv.Itoa(j.Limit))
+	}
+	return indexDumpOutput("folder/journal?"+query.Encode(), ctx.clientFactory)
+}
@@ -25,6 +25,8 @@ type CLI struct {
 	Debug      debugCommand     `cmd:"" help:"Debug command group"`
 	Operations operationCommand `cmd:"" help:"Operation command group"`
 	Errors     errorsCommand    `cmd:"" help:"Error command group"`
+	Search     searchCommand    `cmd:"" help:"Search for files by name in all folders"`
+	APIKeys    apiKeysCommand   `cmd:"" name:"apikeys" help:"Scoped API key command group"`
 	Config     configCommand    `cmd:"" help:"Configuration modification command group" passthrough:""`
 	Stdin      stdinCommand     `cmd:"" name:"-" help:"Read commands from stdin"`
 }
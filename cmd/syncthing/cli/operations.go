@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path/filepath"
 
 	"github.com/alecthomas/kong"
@@ -26,12 +27,17 @@ type defaultIgnoresCommand struct {
 	Path string `arg:""`
 }
 
+type backupDBCommand struct {
+	Path string `arg:"" help:"Directory to write the backup to; must not exist or be empty"`
+}
+
 type operationCommand struct {
 	Restart        struct{}              `cmd:"" help:"Restart syncthing"`
 	Shutdown       struct{}              `cmd:"" help:"Shutdown syncthing"`
 	Upgrade        struct{}              `cmd:"" help:"Upgrade syncthing (if a newer version is available)"`
 	FolderOverride folderOverrideCommand `cmd:"" help:"Override changes on folder (remote for sendonly, local for receiveonly). WARNING: Destructive - deletes/changes your data"`
 	DefaultIgnores defaultIgnoresCommand `cmd:"" help:"Set the default ignores (config) from a file"`
+	BackupDB       backupDBCommand       `cmd:"" name:"backup-db" help:"Back up the database while syncthing is running (restore with syncthing debug db restore)"`
 }
 
 func (*operationCommand) Run(ctx Context, kongCtx *kong.Context) error {
@@ -82,6 +88,18 @@ func (f *folderOverrideCommand) Run(ctx Context) error {
 	return fmt.Errorf("Folder %q not found", rid)
 }
 
+func (b *backupDBCommand) Run(ctx Context) error {
+	// Relative paths are taken to be relative to here, which is usually
+	// where syncthing runs as well.
+	path := b.Path
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	query := make(url.Values)
+	query.Set("path", path)
+	return emptyPost("db/backup?"+query.Encode(), ctx.clientFactory)
+}
+
 func (d *defaultIgnoresCommand) Run(ctx Context) error {
 	client, err := ctx.clientFactory.getClient()
 	if err != nil {
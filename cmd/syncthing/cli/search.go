@@ -0,0 +1,29 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type searchCommand struct {
+	Query  []string `arg:"" required:"" help:"Words to search for; names match if they have a word starting with each"`
+	Folder string   `help:"Search only this folder"`
+	Limit  int      `default:"100" help:"Show at most this many files (0 for no limit)"`
+}
+
+func (s *searchCommand) Run(ctx Context) error {
+	query := make(url.Values)
+	query.Set("q", strings.Join(s.Query, " "))
+	if s.Folder != "" {
+		query.Set("folder", s.Folder)
+	}
+	query.Set("limit", strconv.Itoa(s.Limit))
+	return indexDumpOutput("db/search?"+query.Encode(), ctx.clientFactory)
+}
@@ -18,6 +18,7 @@ type showCommand struct {
 	Discovery    struct{}       `cmd:"" help:"Show the discovered addresses of remote devices (from cache of the running syncthing instance)"`
 	Usage        struct{}       `cmd:"" help:"Show usage report"`
 	Pending      pendingCommand `cmd:"" help:"Pending subcommand group"`
+	Journal      journalCommand `cmd:"" help:"Show the change journal of a folder"`
 }
 
 func (*showCommand) Run(ctx Context, kongCtx *kong.Context) error {
@@ -0,0 +1,219 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/locations"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+type databaseCmd struct {
+	Query   databaseQueryCmd   `cmd:"" help:"List the files of a folder in the database matching the given filters"`
+	Restore databaseRestoreCmd `cmd:"" help:"Replace the database with a backup made while running (syncthing cli operations backup-db)"`
+}
+
+type databaseQueryCmd struct {
+	Folder         string    `arg:"" required:"" help:"Folder ID"`
+	Device         string    `xor:"source" placeholder:"ID" help:"List the files announced by this device, instead of our own"`
+	NeededBy       string    `xor:"source" placeholder:"ID" help:"List the global files this device needs (\"local\" for us)"`
+	Prefix         string    `placeholder:"PATH" help:"Only files whose name starts with this"`
+	Flags          []string  `placeholder:"FLAG" help:"Only files with all of these flags: file, directory, symlink, deleted, invalid, ignored, unsupported, receive-only-changed, must-rescan"`
+	MinSize        int64     `placeholder:"BYTES" help:"Only files at least this large"`
+	MaxSize        int64     `placeholder:"BYTES" help:"Only files at most this large"`
+	ModifiedAfter  time.Time `placeholder:"TIME" help:"Only files modified after this time (RFC 3339)"`
+	ModifiedBefore time.Time `placeholder:"TIME" help:"Only files modified before this time (RFC 3339)"`
+	Limit          int       `help:"Stop after this many files"`
+	Format         string    `enum:"json,csv" default:"json" help:"Output format: JSON, one object per line, or CSV (${enum})"`
+}
+
+func (c databaseQueryCmd) Run() error {
+	sdb, err := sqlite.Open(locations.Get(locations.Database))
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	return c.query(sdb, os.Stdout)
+}
+
+// dbQueryFlags are the flags files can be filtered on.
+var dbQueryFlags = map[string]func(protocol.FileInfo) bool{
+	"file":                 func(f protocol.FileInfo) bool { return f.Type == protocol.FileInfoTypeFile },
+	"directory":            protocol.FileInfo.IsDirectory,
+	"symlink":              protocol.FileInfo.IsSymlink,
+	"deleted":              protocol.FileInfo.IsDeleted,
+	"invalid":              protocol.FileInfo.IsInvalid,
+	"ignored":              protocol.FileInfo.IsIgnored,
+	"unsupported":          protocol.FileInfo.IsUnsupported,
+	"receive-only-changed": protocol.FileInfo.IsReceiveOnlyChanged,
+	"must-rescan":          protocol.FileInfo.MustRescan,
+}
+
+// dbQueryRow is a file as output by the query command.
+type dbQueryRow struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	Modified   time.Time `json:"modified"`
+	Sequence   int64     `json:"sequence"`
+	Deleted    bool      `json:"deleted"`
+	Invalid    bool      `json:"invalid"`
+	LocalFlags string    `json:"localFlags"`
+	Version    string    `json:"version"`
+}
+
+var dbQueryCSVHeader = []string{"name", "type", "size", "modified", "sequence", "deleted", "invalid", "localFlags", "version"}
+
+func (r dbQueryRow) csvRecord() []string {
+	return []string{
+		r.Name, r.Type, strconv.FormatInt(r.Size, 10), r.Modified.Format(time.RFC3339Nano),
+		strconv.FormatInt(r.Sequence, 10), strconv.FormatBool(r.Deleted), strconv.FormatBool(r.Invalid),
+		r.LocalFlags, r.Version,
+	}
+}
+
+func (c databaseQueryCmd) query(sdb db.DB, out io.Writer) error {
+	files, errFn, err := c.files(sdb)
+	if err != nil {
+		return err
+	}
+	matches, err := c.filter()
+	if err != nil {
+		return err
+	}
+
+	var write func(dbQueryRow) error
+	var flush func() error
+	switch c.Format {
+	case "csv":
+		cw := csv.NewWriter(out)
+		if err := cw.Write(dbQueryCSVHeader); err != nil {
+			return err
+		}
+		write = func(r dbQueryRow) error { return cw.Write(r.csvRecord()) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		enc := json.NewEncoder(out)
+		write = func(r dbQueryRow) error { return enc.Encode(r) }
+		flush = func() error { return nil }
+	}
+
+	n := 0
+	for f := range files {
+		if !matches(f) {
+			continue
+		}
+		row := dbQueryRow{
+			Name:       f.Name,
+			Type:       strings.ToLower(strings.TrimPrefix(f.Type.String(), "FILE_INFO_TYPE_")),
+			Size:       f.Size,
+			Modified:   f.ModTime(),
+			Sequence:   f.Sequence,
+			Deleted:    f.IsDeleted(),
+			Invalid:    f.IsInvalid(),
+			LocalFlags: f.LocalFlags.HumanString(),
+			Version:    f.Version.String(),
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+		n++
+		if c.Limit > 0 && n >= c.Limit {
+			break
+		}
+	}
+	if err := errFn(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// files returns the files to filter: those a device needs, or those a
+// device has, starting with the prefix.
+func (c databaseQueryCmd) files(sdb db.DB) (iter.Seq[protocol.FileInfo], func() error, error) {
+	if c.NeededBy != "" {
+		device, err := parseQueryDevice(c.NeededBy)
+		if err != nil {
+			return nil, nil, err
+		}
+		files, errFn := sdb.AllNeededGlobalFiles(c.Folder, device, config.PullOrderAlphabetic, 0, 0)
+		return files, errFn, nil
+	}
+
+	device := protocol.LocalDeviceID
+	if c.Device != "" {
+		var err error
+		if device, err = parseQueryDevice(c.Device); err != nil {
+			return nil, nil, err
+		}
+	}
+	files, errFn := sdb.AllLocalFilesWithPrefix(c.Folder, device, c.Prefix)
+	return files, errFn, nil
+}
+
+// filter returns a function matching the files against the filters.
+func (c databaseQueryCmd) filter() (func(protocol.FileInfo) bool, error) {
+	var flags []func(protocol.FileInfo) bool
+	for _, name := range c.Flags {
+		flag, ok := dbQueryFlags[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown flag %q", name)
+		}
+		flags = append(flags, flag)
+	}
+	prefix := osutil.NormalizedFilename(c.Prefix)
+
+	return func(f protocol.FileInfo) bool {
+		switch {
+		case !strings.HasPrefix(f.Name, prefix):
+			return false
+		case f.Size < c.MinSize:
+			return false
+		case c.MaxSize > 0 && f.Size > c.MaxSize:
+			return false
+		case !c.ModifiedAfter.IsZero() && !f.ModTime().After(c.ModifiedAfter):
+			return false
+		case !c.ModifiedBefore.IsZero() && !f.ModTime().Before(c.ModifiedBefore):
+			return false
+		}
+		for _, flag := range flags {
+			if !flag(f) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseQueryDevice(s string) (protocol.DeviceID, error) {
+	if s == "local" {
+		return protocol.LocalDeviceID, nil
+	}
+	device, err := protocol.DeviceIDFromString(s)
+	if err != nil {
+		return protocol.EmptyDeviceID, errors.New("invalid device ID " + s)
+	}
+	return device, nil
+}
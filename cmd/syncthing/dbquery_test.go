@@ -0,0 +1,125 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestDatabaseQuery(t *testing.T) {
+	sdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sdb.Close() })
+
+	remote := protocol.DeviceID{1, 2, 3}
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	file := func(name string, size int64, version uint64, flags protocol.FlagLocal) protocol.FileInfo {
+		return protocol.FileInfo{
+			Name:       name,
+			Type:       protocol.FileInfoTypeFile,
+			Size:       size,
+			ModifiedS:  modified.Unix() + size,
+			Version:    protocol.Vector{}.Update(protocol.ShortID(version)),
+			LocalFlags: flags,
+		}
+	}
+	local := []protocol.FileInfo{
+		file("a/one", 10, 1, 0),
+		file("a/two", 20, 1, 0),
+		file("b/ignored", 30, 1, protocol.FlagLocalIgnored),
+	}
+	if err := sdb.Update("default", protocol.LocalDeviceID, local); err != nil {
+		t.Fatal(err)
+	}
+	// The remote has a newer a/two, and a/three.
+	remoteFiles := []protocol.FileInfo{
+		file("a/one", 10, 1, 0),
+		file("a/two", 20, 2, 0),
+		file("a/three", 40, 1, 0),
+	}
+	remoteFiles[1].Version = remoteFiles[1].Version.Update(1)
+	for i := range remoteFiles {
+		remoteFiles[i].Sequence = int64(i + 1)
+	}
+	if err := sdb.Update("default", remote, remoteFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(c databaseQueryCmd) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := c.query(sdb, &out); err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		dec := json.NewDecoder(&out)
+		for dec.More() {
+			var row dbQueryRow
+			if err := dec.Decode(&row); err != nil {
+				t.Fatal(err)
+			}
+			res = append(res, row.Name)
+		}
+		slices.Sort(res)
+		return res
+	}
+
+	cases := []struct {
+		cmd  databaseQueryCmd
+		want []string
+	}{
+		{databaseQueryCmd{}, []string{"a/one", "a/two", "b/ignored"}},
+		{databaseQueryCmd{Prefix: "a/"}, []string{"a/one", "a/two"}},
+		{databaseQueryCmd{Flags: []string{"ignored"}}, []string{"b/ignored"}},
+		{databaseQueryCmd{Flags: []string{"invalid", "file"}}, []string{"b/ignored"}},
+		{databaseQueryCmd{MinSize: 15, MaxSize: 25}, []string{"a/two"}},
+		{databaseQueryCmd{ModifiedAfter: modified.Add(15 * time.Second)}, []string{"a/two", "b/ignored"}},
+		{databaseQueryCmd{ModifiedBefore: modified.Add(15 * time.Second)}, []string{"a/one"}},
+		{databaseQueryCmd{Device: remote.String()}, []string{"a/one", "a/three", "a/two"}},
+		{databaseQueryCmd{NeededBy: "local"}, []string{"a/three", "a/two"}},
+		{databaseQueryCmd{NeededBy: "local", Prefix: "a/th"}, []string{"a/three"}},
+	}
+	for _, tc := range cases {
+		tc.cmd.Folder = "default"
+		if got := names(tc.cmd); !slices.Equal(got, tc.want) {
+			t.Errorf("%+v: got %v, want %v", tc.cmd, got, tc.want)
+		}
+	}
+
+	if got := names(databaseQueryCmd{Folder: "default", Limit: 2}); len(got) != 2 {
+		t.Errorf("Limit not applied: %v", got)
+	}
+
+	var out bytes.Buffer
+	if err := (databaseQueryCmd{Folder: "default", Prefix: "a/one", Format: "csv"}).query(sdb, &out); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !slices.Equal(records[0], dbQueryCSVHeader) || records[1][0] != "a/one" || records[1][1] != "file" || records[1][2] != "10" {
+		t.Errorf("Unexpected CSV output %v", records)
+	}
+
+	for _, c := range []databaseQueryCmd{{Flags: []string{"shiny"}}, {Device: "nonsense"}} {
+		c.Folder = "default"
+		if err := c.query(sdb, &out); err == nil {
+			t.Errorf("No error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/gofrs/flock"
+
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/locations"
+)
+
+type databaseRestoreCmd struct {
+	Path     string `arg:"" required:"" type:"existingdir" help:"Directory of the backup"`
+	Validate bool   `help:"Only check that the backup can be restored"`
+}
+
+func (c databaseRestoreCmd) Run() error {
+	if c.Validate {
+		if err := sqlite.ValidateBackup(c.Path); err != nil {
+			return err
+		}
+		slog.Info("Backup is valid", slogutil.FilePath(c.Path))
+		return nil
+	}
+
+	// The database must not be in use while it's replaced.
+	lf := flock.New(locations.Get(locations.LockFile))
+	locked, err := lf.TryLock()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.New("syncthing is running; stop it before restoring the database")
+	}
+	defer lf.Unlock()
+
+	dbPath := locations.Get(locations.Database)
+	if err := sqlite.Restore(c.Path, dbPath); err != nil {
+		return err
+	}
+	slog.Info("Restored database from backup; index IDs were reset so that other devices resend their indexes", slogutil.FilePath(dbPath))
+	return nil
+}
@@ -949,6 +949,7 @@ type debugCmd struct {
 	DatabaseStatistics databaseStatsCmd  `cmd:"" help:"Display database size statistics"`
 	DatabaseCounts     databaseCountsCmd `cmd:"" help:"Display database folder counts"`
 	DatabaseFile       databaseFileCmd   `cmd:"" help:"Display database file metadata"`
+	DB                 databaseCmd       `cmd:"" name:"db" help:"Inspect the database"`
 }
 
 type resetDatabaseCmd struct{}
@@ -63,6 +63,7 @@ angular.module('syncthing.core')
             // emitted by syncthing process
 
             CONFIG_SAVED: 'ConfigSaved',   // Emitted after the config has been saved by the user or by Syncthing itself
+            CONFIG_CHANGED: 'ConfigChanged',   // Emitted when the config has been changed through the REST API, naming the user who did it
             DEVICE_CONNECTED: 'DeviceConnected',   // Generated each time a connection to a device has been established
             DEVICE_DISCONNECTED: 'DeviceDisconnected',   // Generated each time a connection to a device has been terminated
             DEVICE_DISCOVERED: 'DeviceDiscovered',   // Emitted when a new device is discovered using local discovery
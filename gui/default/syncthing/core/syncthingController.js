@@ -27,6 +27,11 @@ angular.module('syncthing.core')
                 // Get index.html again (likely cached) to retrieve the version header
                 $http.get('').success(setVersionFromHeader).error(setVersionFromHeader);
 
+                // Find out which ways of logging in to offer
+                $http.get(authUrlbase + '/methods').success(function (data) {
+                    $scope.login.methods = data;
+                });
+
                 // Can't proceed yet - wait for the page reload after successful login.
                 return;
             }
@@ -71,6 +76,7 @@ angular.module('syncthing.core')
         $scope.login = {
             username: '',
             password: '',
+            methods: { password: true },
             errors: {},
         };
         $scope.completion = {};
@@ -144,12 +150,21 @@ angular.module('syncthing.core')
             $http.post(authUrlbase + '/password', {
               username: $scope.login.username,
               password: $scope.login.password,
+              totpCode: $scope.login.totpCode,
               stayLoggedIn: $scope.login.stayLoggedIn,
             }).then(function () {
                 location.reload();
             }).catch(function (response) {
-                if (response.status === 403) {
-                    $scope.login.errors.badLogin = true;
+                if (response.status === 401 && response.data && response.data.totpRequired) {
+                    $scope.login.totpRequired = true;
+                } else if (response.status === 403) {
+                    if ($scope.login.totpRequired) {
+                        $scope.login.errors.badTOTPCode = true;
+                    } else {
+                        $scope.login.errors.badLogin = true;
+                    }
+                } else if (response.status === 429) {
+                    $scope.login.errors.lockedOut = true;
                 } else {
                     $scope.login.errors.failed = true;
                     console.log('Password authentication failed:', response);
@@ -159,6 +174,14 @@ angular.module('syncthing.core')
             });
         };
 
+        $scope.authenticateOIDC = function () {
+            var url = authUrlbase + '/oidc/login';
+            if ($scope.login.stayLoggedIn) {
+                url += '?stayLoggedIn=true';
+            }
+            location.href = url;
+        };
+
         $scope.logout = function() {
             $http.post(authUrlbase + '/logout', {})
             .then(function () {
@@ -681,12 +704,24 @@ angular.module('syncthing.core')
         $scope.isAuthEnabled = function () {
             // This function should match IsAuthEnabled() in guiconfiguration.go
             var guiCfg = $scope.config && $scope.config.gui;
+            if (window.metadata && window.metadata.username) {
+                // Logged in; non-admins don't get to see the passwords.
+                return true;
+            }
             if (guiCfg) {
-                return guiCfg.authMode === 'ldap' || (guiCfg.user && guiCfg.password);
+                return guiCfg.authMode === 'ldap' || guiCfg.authMode === 'oidc' || hasPasswordUsers(guiCfg)
+                    || (guiCfg.clientCertMode && guiCfg.clientCertMode !== 'off');
             }
             return false;
         };
 
+        function hasPasswordUsers(guiCfg) {
+            // This function should match HasPasswordUsers() in guiconfiguration.go
+            return !!(guiCfg.user && guiCfg.password) || (guiCfg.users || []).some(function (user) {
+                return user.name && user.password;
+            });
+        }
+
         function refreshNoAuthWarning() {
             if (!$scope.system || !$scope.config || !$scope.config.gui) {
                 // We need all to be able to determine the state.
@@ -704,7 +739,7 @@ angular.module('syncthing.core')
                 && !$scope.isAuthEnabled()
                 && !guiCfg.insecureAdminAccess;
 
-            if ((guiCfg.user && guiCfg.password) || guiCfg.authMode === 'ldap') {
+            if ($scope.isAuthEnabled()) {
                 $scope.dismissNotification('authenticationUserAndPassword');
             }
         }
@@ -1625,18 +1660,20 @@ angular.module('syncthing.core')
             showModal('#idqr');
         };
 
+        // Pausing patches only the paused state, which operators are
+        // allowed to do without being able to change the configuration.
         $scope.setDevicePause = function (device, pause) {
             $scope.devices[device].paused = pause;
-            $scope.config.devices = $scope.deviceList();
-            $scope.saveConfig();
+            return $http.patch(urlbase + '/config/devices/' + encodeURIComponent(device), { paused: pause })
+                .finally(refreshConfig).catch($scope.emitHTTPError);
         };
 
         $scope.setFolderPause = function (folder, pause) {
             var cfg = $scope.folders[folder];
             if (cfg) {
                 cfg.paused = pause;
-                $scope.config.folders = folderList($scope.folders);
-                return $scope.saveConfig();
+                return $http.patch(urlbase + '/config/folders/' + encodeURIComponent(folder), { paused: pause })
+                    .finally(refreshConfig).catch($scope.emitHTTPError);
             }
             return $q.when();
         };
@@ -1787,6 +1824,7 @@ angular.module('syncthing.core')
             $scope.tmpGUI = angular.copy($scope.config.gui);
             $scope.tmpRemoteIgnoredDevices = angular.copy($scope.config.remoteIgnoredDevices);
             $scope.tmpDevices = angular.copy($scope.config.devices);
+            $scope.refreshTOTP();
             $('#settings').one('shown.bs.modal', function () {
                 $("#settings a[href='#settings-general']").tab("show");
             }).on('hide.bs.modal', function (event) {
@@ -3145,6 +3183,86 @@ angular.module('syncthing.core')
             });
         });
 
+        $scope.totp = {};
+
+        $scope.refreshTOTP = function () {
+            $scope.totp = {};
+            if (!window.metadata || !window.metadata.username) {
+                return;
+            }
+            $scope.totp.username = window.metadata.username;
+            $http.get(urlbase + '/system/totp').success(function (data) {
+                $scope.totp.status = data;
+            }).error(function () {
+                // Not available, e.g. for users from LDAP.
+            });
+        };
+
+        function totpDone(data) {
+            $scope.totp.enroll = null;
+            $scope.totp.code = '';
+            $scope.totp.error = null;
+            $scope.totp.recoveryCodes = data && data.recoveryCodes;
+            $http.get(urlbase + '/system/totp').success(function (status) {
+                $scope.totp.status = status;
+            });
+            if ($scope.config.gui.totpSecret === undefined) {
+                // Not an admin; the TOTP settings aren't visible to us.
+                return;
+            }
+            // Keep the settings being edited in sync, so that saving them
+            // doesn't undo the change.
+            $http.get(urlbase + '/config/gui').success(function (gui) {
+                [$scope.config.gui, $scope.tmpGUI].forEach(function (cfg) {
+                    if (!cfg) {
+                        return;
+                    }
+                    cfg.totpSecret = gui.totpSecret;
+                    cfg.totpRecoveryCodes = gui.totpRecoveryCodes;
+                    (cfg.users || []).forEach(function (user) {
+                        (gui.users || []).forEach(function (updated) {
+                            if (updated.name === user.name) {
+                                user.totpSecret = updated.totpSecret;
+                                user.totpRecoveryCodes = updated.totpRecoveryCodes;
+                            }
+                        });
+                    });
+                });
+            });
+        }
+
+        function totpError(response) {
+            $scope.totp.error = response.status === 403 ? 'code' : (response.data || response.statusText);
+        }
+
+        $scope.enrollTOTP = function () {
+            $scope.totp.recoveryCodes = null;
+            $http.post(urlbase + '/system/totp/enroll').then(function (response) {
+                var data = response.data;
+                data.qrURL = 'qr/?text=' + encodeURIComponent(data.uri);
+                $scope.totp.enroll = data;
+                $scope.totp.error = null;
+            }).catch(totpError);
+        };
+
+        $scope.confirmTOTP = function () {
+            $http.post(urlbase + '/system/totp/confirm', { code: $scope.totp.code }).then(function (response) {
+                totpDone(response.data);
+            }).catch(totpError);
+        };
+
+        $scope.newTOTPRecoveryCodes = function () {
+            $http.post(urlbase + '/system/totp/recovery', { code: $scope.totp.code }).then(function (response) {
+                totpDone(response.data);
+            }).catch(totpError);
+        };
+
+        $scope.disableTOTP = function () {
+            $http.post(urlbase + '/system/totp/disable', { code: $scope.totp.code }).then(function () {
+                totpDone();
+            }).catch(totpError);
+        };
+
         $scope.setAPIKey = function (cfg) {
             $http.get(urlbase + '/svc/random/string?length=32').success(function (data) {
                 cfg.apiKey = data.random;
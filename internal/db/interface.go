@@ -52,6 +52,10 @@ type DB interface {
 	Update(folder string, device protocol.DeviceID, fs []protocol.FileInfo, opts ...UpdateOption) error
 	Close() error
 
+	// Backup writes a consistent copy of the database, while in use, to
+	// the directory
+	Backup(dir string) error
+
 	// Single files
 	GetDeviceFile(folder string, device protocol.DeviceID, file string) (protocol.FileInfo, bool, error)
 	GetGlobalAvailability(folder, file string) ([]protocol.DeviceID, error)
@@ -73,6 +77,10 @@ type DB interface {
 	AllNeededGlobalFiles(folder string, device protocol.DeviceID, order config.PullOrder, limit, offset int) (iter.Seq[protocol.FileInfo], func() error)
 	AllLocalBlocksWithHash(folder string, hash []byte) (iter.Seq[BlockMapEntry], func() error)
 
+	// Search returns the global files, not deleted, that have a name with
+	// words starting with each of the words of the query.
+	SearchGlobalFiles(folder string, query string, limit int) (iter.Seq[FileMetadata], func() error)
+
 	// Block index management
 	DropBlockIndex(folder string) error
 	PopulateBlockIndex(folder string) error
@@ -101,6 +109,11 @@ type DB interface {
 	GetIndexID(folder string, device protocol.DeviceID) (protocol.IndexID, error)
 	SetIndexID(folder string, device protocol.DeviceID, id protocol.IndexID) error
 
+	// Change journal
+	AppendJournal(folder string, entries []JournalEntry) error
+	AllJournalEntries(folder string, filter JournalFilter) (iter.Seq[JournalEntry], func() error)
+	PruneJournal(folder string, before time.Time, maxEntries int) error
+
 	// MtimeFS
 	DeleteMtime(folder, name string) error
 	GetMtime(folder, name string) (ondisk, virtual time.Time)
@@ -125,6 +138,27 @@ type BlockMapEntry struct {
 	FileName      string
 }
 
+// JournalEntry is a change to a file, as recorded in the change journal.
+type JournalEntry struct {
+	Time    time.Time         `json:"time"`
+	Name    string            `json:"path"`
+	Action  string            `json:"action"` // "modified" or "deleted"
+	Type    string            `json:"type"`   // "file", "dir" or "symlink"
+	Origin  string            `json:"origin"` // "local" for scanned changes, "remote" for pulled ones
+	Device  protocol.DeviceID `json:"device"` // that made the change, if known
+	Version string            `json:"version"`
+}
+
+// JournalFilter selects entries of the change journal. Zero values match
+// everything; a limit of zero means no limit.
+type JournalFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Prefix string
+	Device protocol.DeviceID
+	Limit  int
+}
+
 type KeyValue struct {
 	Key   string
 	Value []byte
@@ -0,0 +1,185 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+)
+
+const mainDBName = "main.db"
+
+var errBackupDirNotEmpty = errors.New("backup directory exists and is not empty")
+
+// Backup writes a copy of the database to the directory, which must not
+// exist or be empty, while the database remains in use. Each of the main
+// and folder databases is copied consistently as of when it's copied; as
+// a restore drops the index IDs anyway, the differences in time between
+// them don't matter.
+func (s *DB) Backup(dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return errBackupDirNotEmpty
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return wrap(err)
+	}
+
+	// The folder databases first, so that every folder in the main
+	// database has one in the backup.
+	if err := s.forEachFolder(func(fdb *folderDB) error {
+		return fdb.vacuumInto(filepath.Join(dir, filepath.Base(fdb.path)))
+	}); err != nil {
+		return err
+	}
+	return s.vacuumInto(filepath.Join(dir, mainDBName))
+}
+
+func (s *baseDB) vacuumInto(path string) error {
+	_, err := s.sql.Exec(`VACUUM INTO ?`, path)
+	return wrap(err, filepath.Base(path))
+}
+
+// ValidateBackup checks that the directory holds a backup made by Backup
+// that can be restored: the main database and those of all its folders are
+// there, intact, and not of a newer schema than we know.
+func ValidateBackup(dir string) error {
+	mainDB, err := openBackupFile(filepath.Join(dir, mainDBName), applicationIDMain)
+	if err != nil {
+		return err
+	}
+	defer mainDB.Close()
+
+	var names []string
+	if err := mainDB.Select(&names, `SELECT database_name FROM folders WHERE database_name IS NOT NULL`); err != nil {
+		return wrap(err)
+	}
+	for _, name := range names {
+		fdb, err := openBackupFile(filepath.Join(dir, filepath.Base(name)), applicationIDFolder)
+		if err != nil {
+			return err
+		}
+		fdb.Close()
+	}
+	return nil
+}
+
+func openBackupFile(path string, applicationID int) (*sqlx.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, wrap(err)
+	}
+	pathURL := url.URL{
+		Scheme:   "file",
+		Path:     fileToUriPath(path),
+		RawQuery: "mode=ro",
+	}
+	sdb, err := sqlx.Open(dbDriver, pathURL.String())
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	var appID int
+	if err := sdb.Get(&appID, `PRAGMA application_id`); err != nil {
+		sdb.Close()
+		return nil, wrap(err, filepath.Base(path))
+	}
+	if appID != applicationID {
+		sdb.Close()
+		return nil, fmt.Errorf("%s: not a Syncthing database of the expected kind", filepath.Base(path))
+	}
+
+	var check string
+	if err := sdb.Get(&check, `PRAGMA integrity_check(1)`); err != nil {
+		sdb.Close()
+		return nil, wrap(err, filepath.Base(path))
+	}
+	if check != "ok" {
+		sdb.Close()
+		return nil, fmt.Errorf("%s: integrity check failed: %s", filepath.Base(path), check)
+	}
+
+	var version int
+	if err := sdb.Get(&version, `SELECT COALESCE(MAX(schema_version), 0) FROM schemamigrations`); err != nil {
+		sdb.Close()
+		return nil, wrap(err, filepath.Base(path))
+	}
+	if version > currentSchemaVersion {
+		sdb.Close()
+		return nil, fmt.Errorf("%s: schema version %d is newer than supported (%d)", filepath.Base(path), version, currentSchemaVersion)
+	}
+
+	return sdb, nil
+}
+
+// Restore replaces the database at path, which must not be in use, with
+// the backup in the directory after validating it. The existing database,
+// if any, is kept alongside with a timestamp suffix. The index IDs are
+// dropped from the restored database, so that other devices send their
+// full indexes again and expect the same from us, as the backup is
+// likely out of date with what they think we know.
+func Restore(backupDir, path string) error {
+	if err := ValidateBackup(backupDir); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		aside := path + ".before-restore-" + time.Now().Format("20060102-150405")
+		if err := os.Rename(path, aside); err != nil {
+			return wrap(err)
+		}
+		slog.Info("Moved existing database aside", slogutil.FilePath(aside))
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return wrap(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(backupDir, "*.db"))
+	if err != nil {
+		return wrap(err)
+	}
+	for _, file := range files {
+		if err := copyFile(file, filepath.Join(path, filepath.Base(file))); err != nil {
+			return wrap(err)
+		}
+	}
+
+	sdb, err := Open(path)
+	if err != nil {
+		return err
+	}
+	if err := sdb.DropAllIndexIDs(); err != nil {
+		sdb.Close()
+		return err
+	}
+	return sdb.Close()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
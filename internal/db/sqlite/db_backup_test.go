@@ -0,0 +1,129 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	dbDir := filepath.Join(t.TempDir(), "db")
+	sdb, err := Open(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+
+	files := []protocol.FileInfo{genFile("test1", 1, 0), genFile("test2", 2, 0)}
+	if err := sdb.Update(folderID, protocol.LocalDeviceID, files); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.SetIndexID(folderID, protocol.DeviceID{42}, 1234); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.PutKV("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Back up while open, and validate the backup
+	backupDir := filepath.Join(t.TempDir(), "backup")
+	if err := sdb.Backup(backupDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBackup(backupDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.Backup(backupDir); err == nil {
+		t.Error("expected error backing up into a non-empty directory")
+	}
+
+	// Changes after the backup are lost when restoring
+	if err := sdb.Update(folderID, protocol.LocalDeviceID, []protocol.FileInfo{genFile("test3", 1, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(backupDir, dbDir); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := filepath.Glob(dbDir + ".before-restore-*"); len(matches) != 1 {
+		t.Error("expected the existing database to be kept, got", matches)
+	}
+
+	sdb, err = Open(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+	for name, exists := range map[string]bool{"test1": true, "test2": true, "test3": false} {
+		if _, ok, err := sdb.GetDeviceFile(folderID, protocol.LocalDeviceID, name); err != nil || ok != exists {
+			t.Errorf("%s: expected existence %v, got %v (%v)", name, exists, ok, err)
+		}
+	}
+	if val, err := sdb.GetKV("key"); err != nil || string(val) != "value" {
+		t.Errorf("expected the KV value to be restored, got %q (%v)", val, err)
+	}
+	if id, err := sdb.GetIndexID(folderID, protocol.DeviceID{42}); err != nil || id == 1234 {
+		t.Errorf("expected the index ID to be dropped, got %v (%v)", id, err)
+	}
+}
+
+func TestValidateBackupErrors(t *testing.T) {
+	t.Parallel()
+
+	sdb, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+	if err := sdb.Update(folderID, protocol.LocalDeviceID, []protocol.FileInfo{genFile("test1", 1, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	backupDir := t.TempDir()
+	if err := sdb.Backup(backupDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing folder database
+	folderDBs, err := filepath.Glob(filepath.Join(backupDir, "folder.*"))
+	if err != nil || len(folderDBs) != 1 {
+		t.Fatal("expected one folder database, got", folderDBs, err)
+	}
+	if err := os.Rename(folderDBs[0], folderDBs[0]+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBackup(backupDir); err == nil {
+		t.Error("expected error for missing folder database")
+	}
+
+	// A folder database in place of the main one
+	if err := os.Rename(folderDBs[0]+".moved", filepath.Join(backupDir, mainDBName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateBackup(backupDir); err == nil {
+		t.Error("expected error for wrong kind of database")
+	}
+
+	// Not a backup at all
+	if err := ValidateBackup(t.TempDir()); err == nil {
+		t.Error("expected error for empty directory")
+	}
+}
@@ -376,6 +376,47 @@ func (s *DB) GetDeviceSequence(folder string, device protocol.DeviceID) (int64,
 	return fdb.GetDeviceSequence(device)
 }
 
+func (s *DB) SearchGlobalFiles(folder string, query string, limit int) (iter.Seq[db.FileMetadata], func() error) {
+	fdb, err := s.getFolderDB(folder, false)
+	if errors.Is(err, errNoSuchFolder) {
+		return func(yield func(db.FileMetadata) bool) {}, func() error { return nil }
+	}
+	if err != nil {
+		return func(yield func(db.FileMetadata) bool) {}, func() error { return err }
+	}
+	return fdb.SearchGlobalFiles(query, limit)
+}
+
+func (s *DB) AppendJournal(folder string, entries []db.JournalEntry) error {
+	fdb, err := s.getFolderDB(folder, true)
+	if err != nil {
+		return err
+	}
+	return fdb.AppendJournal(entries)
+}
+
+func (s *DB) AllJournalEntries(folder string, filter db.JournalFilter) (iter.Seq[db.JournalEntry], func() error) {
+	fdb, err := s.getFolderDB(folder, false)
+	if errors.Is(err, errNoSuchFolder) {
+		return func(yield func(db.JournalEntry) bool) {}, func() error { return nil }
+	}
+	if err != nil {
+		return func(yield func(db.JournalEntry) bool) {}, func() error { return err }
+	}
+	return fdb.AllJournalEntries(filter)
+}
+
+func (s *DB) PruneJournal(folder string, before time.Time, maxEntries int) error {
+	fdb, err := s.getFolderDB(folder, false)
+	if errors.Is(err, errNoSuchFolder) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fdb.PruneJournal(before, maxEntries)
+}
+
 func (s *DB) DeleteMtime(folder, name string) error {
 	fdb, err := s.getFolderDB(folder, false)
 	if errors.Is(err, errNoSuchFolder) {
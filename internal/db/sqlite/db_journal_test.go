@@ -0,0 +1,111 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	sdb, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := sdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// A folder without a journal has no entries
+	entries, err := itererr.Collect(sdb.AllJournalEntries(folderID, db.JournalFilter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatal("expected no entries, got", entries)
+	}
+
+	t0 := time.Unix(1700000000, 0)
+	hour := func(h int) time.Time { return t0.Add(time.Duration(h) * time.Hour) }
+	dev1 := protocol.DeviceID{1}
+	dev2 := protocol.DeviceID{2}
+	if err := sdb.AppendJournal(folderID, []db.JournalEntry{
+		{Time: hour(0), Name: "a/file1", Action: "modified", Type: "file", Origin: "local", Device: dev1, Version: "v1"},
+		{Time: hour(1), Name: "a/file2", Action: "deleted", Type: "file", Origin: "remote", Device: dev2, Version: "v2"},
+		{Time: hour(2), Name: "b", Action: "modified", Type: "dir", Origin: "remote"},
+		{Time: hour(3), Name: "ab", Action: "modified", Type: "symlink", Origin: "local", Device: dev1, Version: "v3"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		filter db.JournalFilter
+		names  []string
+	}{
+		{db.JournalFilter{}, []string{"a/file1", "a/file2", "b", "ab"}},
+		{db.JournalFilter{Since: hour(1)}, []string{"a/file2", "b", "ab"}},
+		{db.JournalFilter{Until: hour(1)}, []string{"a/file1"}},
+		{db.JournalFilter{Since: hour(1), Until: hour(3)}, []string{"a/file2", "b"}},
+		{db.JournalFilter{Prefix: "a/"}, []string{"a/file1", "a/file2"}},
+		{db.JournalFilter{Device: dev1}, []string{"a/file1", "ab"}},
+		{db.JournalFilter{Limit: 2}, []string{"a/file1", "a/file2"}},
+	}
+	for _, tc := range cases {
+		entries, err := itererr.Collect(sdb.AllJournalEntries(folderID, tc.filter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(tc.names) {
+			t.Fatalf("%+v: got %d entries, expected %v", tc.filter, len(entries), tc.names)
+		}
+		for i, e := range entries {
+			if e.Name != tc.names[i] {
+				t.Errorf("%+v: entry %d is %q, expected %q", tc.filter, i, e.Name, tc.names[i])
+			}
+		}
+	}
+
+	// The entries come back as they went in
+	entries, err = itererr.Collect(sdb.AllJournalEntries(folderID, db.JournalFilter{Limit: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := db.JournalEntry{Time: hour(1), Name: "a/file2", Action: "deleted", Type: "file", Origin: "remote", Device: dev2, Version: "v2"}
+	if e := entries[1]; !e.Time.Equal(exp.Time) || e.Name != exp.Name || e.Action != exp.Action || e.Type != exp.Type || e.Origin != exp.Origin || e.Device != exp.Device || e.Version != exp.Version {
+		t.Errorf("got %+v, expected %+v", e, exp)
+	}
+
+	// Prune by time and then by count
+	if err := sdb.PruneJournal(folderID, hour(1), 0); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = itererr.Collect(sdb.AllJournalEntries(folderID, db.JournalFilter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Name != "a/file2" {
+		t.Fatal("unexpected entries after pruning by time", entries)
+	}
+	if err := sdb.PruneJournal(folderID, time.Time{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = itererr.Collect(sdb.AllJournalEntries(folderID, db.JournalFilter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "ab" {
+		t.Fatal("unexpected entries after pruning by count", entries)
+	}
+}
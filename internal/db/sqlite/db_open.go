@@ -68,7 +68,7 @@ func Open(path string, opts ...Option) (*DB, error) {
 	_ = os.MkdirAll(path, 0o700)
 	initTmpDir(path)
 
-	mainPath := filepath.Join(path, "main.db")
+	mainPath := filepath.Join(path, mainDBName)
 	mainBase, err := openBase(mainPath, maxDBConns, pragmas, schemas, migrations)
 	if err != nil {
 		return nil, err
@@ -119,7 +119,7 @@ func OpenForMigration(path string) (*DB, error) {
 	_ = os.MkdirAll(path, 0o700)
 	initTmpDir(path)
 
-	mainPath := filepath.Join(path, "main.db")
+	mainPath := filepath.Join(path, mainDBName)
 	mainBase, err := openBase(mainPath, 1, pragmas, schemas, migrations)
 	if err != nil {
 		return nil, err
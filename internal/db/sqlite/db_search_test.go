@@ -0,0 +1,118 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestSearchGlobalFiles(t *testing.T) {
+	t.Parallel()
+
+	sdb, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := sdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Some files we have, one deleted
+	deleted := genFile("reports/old-report.txt", 1, 0)
+	deleted.SetDeleted(1)
+	local := []protocol.FileInfo{
+		genDir("reports", 0),
+		genFile("reports/Annual_Report_2025.pdf", 1, 0),
+		genFile("photos/holiday.jpg", 1, 0),
+		deleted,
+	}
+	if err := sdb.Update(folderID, protocol.LocalDeviceID, local); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file only a remote device has
+	remote := []protocol.FileInfo{genFile("music/report-song.mp3", 1, 1)}
+	if err := sdb.Update(folderID, protocol.DeviceID{42}, remote); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		query string
+		limit int
+		names []string
+	}{
+		{"report", 0, []string{"music/report-song.mp3", "reports", "reports/Annual_Report_2025.pdf"}},
+		{"REPORT 2025", 0, []string{"reports/Annual_Report_2025.pdf"}},
+		{"annual pdf", 0, []string{"reports/Annual_Report_2025.pdf"}},
+		{"hol", 0, []string{"photos/holiday.jpg"}},
+		{"song", 0, []string{"music/report-song.mp3"}},
+		{"report", 1, []string{"music/report-song.mp3"}},
+		{"old", 0, nil},
+		{"nothing", 0, nil},
+		{`" OR * -`, 0, nil},
+	}
+	for _, tc := range cases {
+		files, err := itererr.Collect(sdb.SearchGlobalFiles(folderID, tc.query, tc.limit))
+		if err != nil {
+			t.Fatalf("%q: %v", tc.query, err)
+		}
+		var names []string
+		for _, f := range files {
+			names = append(names, filepath.ToSlash(f.Name))
+		}
+		if !slices.Equal(names, tc.names) {
+			t.Errorf("%q: got %v, expected %v", tc.query, names, tc.names)
+		}
+	}
+
+	// The index is populated for names without words
+	fdb, err := sdb.getFolderDB(folderID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fdb.sql.Exec(`DELETE FROM file_name_words`); err != nil {
+		t.Fatal(err)
+	}
+	if err := fdb.populateSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := itererr.Collect(sdb.SearchGlobalFiles(folderID, "annual", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Error("expected a file after populating the index, got", files)
+	}
+
+	// Files that are gone are no longer found
+	if err := sdb.DropAllFiles(folderID, protocol.DeviceID{42}); err != nil {
+		t.Fatal(err)
+	}
+	files, err = itererr.Collect(sdb.SearchGlobalFiles(folderID, "song", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Error("expected no files after dropping, got", files)
+	}
+
+	// Unknown folders have no files
+	files, err = itererr.Collect(sdb.SearchGlobalFiles("unknown", "report", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Error("expected no files in unknown folder, got", files)
+	}
+}
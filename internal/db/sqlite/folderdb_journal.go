@@ -0,0 +1,136 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+type journalRow struct {
+	Time     int64
+	Name     string
+	Action   string
+	Type     string
+	Origin   string
+	DeviceID string `db:"device_id"`
+	Version  string
+}
+
+func (r journalRow) entry() (db.JournalEntry, error) {
+	e := db.JournalEntry{
+		Time:    time.Unix(0, r.Time),
+		Name:    r.Name,
+		Action:  r.Action,
+		Type:    r.Type,
+		Origin:  r.Origin,
+		Version: r.Version,
+	}
+	if r.DeviceID != "" {
+		dev, err := protocol.DeviceIDFromString(r.DeviceID)
+		if err != nil {
+			return db.JournalEntry{}, err
+		}
+		e.Device = dev
+	}
+	return e, nil
+}
+
+func (s *folderDB) AppendJournal(entries []db.JournalEntry) error {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	tx, err := s.sql.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return wrap(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	//nolint:sqlclosecheck
+	insertStmt, err := tx.Preparex(`
+		INSERT INTO journal (time, name, action, type, origin, device_id, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return wrap(err, "prepare insert journal")
+	}
+	for _, e := range entries {
+		device := ""
+		if e.Device != protocol.EmptyDeviceID {
+			device = e.Device.String()
+		}
+		if _, err := insertStmt.Exec(e.Time.UnixNano(), osutil.NormalizedFilename(e.Name), e.Action, e.Type, e.Origin, device, e.Version); err != nil {
+			return wrap(err, "insert journal")
+		}
+	}
+	return wrap(tx.Commit())
+}
+
+func (s *folderDB) AllJournalEntries(filter db.JournalFilter) (iter.Seq[db.JournalEntry], func() error) {
+	var conds []string
+	var args []any
+	if !filter.Since.IsZero() {
+		conds = append(conds, "time >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conds = append(conds, "time < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+	if filter.Prefix != "" {
+		prefix := osutil.NormalizedFilename(filter.Prefix)
+		conds = append(conds, "name >= ? AND name < ?")
+		args = append(args, prefix, prefixEnd(prefix))
+	}
+	if filter.Device != protocol.EmptyDeviceID {
+		conds = append(conds, "device_id = ?")
+		args = append(args, filter.Device.String())
+	}
+
+	query := `SELECT time, name, action, type, origin, device_id, version FROM journal`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY idx"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	it, errFn := iterStructs[journalRow](s.sql.Queryx(query, args...))
+	return itererr.Map(it, errFn, journalRow.entry)
+}
+
+func (s *folderDB) PruneJournal(before time.Time, maxEntries int) error {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	if !before.IsZero() {
+		if _, err := s.stmt(`
+			DELETE FROM journal
+			WHERE time < ?
+		`).Exec(before.UnixNano()); err != nil {
+			return wrap(err)
+		}
+	}
+	if maxEntries > 0 {
+		if _, err := s.stmt(`
+			DELETE FROM journal
+			WHERE idx <= (SELECT MAX(idx) FROM journal) - ?
+		`).Exec(maxEntries); err != nil {
+			return wrap(err)
+		}
+	}
+	return nil
+}
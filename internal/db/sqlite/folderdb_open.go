@@ -56,6 +56,10 @@ func openFolderDB(folder, path string, deleteRetention time.Duration) (*folderDB
 	fdb.localDeviceIdx, _ = fdb.deviceIdxLocked(protocol.LocalDeviceID)
 	fdb.tplInput["LocalDeviceIdx"] = fdb.localDeviceIdx
 
+	if err := fdb.populateSearchIndex(); err != nil {
+		return nil, err
+	}
+
 	return fdb, nil
 }
 
@@ -0,0 +1,113 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sqlite
+
+import (
+	"context"
+	"iter"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/lib/osutil"
+)
+
+func (s *folderDB) SearchGlobalFiles(query string, limit int) (iter.Seq[db.FileMetadata], func() error) {
+	words := searchWords(query)
+	if len(words) == 0 {
+		return func(yield func(db.FileMetadata) bool) {}, func() error { return nil }
+	}
+
+	// Names with a word starting with each of the words of the query
+	var matches []string
+	var args []any
+	for _, word := range words {
+		matches = append(matches, `SELECT name_idx FROM file_name_words WHERE word >= ? AND word < ?`)
+		args = append(args, word, prefixEnd(word))
+	}
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	args = append(args, limit)
+
+	it, errFn := iterStructs[db.FileMetadata](s.sql.Queryx(s.expandTemplateVars(`
+		SELECT f.sequence, n.name, f.type, f.modified as modnanos, f.size, f.deleted, f.local_flags as localflags FROM files f
+		INNER JOIN file_names n ON f.name_idx = n.idx
+		WHERE f.name_idx IN (`+strings.Join(matches, " INTERSECT ")+`)
+			AND f.local_flags & {{.FlagLocalGlobal}} != 0 AND NOT f.deleted
+		ORDER BY n.name
+		LIMIT ?
+	`), args...))
+	return itererr.Map(it, errFn, func(m db.FileMetadata) (db.FileMetadata, error) {
+		m.Name = osutil.NativeFilename(m.Name)
+		return m, nil
+	})
+}
+
+// populateSearchIndex adds the words of the names that don't have any, as
+// is the case for all of them when the index was added to an existing
+// database.
+func (s *folderDB) populateSearchIndex() error {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	var missing bool
+	if err := s.stmt(`
+		SELECT EXISTS (SELECT 1 FROM file_names) AND NOT EXISTS (SELECT 1 FROM file_name_words)
+	`).Get(&missing); err != nil {
+		return wrap(err)
+	}
+	if !missing {
+		return nil
+	}
+
+	tx, err := s.sql.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return wrap(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var names []struct {
+		Idx  int64
+		Name string
+	}
+	if err := tx.Select(&names, `SELECT idx, name FROM file_names`); err != nil {
+		return wrap(err)
+	}
+	//nolint:sqlclosecheck
+	insertWordStmt, err := tx.Preparex(`
+		INSERT OR IGNORE INTO file_name_words (word, name_idx)
+		VALUES (?, ?)
+	`)
+	if err != nil {
+		return wrap(err, "prepare insert word")
+	}
+	for _, n := range names {
+		for _, word := range nameWords(n.Name) {
+			if _, err := insertWordStmt.Exec(word, n.Idx); err != nil {
+				return wrap(err, "insert word")
+			}
+		}
+	}
+	return wrap(tx.Commit())
+}
+
+// nameWords returns the words of the last component of the normalized
+// file name, as indexed for searching.
+func nameWords(name string) []string {
+	return searchWords(path.Base(name))
+}
+
+// searchWords splits the string into lower cased words of letters and
+// digits.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
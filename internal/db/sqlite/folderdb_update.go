@@ -59,6 +59,15 @@ func (s *folderDB) Update(device protocol.DeviceID, fs []protocol.FileInfo, opti
 		return wrap(err, "prepare insert name")
 	}
 
+	//nolint:sqlclosecheck
+	insertWordStmt, err := txp.Preparex(`
+		INSERT OR IGNORE INTO file_name_words (word, name_idx)
+		VALUES (?, ?)
+	`)
+	if err != nil {
+		return wrap(err, "prepare insert word")
+	}
+
 	//nolint:sqlclosecheck
 	insertVersionStmt, err := txp.Preparex(`
 		INSERT INTO file_versions (version)
@@ -128,6 +137,11 @@ func (s *folderDB) Update(device protocol.DeviceID, fs []protocol.FileInfo, opti
 		if err := insertNameStmt.Get(&nameIdx, f.Name); err != nil {
 			return wrap(err, "insert name")
 		}
+		for _, word := range nameWords(f.Name) {
+			if _, err := insertWordStmt.Exec(word, nameIdx); err != nil {
+				return wrap(err, "insert word")
+			}
+		}
 
 		var versionIdx int64
 		if err := insertVersionStmt.Get(&versionIdx, f.Version.String()); err != nil {
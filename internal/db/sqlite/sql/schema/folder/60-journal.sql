@@ -0,0 +1,21 @@
+-- Copyright (C) 2026 The Syncthing Authors.
+--
+-- This Source Code Form is subject to the terms of the Mozilla Public
+-- License, v. 2.0. If a copy of the MPL was not distributed with this file,
+-- You can obtain one at https://mozilla.org/MPL/2.0/.
+
+--- The change journal, recording changes to files as they were scanned or
+--- pulled
+CREATE TABLE IF NOT EXISTS journal (
+    idx INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    time INTEGER NOT NULL, -- unix nanos
+    name TEXT NOT NULL COLLATE BINARY,
+    action TEXT NOT NULL, -- modified, deleted
+    type TEXT NOT NULL, -- file, dir, symlink
+    origin TEXT NOT NULL, -- local, remote
+    device_id TEXT NOT NULL COLLATE BINARY, -- the device that made the change, or empty
+    version TEXT NOT NULL COLLATE BINARY
+) STRICT
+;
+CREATE INDEX IF NOT EXISTS journal_time ON journal (time)
+;
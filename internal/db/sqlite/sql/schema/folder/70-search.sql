@@ -0,0 +1,22 @@
+-- Copyright (C) 2026 The Syncthing Authors.
+--
+-- This Source Code Form is subject to the terms of the Mozilla Public
+-- License, v. 2.0. If a copy of the MPL was not distributed with this file,
+-- You can obtain one at https://mozilla.org/MPL/2.0/.
+
+-- Search
+--
+-- The words of each file name, lower cased, for finding files by name. The
+-- words are those of the last path component, split on anything that isn't
+-- a letter or a digit. The words are inserted along with the names, and
+-- removed with them by the cascade.
+CREATE TABLE IF NOT EXISTS file_name_words (
+    word TEXT NOT NULL COLLATE BINARY,
+    name_idx INTEGER NOT NULL,
+    PRIMARY KEY(word, name_idx),
+    FOREIGN KEY(name_idx) REFERENCES file_names(idx) ON DELETE CASCADE
+) STRICT, WITHOUT ROWID
+;
+-- For the cascade when removing names
+CREATE INDEX IF NOT EXISTS file_name_words_name_idx ON file_name_words (name_idx)
+;
@@ -93,6 +93,10 @@ type service struct {
 	listenerAddr         net.Addr
 	exitChan             chan *svcutil.FatalErr
 	miscDB               *db.Typed
+	apiKeyUsage          *apiKeyUsage
+	totp                 *totpAuthenticator
+	configHistory        *configHistory
+	loginLimiter         *loginLimiter
 	shutdownTimeout      time.Duration
 
 	guiErrors slogutil.Recorder
@@ -108,6 +112,12 @@ type Service interface {
 }
 
 func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonName string, m model.Model, defaultSub, diskSub events.BufferedSubscription, evLogger events.Logger, discoverer discover.Manager, connectionsService connections.Service, urService *ur.Service, fss model.FolderSummaryService, errors, systemLog slogutil.Recorder, noUpgrade bool, miscDB *db.Typed) Service {
+	// The history stays subscribed for as long as we run, unlike the
+	// service which subscribes while serving, so that changes made while
+	// the GUI is restarting or failing to start are recorded too.
+	configHistory := newConfigHistory(miscDB)
+	cfg.Subscribe(configHistory)
+
 	return &service{
 		id:      id,
 		cfg:     cfg,
@@ -130,6 +140,10 @@ func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonNam
 		startedOnce:          make(chan struct{}),
 		exitChan:             make(chan *svcutil.FatalErr, 1),
 		miscDB:               miscDB,
+		apiKeyUsage:          newAPIKeyUsage(miscDB),
+		totp:                 newTOTPAuthenticator(cfg),
+		configHistory:        configHistory,
+		loginLimiter:         newLoginLimiter(),
 		shutdownTimeout:      100 * time.Millisecond,
 	}
 }
@@ -139,7 +153,7 @@ func (s *service) WaitForStart() error {
 	return s.startupErr
 }
 
-func (s *service) getListener(guiCfg config.GUIConfiguration) (net.Listener, error) {
+func (s *service) getListener(guiCfg config.GUIConfiguration, clientCerts *clientCertVerifier) (net.Listener, error) {
 	httpsCertFile := locations.Get(locations.HTTPSCertFile)
 	httpsKeyFile := locations.Get(locations.HTTPSKeyFile)
 	cert, err := tls.LoadX509KeyPair(httpsCertFile, httpsKeyFile)
@@ -174,6 +188,7 @@ func (s *service) getListener(guiCfg config.GUIConfiguration) (net.Listener, err
 	}
 	tlsCfg := tlsutil.SecureDefaultWithTLS12()
 	tlsCfg.Certificates = []tls.Certificate{cert}
+	clientCerts.configureTLS(tlsCfg)
 
 	if guiCfg.Network() == "unix" {
 		// When listening on a UNIX socket we should unlink before bind,
@@ -217,7 +232,9 @@ func sendJSON(w http.ResponseWriter, jsonObject interface{}) {
 }
 
 func (s *service) Serve(ctx context.Context) error {
-	listener, err := s.getListener(s.cfg.GUI())
+	guiCfg := s.cfg.GUI()
+	clientCerts := newClientCertVerifier(guiCfg)
+	listener, err := s.getListener(guiCfg, clientCerts)
 	if err != nil {
 		select {
 		case <-s.startedOnce:
@@ -247,6 +264,14 @@ func (s *service) Serve(ctx context.Context) error {
 	s.cfg.Subscribe(s)
 	defer s.cfg.Unsubscribe(s)
 
+	// Lock out logins after repeated failures, as seen in the login
+	// attempt events.
+	limiterCtx, cancelLimiter := context.WithCancel(ctx)
+	defer cancelLimiter()
+	loginSub := s.evLogger.Subscribe(events.LoginAttempt)
+	defer loginSub.Unsubscribe()
+	go s.loginLimiter.run(limiterCtx, loginSub)
+
 	restMux := httprouter.New()
 
 	// The GET handlers
@@ -260,9 +285,11 @@ func (s *service) Serve(ctx context.Context) error {
 	restMux.HandlerFunc(http.MethodGet, "/rest/db/localchanged", s.getDBLocalChanged)         // folder [perpage] [page]
 	restMux.HandlerFunc(http.MethodGet, "/rest/db/status", s.getDBStatus)                     // folder
 	restMux.HandlerFunc(http.MethodGet, "/rest/db/browse", s.getDBBrowse)                     // folder [prefix] [dirsonly] [levels]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/search", s.getDBSearch)                     // q [folder] [limit]
 	restMux.HandlerFunc(http.MethodGet, "/rest/folder/versions", s.getFolderVersions)         // folder
 	restMux.HandlerFunc(http.MethodGet, "/rest/folder/errors", s.getFolderErrors)             // folder [perpage] [page]
 	restMux.HandlerFunc(http.MethodGet, "/rest/folder/pullerrors", s.getFolderErrors)         // folder (deprecated)
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/journal", s.getFolderJournal)           // folder [since] [until] [prefix] [device] [limit]
 	restMux.HandlerFunc(http.MethodGet, "/rest/events", s.getIndexEvents)                     // [since] [limit] [timeout] [events]
 	restMux.HandlerFunc(http.MethodGet, "/rest/events/disk", s.getDiskEvents)                 // [since] [limit] [timeout]
 	restMux.HandlerFunc(http.MethodGet, "/rest/noauth/health", s.getHealth)                   // -
@@ -284,8 +311,11 @@ func (s *service) Serve(ctx context.Context) error {
 	restMux.HandlerFunc(http.MethodGet, "/rest/system/loglevels", s.getSystemDebug)           // -
 	restMux.HandlerFunc(http.MethodGet, "/rest/system/log", s.getSystemLog)                   // [since]
 	restMux.HandlerFunc(http.MethodGet, "/rest/system/log.txt", s.getSystemLogTxt)            // [since]
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/apikeys", s.getAPIKeys)                 // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/totp", s.getTOTP)                       // -
 
 	// The POST handlers
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/backup", s.postDBBackup)                      // path
 	restMux.HandlerFunc(http.MethodPost, "/rest/db/prio", s.postDBPrio)                          // folder file
 	restMux.HandlerFunc(http.MethodPost, "/rest/db/ignores", s.postDBIgnores)                    // folder
 	restMux.HandlerFunc(http.MethodPost, "/rest/db/override", s.postDBOverride)                  // folder
@@ -302,22 +332,36 @@ func (s *service) Serve(ctx context.Context) error {
 	restMux.HandlerFunc(http.MethodPost, "/rest/system/pause", s.makeDevicePauseHandler(true))   // [device]
 	restMux.HandlerFunc(http.MethodPost, "/rest/system/resume", s.makeDevicePauseHandler(false)) // [device]
 	restMux.HandlerFunc(http.MethodPost, "/rest/system/loglevels", s.postSystemDebug)            // [enable] [disable]
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/apikeys", s.postAPIKey)                   // <body>
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/totp/enroll", s.postTOTPEnroll)           // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/totp/confirm", s.postTOTPConfirm)         // <body>
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/totp/recovery", s.postTOTPRecoveryCodes)  // <body>
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/totp/disable", s.postTOTPDisable)         // <body>
 
 	// The DELETE handlers
 	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/devices", s.deletePendingDevices) // device
 	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/folders", s.deletePendingFolders) // folder [device]
+	restMux.HandlerFunc(http.MethodDelete, "/rest/system/apikeys", s.deleteAPIKey)                  // name
+	restMux.HandlerFunc(http.MethodDelete, "/rest/system/totp", s.deleteTOTP)                       // user
 
 	// Config endpoints
 
 	configBuilder := &configMuxBuilder{
-		Router: restMux,
-		id:     s.id,
-		cfg:    s.cfg,
+		Router:   restMux,
+		id:       s.id,
+		cfg:      s.cfg,
+		evLogger: s.evLogger,
+		history:  s.configHistory,
 	}
 
 	configBuilder.registerConfig("/rest/config")
 	configBuilder.registerConfigInsync("/rest/config/insync") // deprecated
 	configBuilder.registerConfigRequiresRestart("/rest/config/restart-required")
+	configBuilder.registerConfigHistory("/rest/config/history")
+	configBuilder.registerConfigApply("/rest/config/apply")
+	configBuilder.registerConfigLocked("/rest/config/locked")
+	configBuilder.registerConfigSchema("/rest/config/schema")
+	configBuilder.registerConfigValidate("/rest/config/validate")
 	configBuilder.registerFolders("/rest/config/folders")
 	configBuilder.registerDevices("/rest/config/devices")
 	configBuilder.registerFolder("/rest/config/folders/:id")
@@ -325,8 +369,11 @@ func (s *service) Serve(ctx context.Context) error {
 	configBuilder.registerDefaultFolder("/rest/config/defaults/folder")
 	configBuilder.registerDefaultDevice("/rest/config/defaults/device")
 	configBuilder.registerDefaultIgnores("/rest/config/defaults/ignores")
+	configBuilder.registerShareRules("/rest/config/sharerules")
+	configBuilder.registerAutoAcceptRules("/rest/config/autoacceptrules")
 	configBuilder.registerOptions("/rest/config/options")
 	configBuilder.registerLDAP("/rest/config/ldap")
+	configBuilder.registerOIDC("/rest/config/oidc")
 	configBuilder.registerGUI("/rest/config/gui")
 
 	// Deprecated config endpoints
@@ -341,8 +388,9 @@ func (s *service) Serve(ctx context.Context) error {
 	debugMux.HandleFunc("/rest/debug/file", s.getDebugFile)
 	restMux.Handler(http.MethodGet, "/rest/debug/*method", debugMux)
 
-	// A handler that disables caching
-	noCacheRestMux := noCacheMiddleware(restMux)
+	// A handler that disables caching, behind the check that the user has
+	// the role required for the route
+	noCacheRestMux := noCacheMiddleware(roleMiddleware(restMux))
 
 	// The main routing handler
 	mux := http.NewServeMux()
@@ -359,8 +407,6 @@ func (s *service) Serve(ctx context.Context) error {
 	promHttpHandler := promhttp.Handler()
 	mux.Handle("/metrics", promHttpHandler)
 
-	guiCfg := s.cfg.GUI()
-
 	// Wrap everything in CSRF protection. The /rest prefix should be
 	// protected, other requests will grant cookies.
 	var handler http.Handler = newCsrfManager(s.id.Short().String(), "/rest", guiCfg, mux, s.miscDB)
@@ -370,14 +416,41 @@ func (s *service) Serve(ctx context.Context) error {
 
 	// Wrap everything in basic auth, if user/password is set.
 	if guiCfg.IsAuthEnabled() {
-		tokenCookieManager := newTokenCookieManager(s.id.Short().String(), guiCfg, s.evLogger, s.miscDB)
-		authMW := newBasicAuthAndSessionMiddleware(tokenCookieManager, guiCfg, s.cfg.LDAP(), handler, s.evLogger)
+		tokenCookieManager := newTokenCookieManager(s.id.Short().String(), guiCfg, s.miscDB)
+		authMW := newBasicAuthAndSessionMiddleware(tokenCookieManager, guiCfg, s.cfg.LDAP(), s.totp, s.loginLimiter, handler, s.evLogger)
 		handler = authMW
 
 		restMux.Handler(http.MethodPost, "/rest/noauth/auth/password", http.HandlerFunc(authMW.passwordAuthHandler))
 
 		// Logout is a no-op without a valid session cookie, so /noauth/ is fine here
 		restMux.Handler(http.MethodPost, "/rest/noauth/auth/logout", http.HandlerFunc(authMW.handleLogout))
+
+		if guiCfg.AuthMode == config.AuthModeOIDC {
+			oidc := newOIDCAuthenticator(s.cfg.OIDC(), guiCfg, tokenCookieManager, s.evLogger)
+			restMux.Handler(http.MethodGet, "/rest/noauth/auth/oidc/login", http.HandlerFunc(oidc.loginHandler))
+			restMux.Handler(http.MethodGet, oidcCallbackPath, http.HandlerFunc(oidc.callbackHandler))
+		}
+	}
+
+	// The login page needs to know which ways of logging in to offer
+	restMux.HandlerFunc(http.MethodGet, "/rest/noauth/auth/methods", func(w http.ResponseWriter, _ *http.Request) {
+		sendJSON(w, map[string]bool{
+			"password": guiCfg.AuthMode == config.AuthModeLDAP || guiCfg.HasPasswordUsers(),
+			"oidc":     guiCfg.AuthMode == config.AuthModeOIDC,
+		})
+	})
+
+	// Requests with scoped API keys are limited to the key's scopes, and
+	// otherwise pass the checks above.
+	handler = &scopedAPIKeyMiddleware{cfg: s.cfg, usage: s.apiKeyUsage, next: handler}
+
+	// Find the user of the client certificate, if any, before anything
+	// else as it may be required.
+	if guiCfg.ClientCertMode != config.ClientCertModeOff {
+		if !guiCfg.UseTLS() {
+			slog.WarnContext(ctx, "GUI client certificate authentication is enabled but HTTPS is not; only HTTPS requests can be authenticated by certificate")
+		}
+		handler = &clientCertMiddleware{verifier: clientCerts, next: handler}
 	}
 
 	// Redirect to HTTPS if we are supposed to
@@ -486,7 +559,7 @@ func (*service) VerifyConfiguration(_, to config.Configuration) error {
 }
 
 func (s *service) CommitConfiguration(from, to config.Configuration) bool {
-	if to.GUI == from.GUI {
+	if guiConfigEqual(to.GUI, from.GUI) && oidcConfigEqual(to.OIDC, from.OIDC) {
 		// No GUI changes, we're done here.
 		return true
 	}
@@ -501,6 +574,32 @@ func (s *service) CommitConfiguration(from, to config.Configuration) bool {
 	return true
 }
 
+// guiConfigEqual compares the GUI configurations, treating nil and empty
+// lists as equal. Scoped API keys and TOTP enrolment are looked up in the
+// current configuration when needed and don't need a restart.
+func guiConfigEqual(a, b config.GUIConfiguration) bool {
+	userEqual := func(x, y config.GUIUser) bool {
+		return x.Name == y.Name && x.Password == y.Password && x.Role == y.Role
+	}
+	if !slices.EqualFunc(a.Users, b.Users, userEqual) || !slices.Equal(a.ClientCerts, b.ClientCerts) {
+		return false
+	}
+	a.Users, b.Users = nil, nil
+	a.ClientCerts, b.ClientCerts = nil, nil
+	a.APIKeys, b.APIKeys = nil, nil
+	a.TOTPSecret, b.TOTPSecret = "", ""
+	a.TOTPRecoveryCodes, b.TOTPRecoveryCodes = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// oidcConfigEqual compares the OIDC configurations, treating nil and empty
+// lists as equal.
+func oidcConfigEqual(a, b config.OIDCConfiguration) bool {
+	return a.Issuer == b.Issuer && a.ClientID == b.ClientID && a.ClientSecret == b.ClientSecret &&
+		a.RedirectURL == b.RedirectURL && a.UsernameClaim == b.UsernameClaim && a.GroupsClaim == b.GroupsClaim &&
+		slices.Equal(a.Scopes, b.Scopes) && slices.Equal(a.AllowedGroups, b.AllowedGroups)
+}
+
 func (s *service) fatal(err *svcutil.FatalErr) {
 	// s.exitChan is 1-buffered and whoever is first gets handled.
 	select {
@@ -695,11 +794,14 @@ func (*service) getSystemPaths(w http.ResponseWriter, _ *http.Request) {
 	sendJSON(w, locations.ListExpandedPaths())
 }
 
-func (s *service) getJSMetadata(w http.ResponseWriter, _ *http.Request) {
+func (s *service) getJSMetadata(w http.ResponseWriter, r *http.Request) {
+	user, _ := apiUserFromRequest(r)
 	meta, _ := json.Marshal(map[string]interface{}{
 		"deviceID":      s.id.String(),
 		"deviceIDShort": s.id.Short().String(),
 		"authenticated": true,
+		"username":      user.Name,
+		"role":          requestRole(r).String(),
 	})
 	w.Header().Set("Content-Type", "application/javascript")
 	fmt.Fprintf(w, "var metadata = %s;\n", meta)
@@ -761,6 +863,37 @@ func (s *service) getDBBrowse(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, result)
 }
 
+func (s *service) getDBSearch(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	query := qs.Get("q")
+	folder := qs.Get("folder") // empty means all folders
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if v := qs.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := s.model.SearchFiles(folder, query, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if isFolderNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	sendJSON(w, results)
+}
+
 func (s *service) getDBCompletion(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	folder := qs.Get("folder")    // empty means all folders
@@ -1372,7 +1505,7 @@ func (*service) getEvents(w http.ResponseWriter, r *http.Request, eventSub event
 		evs = evs[len(evs)-limit:]
 	}
 
-	sendJSON(w, evs)
+	sendJSON(w, redactedEvents(r, evs))
 }
 
 func (*service) getEventMask(evs string) events.EventType {
@@ -1499,7 +1632,7 @@ func (s *service) makeDevicePauseHandler(paused bool) http.HandlerFunc {
 
 		var msg string
 		var status int
-		_, err := s.cfg.Modify(func(cfg *config.Configuration) {
+		_, err := s.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 			if deviceStr == "" {
 				for i := range cfg.Devices {
 					cfg.Devices[i].Paused = paused
@@ -1523,6 +1656,9 @@ func (s *service) makeDevicePauseHandler(paused bool) http.HandlerFunc {
 
 			cfg.Devices[i].Paused = paused
 		})
+		if err == nil && msg == "" {
+			emitConfigChanged(r, s.evLogger)
+		}
 
 		if msg != "" {
 			http.Error(w, msg, status)
@@ -1557,6 +1693,25 @@ func (s *service) postDBScan(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *service) postDBBackup(w http.ResponseWriter, r *http.Request) {
+	path, err := fs.ExpandTilde(r.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !filepath.IsAbs(path) {
+		http.Error(w, "Backup path must be absolute", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.model.BackupDatabase(path); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Backed up database", slogutil.FilePath(path))
+	sendJSON(w, map[string]string{"path": path})
+}
+
 func (s *service) postDBPrio(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	folder := qs.Get("folder")
@@ -1617,6 +1772,49 @@ func (s *service) postFolderVersionsRestore(w http.ResponseWriter, r *http.Reque
 	sendJSON(w, errorStringMap(ferr))
 }
 
+func (s *service) getFolderJournal(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	folder := qs.Get("folder")
+	if _, ok := s.cfg.Folder(folder); !ok {
+		http.Error(w, "Folder "+folder+" does not exist", http.StatusNotFound)
+		return
+	}
+
+	filter := db.JournalFilter{Prefix: qs.Get("prefix")}
+	var err error
+	for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := qs.Get(param); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, param+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if v := qs.Get("device"); v != "" {
+		if filter.Device, err = protocol.DeviceIDFromString(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := qs.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, errFn := s.model.ChangeJournal(folder, filter)
+	res := slices.Collect(entries)
+	if err := errFn(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res == nil {
+		res = []db.JournalEntry{}
+	}
+	sendJSON(w, res)
+}
+
 func (s *service) getFolderErrors(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	folder := qs.Get("folder")
@@ -0,0 +1,309 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	apiKeyUsageDBKey        = "apiKeyLastUsed"
+	apiKeyUsageSaveInterval = time.Minute
+	scopedAPIKeyLength      = 32
+	maxAPIKeyRequestSize    = 64 << 10
+)
+
+// apiKeyUsage keeps track of when each scoped API key was last used. The
+// times are persisted in the misc DB, at most once per save interval.
+type apiKeyUsage struct {
+	miscDB *db.Typed
+
+	mut       sync.Mutex
+	lastUsed  map[string]time.Time // key name -> time
+	saveTimer *time.Timer
+}
+
+func newAPIKeyUsage(miscDB *db.Typed) *apiKeyUsage {
+	lastUsed := make(map[string]time.Time)
+	if bs, ok, _ := miscDB.Bytes(apiKeyUsageDBKey); ok {
+		_ = json.Unmarshal(bs, &lastUsed) // best effort
+	}
+	return &apiKeyUsage{
+		miscDB:   miscDB,
+		lastUsed: lastUsed,
+	}
+}
+
+func (u *apiKeyUsage) Touch(name string, when time.Time) {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	u.lastUsed[name] = when
+	u.scheduleSaveLocked()
+}
+
+func (u *apiKeyUsage) LastUsed(name string) time.Time {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	return u.lastUsed[name]
+}
+
+func (u *apiKeyUsage) Forget(name string) {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	delete(u.lastUsed, name)
+	u.scheduleSaveLocked()
+}
+
+func (u *apiKeyUsage) scheduleSaveLocked() {
+	if u.saveTimer == nil {
+		u.saveTimer = time.AfterFunc(apiKeyUsageSaveInterval, u.scheduledSave)
+	}
+}
+
+func (u *apiKeyUsage) scheduledSave() {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+
+	u.saveTimer = nil
+
+	bs, _ := json.Marshal(u.lastUsed)           // can't fail
+	_ = u.miscDB.PutBytes(apiKeyUsageDBKey, bs) // can fail, but what are we going to do?
+}
+
+// scopedAPIKeyMiddleware handles requests carrying a scoped API key,
+// letting them through to what the key's scopes allow and no further.
+// Requests with any other key, or none, are left to the next handler.
+type scopedAPIKeyMiddleware struct {
+	cfg   config.Wrapper
+	usage *apiKeyUsage
+	next  http.Handler
+}
+
+func (m *scopedAPIKeyMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The keys are taken from the current configuration, so that adding
+	// and removing them doesn't require restarting the GUI.
+	value := apiKeyFromHeader(r)
+	if value == "" {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+	key, ok := m.cfg.GUI().ScopedAPIKey(value)
+	if !ok {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		slog.Warn("Rejected expired API key", slog.String("name", key.Name), slog.String("address", r.RemoteAddr))
+		forbidden(w)
+		return
+	}
+	if !scopedAPIKeyAllows(key, r) {
+		slog.Debug("Request outside API key scope", slog.String("name", key.Name), slog.String("method", r.Method), slog.String("path", r.URL.Path))
+		forbidden(w)
+		return
+	}
+
+	m.usage.Touch(key.Name, now)
+
+	role := config.GUIRoleViewer
+	if key.HasScope(config.APIKeyScopeFolder) {
+		role = config.GUIRoleOperator
+	}
+	m.next.ServeHTTP(w, withAPIUser(r, apiUser{Name: key.Name, Role: role, scopedKey: &key}))
+}
+
+// hasScopedAPIKey returns true if the request was let through by
+// scopedAPIKeyMiddleware.
+func hasScopedAPIKey(r *http.Request) bool {
+	user, ok := apiUserFromRequest(r)
+	return ok && user.scopedKey != nil
+}
+
+// scopedAPIKeyAllows returns true if the request is within the scopes of the
+// key. Role restrictions apply on top of this, in roleMiddleware.
+func scopedAPIKeyAllows(key config.GUIAPIKey, r *http.Request) bool {
+	path := r.URL.Path
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	rest := strings.HasPrefix(path, "/rest/")
+
+	switch {
+	case key.HasScope(config.APIKeyScopeMetrics) && read && path == "/metrics":
+		return true
+	case key.HasScope(config.APIKeyScopeEvents) && read && (path == "/rest/events" || path == "/rest/events/disk"):
+		return true
+	case key.HasScope(config.APIKeyScopeRead) && rest && routeRole(r.Method, path) == config.GUIRoleViewer:
+		return true
+	case key.HasScope(config.APIKeyScopeFolder) && isFolderRoute(path) && key.HasFolder(requestFolder(r)):
+		return true
+	default:
+		return false
+	}
+}
+
+// folderRoutes are the routes about the folder given by requestFolder, the
+// only ones the folder scope lets through. Path patterns are as for
+// routeRoles.
+var folderRoutes = []string{
+	"/rest/db/*",
+	"/rest/folder/*",
+	"/rest/config/folders/:id",
+}
+
+func isFolderRoute(path string) bool {
+	return slices.ContainsFunc(folderRoutes, func(pattern string) bool {
+		return matchRoutePattern(pattern, path)
+	})
+}
+
+// requestFolder returns the ID of the folder the request is about, if any.
+func requestFolder(r *http.Request) string {
+	if matchRoutePattern("/rest/config/folders/:id", r.URL.Path) {
+		return r.URL.Path[len("/rest/config/folders/"):]
+	}
+	return r.URL.Query().Get("folder")
+}
+
+// apiKeyFromHeader returns the API key given in the X-API-Key or
+// Authorization header.
+func apiKeyFromHeader(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return auth[len("bearer "):]
+	}
+	return ""
+}
+
+// apiKeyInfo describes a scoped API key, without the key itself.
+type apiKeyInfo struct {
+	Name     string               `json:"name"`
+	Scopes   []config.APIKeyScope `json:"scopes"`
+	Folders  []string             `json:"folders"`
+	Expires  time.Time            `json:"expires"`
+	Expired  bool                 `json:"expired"`
+	LastUsed time.Time            `json:"lastUsed"`
+}
+
+func (s *service) getAPIKeys(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	keys := s.cfg.GUI().APIKeys
+	infos := make([]apiKeyInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = apiKeyInfo{
+			Name:     key.Name,
+			Scopes:   key.Scopes,
+			Folders:  key.Folders,
+			Expires:  key.Expires,
+			Expired:  key.Expired(now),
+			LastUsed: s.apiKeyUsage.LastUsed(key.Name),
+		}
+	}
+	sendJSON(w, infos)
+}
+
+// postAPIKey creates a scoped API key and returns it, including the key
+// value which can't be retrieved later by anyone but admins.
+func (s *service) postAPIKey(w http.ResponseWriter, r *http.Request) {
+	var key config.GUIAPIKey
+	if err := unmarshalTo(http.MaxBytesReader(w, r.Body, maxAPIKeyRequestSize), &key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateNewAPIKey(key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key.Key = rand.String(scopedAPIKeyLength)
+
+	exists := false
+	waiter, err := s.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+		if slices.ContainsFunc(cfg.GUI.APIKeys, func(k config.GUIAPIKey) bool { return k.Name == key.Name }) {
+			exists = true
+			return
+		}
+		cfg.GUI.APIKeys = append(cfg.GUI.APIKeys, key)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		http.Error(w, fmt.Sprintf("an API key named %q already exists", key.Name), http.StatusConflict)
+		return
+	}
+	if !s.finishAPIKeyChange(w, r, waiter) {
+		return
+	}
+	sendJSON(w, key)
+}
+
+func validateNewAPIKey(key config.GUIAPIKey) error {
+	if key.Name == "" {
+		return errors.New("API key name must be given")
+	}
+	if len(key.Scopes) == 0 {
+		return errors.New("at least one scope must be given")
+	}
+	if slices.Contains(key.Scopes, config.APIKeyScopeUnknown) {
+		return errors.New("unknown scope")
+	}
+	if key.HasScope(config.APIKeyScopeFolder) != (len(key.Folders) > 0) {
+		return errors.New("folders must be given for, and only for, the folder scope")
+	}
+	if key.Expired(time.Now()) {
+		return errors.New("expiry time is in the past")
+	}
+	return nil
+}
+
+func (s *service) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	found := false
+	waiter, err := s.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+		before := len(cfg.GUI.APIKeys)
+		cfg.GUI.APIKeys = slices.DeleteFunc(cfg.GUI.APIKeys, func(k config.GUIAPIKey) bool { return k.Name == name })
+		found = len(cfg.GUI.APIKeys) < before
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "No API key with given name", http.StatusNotFound)
+		return
+	}
+	s.apiKeyUsage.Forget(name)
+	s.finishAPIKeyChange(w, r, waiter)
+}
+
+func (s *service) finishAPIKeyChange(w http.ResponseWriter, r *http.Request, waiter config.Waiter) bool {
+	waiter.Wait()
+	emitConfigChanged(r, s.evLogger)
+	if err := s.cfg.Save(); err != nil {
+		slog.Error("Failed to save config", slogutil.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+)
+
+func TestScopedAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{
+		User:       "üser",
+		Password:   "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq", // bcrypt of "räksmörgås" in UTF-8
+		RawAddress: "127.0.0.1:0",
+		APIKey:     testAPIKey,
+		APIKeys: []config.GUIAPIKey{
+			{Name: "reader", Key: "readkey", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}},
+			{Name: "scanner", Key: "folderkey", Scopes: []config.APIKeyScope{config.APIKeyScopeFolder}, Folders: []string{"default"}},
+			{Name: "monitor", Key: "metricskey", Scopes: []config.APIKeyScope{config.APIKeyScopeMetrics, config.APIKeyScopeEvents}},
+			{Name: "old", Key: "expiredkey", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}, Expires: time.Now().Add(-time.Minute)},
+		},
+	})
+	baseURL := startHTTP(t, cfg)
+
+	cases := []struct {
+		key    string
+		method string
+		path   string
+		status int
+	}{
+		{"readkey", http.MethodGet, "/rest/system/status", http.StatusOK},
+		{"readkey", http.MethodPost, "/rest/system/ping", http.StatusOK},
+		{"readkey", http.MethodGet, "/rest/system/log", http.StatusForbidden},
+		{"readkey", http.MethodPost, "/rest/db/scan?folder=default", http.StatusForbidden},
+		{"readkey", http.MethodGet, "/metrics", http.StatusForbidden},
+		{"folderkey", http.MethodPost, "/rest/db/scan?folder=default", http.StatusOK},
+		{"folderkey", http.MethodPost, "/rest/db/scan?folder=other", http.StatusForbidden},
+		{"folderkey", http.MethodPost, "/rest/db/ignores?folder=default", http.StatusForbidden},
+		{"folderkey", http.MethodGet, "/rest/system/status", http.StatusForbidden},
+		{"folderkey", http.MethodPost, "/rest/system/pause?folder=default", http.StatusForbidden},
+		{"folderkey", http.MethodGet, "/rest/config?folder=default", http.StatusForbidden},
+		{"folderkey", http.MethodGet, "/rest/events?folder=default", http.StatusForbidden},
+		{"folderkey", http.MethodGet, "/rest/config/folders/other", http.StatusForbidden},
+		{"metricskey", http.MethodGet, "/metrics", http.StatusOK},
+		{"metricskey", http.MethodGet, "/rest/system/status", http.StatusForbidden},
+		{"expiredkey", http.MethodGet, "/rest/system/status", http.StatusForbidden},
+		{"nosuchkey", http.MethodGet, "/rest/system/status", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		resp := httpRequest(tc.method, baseURL+tc.path, nil, "", "", tc.key, "", "", "", nil, t)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s with %s: got status %d, expected %d", tc.method, tc.path, tc.key, resp.StatusCode, tc.status)
+		}
+	}
+
+	t.Run("last use is tracked", func(t *testing.T) {
+		resp := httpGet(baseURL+"/rest/system/apikeys", "", "", testAPIKey, "", nil, t)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status %d", resp.StatusCode)
+		}
+		var infos []apiKeyInfo
+		if err := json.NewDecoder(resp.Body).Decode(&infos); err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range infos {
+			used := !info.LastUsed.IsZero()
+			if used != (info.Name != "old") {
+				t.Errorf("Key %s: unexpected last use %v", info.Name, info.LastUsed)
+			}
+			if info.Expired != (info.Name == "old") {
+				t.Errorf("Key %s: unexpected expired state %v", info.Name, info.Expired)
+			}
+		}
+	})
+
+	t.Run("scoped keys can't list keys", func(t *testing.T) {
+		resp := httpGet(baseURL+"/rest/system/apikeys", "", "", "readkey", "", nil, t)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Unexpected status %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestValidateNewAPIKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		key config.GUIAPIKey
+		ok  bool
+	}{
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}}, true},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeFolder}, Folders: []string{"default"}}, true},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}, Expires: time.Now().Add(time.Hour)}, true},
+		{config.GUIAPIKey{Scopes: []config.APIKeyScope{config.APIKeyScopeRead}}, false},
+		{config.GUIAPIKey{Name: "a"}, false},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeUnknown}}, false},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeFolder}}, false},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}, Folders: []string{"default"}}, false},
+		{config.GUIAPIKey{Name: "a", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}, Expires: time.Now().Add(-time.Hour)}, false},
+	}
+
+	for i, tc := range cases {
+		if err := validateNewAPIKey(tc.key); (err == nil) != tc.ok {
+			t.Errorf("%d: unexpected result %v", i, err)
+		}
+	}
+}
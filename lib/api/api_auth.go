@@ -8,6 +8,7 @@ package api
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net"
@@ -30,13 +31,16 @@ const (
 	maxLoginRequestSize = 1 << 10 // one kibibyte for username+password
 )
 
-func emitLoginAttempt(success bool, username string, r *http.Request, evLogger events.Logger) {
+func emitLoginAttempt(success bool, user apiUser, r *http.Request, evLogger events.Logger) {
 	remoteAddress, proxy := remoteAddress(r)
 	evData := map[string]any{
 		"success":       success,
-		"username":      username,
+		"username":      user.Name,
 		"remoteAddress": remoteAddress,
 	}
+	if success {
+		evData["role"] = user.Role.String()
+	}
 	if proxy != "" {
 		evData["proxy"] = proxy
 	}
@@ -45,7 +49,7 @@ func emitLoginAttempt(success bool, username string, r *http.Request, evLogger e
 	if success {
 		return
 	}
-	l := slog.Default().With(slogutil.Address(remoteAddress), slog.String("username", username))
+	l := slog.Default().With(slogutil.Address(remoteAddress), slog.String("username", user.Name))
 	if proxy != "" {
 		l = l.With("proxy", proxy)
 	}
@@ -128,15 +132,19 @@ type basicAuthAndSessionMiddleware struct {
 	tokenCookieManager *tokenCookieManager
 	guiCfg             config.GUIConfiguration
 	ldapCfg            config.LDAPConfiguration
+	totp               *totpAuthenticator
+	limiter            *loginLimiter
 	next               http.Handler
 	evLogger           events.Logger
 }
 
-func newBasicAuthAndSessionMiddleware(tokenCookieManager *tokenCookieManager, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, next http.Handler, evLogger events.Logger) *basicAuthAndSessionMiddleware {
+func newBasicAuthAndSessionMiddleware(tokenCookieManager *tokenCookieManager, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, totp *totpAuthenticator, limiter *loginLimiter, next http.Handler, evLogger events.Logger) *basicAuthAndSessionMiddleware {
 	return &basicAuthAndSessionMiddleware{
 		tokenCookieManager: tokenCookieManager,
 		guiCfg:             guiCfg,
 		ldapCfg:            ldapCfg,
+		totp:               totp,
+		limiter:            limiter,
 		next:               next,
 		evLogger:           evLogger,
 	}
@@ -144,19 +152,48 @@ func newBasicAuthAndSessionMiddleware(tokenCookieManager *tokenCookieManager, gu
 
 func (m *basicAuthAndSessionMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if hasValidAPIKeyHeader(r, m.guiCfg) {
-		m.next.ServeHTTP(w, r)
+		// The API key gives full access.
+		m.next.ServeHTTP(w, withAPIUser(r, apiUser{Role: config.GUIRoleAdmin}))
 		return
 	}
 
-	if m.tokenCookieManager.hasValidSession(r) {
+	if hasScopedAPIKey(r) {
+		// Already checked against the key's scopes.
 		m.next.ServeHTTP(w, r)
 		return
 	}
 
+	if name, ok := certUserFromRequest(r); ok && m.guiCfg.ClientCertMode == config.ClientCertModeSufficient {
+		if role, ok := m.guiCfg.CertUserRole(name); ok {
+			// The certificate is presented with each request, so there's
+			// no need for a session.
+			m.next.ServeHTTP(w, withAPIUser(r, apiUser{Name: name, Role: role}))
+			return
+		}
+	}
+
+	if user, ok := m.sessionUser(r); ok && m.certAllows(r, user.Name) {
+		m.next.ServeHTTP(w, withAPIUser(r, user))
+		return
+	}
+
 	// Fall back to Basic auth if provided
-	if username, ok := attemptBasicAuth(r, m.guiCfg, m.ldapCfg, m.evLogger); ok {
-		m.tokenCookieManager.createSession(username, false, w, r)
-		m.next.ServeHTTP(w, r)
+	if username, _, ok := r.BasicAuth(); ok && !m.limiter.checkLoginLockout(w, r, username) {
+		return
+	}
+	if user, ok := attemptBasicAuth(r, m.guiCfg, m.ldapCfg, m.evLogger); ok {
+		if !m.certAllows(r, user.Name) {
+			forbidden(w)
+			return
+		}
+		if m.requiresTOTP(user.Name) {
+			// There's no way to give a second factor with Basic auth.
+			forbidden(w)
+			return
+		}
+		emitLoginAttempt(true, user, r, m.evLogger)
+		m.tokenCookieManager.createSession(user, false, w, r)
+		m.next.ServeHTTP(w, withAPIUser(r, user))
 		return
 	}
 
@@ -176,10 +213,40 @@ func (m *basicAuthAndSessionMiddleware) ServeHTTP(w http.ResponseWriter, r *http
 	forbidden(w)
 }
 
+// sessionUser returns the user of the request's session, if it has a
+// valid one. The role of password users follows the current
+// configuration, so that removing or demoting a user takes effect
+// immediately. Users authenticated elsewhere keep the role they were
+// given at login.
+func (m *basicAuthAndSessionMiddleware) sessionUser(r *http.Request) (apiUser, bool) {
+	user, ok := m.tokenCookieManager.sessionUser(r)
+	if ok && m.guiCfg.AuthMode == config.AuthModeStatic {
+		user.Role, ok = m.guiCfg.UserRole(user.Name)
+	}
+	return user, ok
+}
+
+// requiresTOTP returns true if the named user must give a second factor
+// to log in.
+func (m *basicAuthAndSessionMiddleware) requiresTOTP(username string) bool {
+	return m.guiCfg.AuthMode == config.AuthModeStatic && m.totp.enrolled(username)
+}
+
+// certAllows returns true unless client certificates are required and the
+// request's certificate doesn't belong to the named user.
+func (m *basicAuthAndSessionMiddleware) certAllows(r *http.Request, username string) bool {
+	if m.guiCfg.ClientCertMode != config.ClientCertModeRequired {
+		return true
+	}
+	name, ok := certUserFromRequest(r)
+	return ok && name == username
+}
+
 func (m *basicAuthAndSessionMiddleware) passwordAuthHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Username     string
 		Password     string
+		TOTPCode     string
 		StayLoggedIn bool
 	}
 	if err := unmarshalTo(http.MaxBytesReader(w, r.Body, maxLoginRequestSize), &req); err != nil {
@@ -188,38 +255,59 @@ func (m *basicAuthAndSessionMiddleware) passwordAuthHandler(w http.ResponseWrite
 		return
 	}
 
-	if auth(req.Username, req.Password, m.guiCfg, m.ldapCfg) {
-		m.tokenCookieManager.createSession(req.Username, req.StayLoggedIn, w, r)
+	if !m.limiter.checkLoginLockout(w, r, req.Username) {
+		return
+	}
+
+	if role, ok := auth(req.Username, req.Password, m.guiCfg, m.ldapCfg); ok && m.certAllows(r, req.Username) {
+		if m.requiresTOTP(req.Username) {
+			if req.TOTPCode == "" {
+				// The password was right; ask for the second factor.
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(map[string]bool{"totpRequired": true})
+				return
+			}
+			if !m.totp.verify(req.Username, req.TOTPCode) {
+				emitLoginAttempt(false, apiUser{Name: req.Username}, r, m.evLogger)
+				antiBruteForceSleep()
+				forbidden(w)
+				return
+			}
+		}
+		user := apiUser{Name: req.Username, Role: role}
+		emitLoginAttempt(true, user, r, m.evLogger)
+		m.tokenCookieManager.createSession(user, req.StayLoggedIn, w, r)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	emitLoginAttempt(false, req.Username, r, m.evLogger)
+	emitLoginAttempt(false, apiUser{Name: req.Username}, r, m.evLogger)
 	antiBruteForceSleep()
 	forbidden(w)
 }
 
-func attemptBasicAuth(r *http.Request, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, evLogger events.Logger) (string, bool) {
+func attemptBasicAuth(r *http.Request, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, evLogger events.Logger) (apiUser, bool) {
 	username, password, ok := r.BasicAuth()
 	if !ok {
-		return "", false
+		return apiUser{}, false
 	}
 
 	slog.Debug("Sessionless HTTP request with authentication; this is expensive.")
 
-	if auth(username, password, guiCfg, ldapCfg) {
-		return username, true
+	if role, ok := auth(username, password, guiCfg, ldapCfg); ok {
+		return apiUser{Name: username, Role: role}, true
 	}
 
 	usernameFromIso := string(iso88591ToUTF8([]byte(username)))
 	passwordFromIso := string(iso88591ToUTF8([]byte(password)))
-	if auth(usernameFromIso, passwordFromIso, guiCfg, ldapCfg) {
-		return usernameFromIso, true
+	if role, ok := auth(usernameFromIso, passwordFromIso, guiCfg, ldapCfg); ok {
+		return apiUser{Name: usernameFromIso, Role: role}, true
 	}
 
-	emitLoginAttempt(false, username, r, evLogger)
+	emitLoginAttempt(false, apiUser{Name: username}, r, evLogger)
 	antiBruteForceSleep()
-	return "", false
+	return apiUser{}, false
 }
 
 func (m *basicAuthAndSessionMiddleware) handleLogout(w http.ResponseWriter, r *http.Request) {
@@ -227,7 +315,7 @@ func (m *basicAuthAndSessionMiddleware) handleLogout(w http.ResponseWriter, r *h
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func auth(username string, password string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration) bool {
+func auth(username string, password string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration) (config.GUIRole, bool) {
 	if guiCfg.AuthMode == config.AuthModeLDAP {
 		return authLDAP(username, password, ldapCfg)
 	} else {
@@ -235,11 +323,11 @@ func auth(username string, password string, guiCfg config.GUIConfiguration, ldap
 	}
 }
 
-func authStatic(username string, password string, guiCfg config.GUIConfiguration) bool {
-	return guiCfg.CompareHashedPassword(password) == nil && username == guiCfg.User
+func authStatic(username string, password string, guiCfg config.GUIConfiguration) (config.GUIRole, bool) {
+	return guiCfg.AuthenticateUser(username, password)
 }
 
-func authLDAP(username string, password string, cfg config.LDAPConfiguration) bool {
+func authLDAP(username string, password string, cfg config.LDAPConfiguration) (config.GUIRole, bool) {
 	address := cfg.Address
 	hostname, _, err := net.SplitHostPort(address)
 	if err != nil {
@@ -257,14 +345,14 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 
 	if err != nil {
 		slog.Error("Failed to dial LDAP server", slogutil.Error(err))
-		return false
+		return 0, false
 	}
 
 	if cfg.Transport == config.LDAPTransportStartTLS {
 		err = connection.StartTLS(&tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify})
 		if err != nil {
 			slog.Error("Failed to handshake start TLS With LDAP server", slogutil.Error(err))
-			return false
+			return 0, false
 		}
 	}
 
@@ -274,17 +362,21 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 	err = connection.Bind(bindDN, password)
 	if err != nil {
 		slog.Error("Failed to bind with LDAP server", slogutil.Error(err))
-		return false
+		return 0, false
 	}
 
 	if cfg.SearchFilter == "" && cfg.SearchBaseDN == "" {
+		if cfg.HasGroupRoles() {
+			slog.Error("Bad LDAP configuration: searchFilter and searchBaseDN must be set to assign roles by group")
+			return 0, false
+		}
 		// We're done here.
-		return true
+		return config.GUIRoleAdmin, true
 	}
 
 	if cfg.SearchFilter == "" || cfg.SearchBaseDN == "" {
 		slog.Error("Bad LDAP configuration: both searchFilter and searchBaseDN must be set, or neither")
-		return false
+		return 0, false
 	}
 
 	// If a search filter and search base is set we do an LDAP search for
@@ -294,19 +386,37 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 	searchString := formatOptionalPercentS(cfg.SearchFilter, ldap.EscapeFilter(username))
 	const sizeLimit = 2  // we search for up to two users -- we only want to match one, so getting any number >1 is a failure.
 	const timeLimit = 60 // Search for up to a minute...
-	searchReq := ldap.NewSearchRequest(cfg.SearchBaseDN, ldap.ScopeWholeSubtree, ldap.DerefFindingBaseObj, sizeLimit, timeLimit, false, searchString, nil, nil)
+	var attributes []string
+	groupAttribute := cfg.GroupAttribute
+	if groupAttribute == "" {
+		groupAttribute = "memberOf"
+	}
+	if cfg.HasGroupRoles() {
+		// Group membership attributes are often operational, and only
+		// returned when asked for explicitly.
+		attributes = []string{groupAttribute}
+	}
+	searchReq := ldap.NewSearchRequest(cfg.SearchBaseDN, ldap.ScopeWholeSubtree, ldap.DerefFindingBaseObj, sizeLimit, timeLimit, false, searchString, attributes, nil)
 
 	res, err := connection.Search(searchReq)
 	if err != nil {
 		slog.Warn("Failed LDAP search", slogutil.Error(err))
-		return false
+		return 0, false
 	}
 	if len(res.Entries) != 1 {
 		slog.Warn("Incorrect number of LDAP search results (expected one)", slog.Int("results", len(res.Entries)))
-		return false
+		return 0, false
 	}
 
-	return true
+	if !cfg.HasGroupRoles() {
+		return config.GUIRoleAdmin, true
+	}
+	role, ok := cfg.GroupRole(res.Entries[0].GetAttributeValues(groupAttribute))
+	if !ok {
+		slog.Warn("LDAP user is not a member of any group with a GUI role", slog.String("username", username))
+		return 0, false
+	}
+	return role, true
 }
 
 func formatOptionalPercentS(template string, username string) string {
@@ -0,0 +1,143 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+// clientCertVerifier maps verified TLS client certificates to GUI user
+// names.
+type clientCertVerifier struct {
+	mode  config.ClientCertMode
+	roots *x509.CertPool // nil when there is no client CA
+	certs []config.GUIClientCert
+}
+
+func newClientCertVerifier(guiCfg config.GUIConfiguration) *clientCertVerifier {
+	v := &clientCertVerifier{
+		mode:  guiCfg.ClientCertMode,
+		certs: guiCfg.ClientCerts,
+	}
+	if v.mode == config.ClientCertModeOff || guiCfg.ClientCAFile == "" {
+		return v
+	}
+	roots, err := loadClientCAs(guiCfg.ClientCAFile)
+	if err != nil {
+		// Certificates can still match by pinned fingerprint.
+		slog.Error("Failed to load GUI client CA certificates", slog.String("path", guiCfg.ClientCAFile), slogutil.Error(err))
+		return v
+	}
+	v.roots = roots
+	return v
+}
+
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	path, err := fs.ExpandTilde(path)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bs) {
+		return nil, errors.New("no certificates found")
+	}
+	return roots, nil
+}
+
+// configureTLS makes the TLS server ask for client certificates. They are
+// not verified during the handshake, as pinned certificates may be self
+// signed, but in certUser.
+func (v *clientCertVerifier) configureTLS(tlsCfg *tls.Config) {
+	if v.mode == config.ClientCertModeOff {
+		return
+	}
+	tlsCfg.ClientAuth = tls.RequestClientCert
+	// Advertised to clients, to help them pick a certificate.
+	tlsCfg.ClientCAs = v.roots
+}
+
+// certUser returns the name of the user the connection's client
+// certificate is mapped to, if there is a valid such certificate.
+func (v *clientCertVerifier) certUser(state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", false
+	}
+	leaf := state.PeerCertificates[0]
+
+	for _, c := range v.certs {
+		if c.MatchesFingerprint(leaf.Raw) {
+			return c.User, true
+		}
+	}
+
+	if v.roots == nil {
+		return "", false
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		slog.Debug("Client certificate not verified", slog.String("subject", leaf.Subject.String()), slogutil.Error(err))
+		return "", false
+	}
+	for _, c := range v.certs {
+		if c.Subject != "" && (c.Subject == leaf.Subject.CommonName || c.Subject == leaf.Subject.String()) {
+			return c.User, true
+		}
+	}
+	return "", false
+}
+
+type certUserKey struct{}
+
+// certUserFromRequest returns the name of the user the request's client
+// certificate is mapped to, as found by clientCertMiddleware.
+func certUserFromRequest(r *http.Request) (string, bool) {
+	name, ok := r.Context().Value(certUserKey{}).(string)
+	return name, ok
+}
+
+// clientCertMiddleware records the user of the request's client
+// certificate, and rejects requests without one when certificates are
+// required.
+type clientCertMiddleware struct {
+	verifier *clientCertVerifier
+	next     http.Handler
+}
+
+func (m *clientCertMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := m.verifier.certUser(r.TLS)
+	if !ok {
+		if m.verifier.mode == config.ClientCertModeRequired {
+			forbidden(w)
+			return
+		}
+		m.next.ServeHTTP(w, r)
+		return
+	}
+	m.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), certUserKey{}, name)))
+}
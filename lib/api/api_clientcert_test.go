@@ -0,0 +1,192 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+)
+
+// newTestCert returns a certificate with the given common name, signed by
+// the parent or self signed if there is none.
+func newTestCert(t *testing.T, cn string, isCA bool, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func fingerprint(cert tls.Certificate) string {
+	sum := sha256.Sum256(cert.Certificate[0])
+	hexStr := strings.ToUpper(hex.EncodeToString(sum[:]))
+	var parts []string
+	for i := 0; i < len(hexStr); i += 2 {
+		parts = append(parts, hexStr[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+func TestClientCertUser(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCert(t, "Test CA", true, nil)
+	signed := newTestCert(t, "automation", false, &ca)
+	pinned := newTestCert(t, "laptop", false, nil)
+	impostor := newTestCert(t, "automation", false, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	v := &clientCertVerifier{
+		mode:  config.ClientCertModeSufficient,
+		roots: roots,
+		certs: []config.GUIClientCert{
+			{Subject: "automation", User: "bot"},
+			{Fingerprint: fingerprint(pinned), User: "alice"},
+		},
+	}
+
+	cases := []struct {
+		name  string
+		state *tls.ConnectionState
+		user  string
+	}{
+		{"signed by CA", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{signed.Leaf}}, "bot"},
+		{"pinned", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{pinned.Leaf}}, "alice"},
+		{"not signed by CA", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{impostor.Leaf}}, ""},
+		{"no certificate", &tls.ConnectionState{}, ""},
+		{"no TLS", nil, ""},
+	}
+
+	for _, tc := range cases {
+		user, ok := v.certUser(tc.state)
+		if user != tc.user || ok != (tc.user != "") {
+			t.Errorf("%s: got %q, %v, expected %q", tc.name, user, ok, tc.user)
+		}
+	}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	t.Parallel()
+
+	userCert := newTestCert(t, "user", false, nil)
+	botCert := newTestCert(t, "bot", false, nil)
+
+	get := func(t *testing.T, baseURL string, cert *tls.Certificate, username, password string) (int, string) {
+		t.Helper()
+		tlsCfg := &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+		if cert != nil {
+			tlsCfg.Certificates = []tls.Certificate{*cert}
+		}
+		cli := http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+		req, err := http.NewRequest(http.MethodGet, strings.Replace(baseURL, "http://", "https://", 1)+"/meta.js", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		bs, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(bs)
+	}
+
+	guiCfg := func(mode config.ClientCertMode) config.GUIConfiguration {
+		return config.GUIConfiguration{
+			User:           "üser",
+			Password:       "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq", // bcrypt of "räksmörgås" in UTF-8
+			RawAddress:     "127.0.0.1:0",
+			APIKey:         testAPIKey,
+			Users:          []config.GUIUser{{Name: "bot", Role: config.GUIRoleOperator}},
+			ClientCertMode: mode,
+			ClientCerts: []config.GUIClientCert{
+				{Fingerprint: fingerprint(userCert), User: "üser"},
+				{Fingerprint: fingerprint(botCert), User: "bot"},
+			},
+		}
+	}
+
+	t.Run("sufficient", func(t *testing.T) {
+		t.Parallel()
+		cfg := newMockedConfig()
+		cfg.GUIReturns(guiCfg(config.ClientCertModeSufficient))
+		baseURL := startHTTP(t, cfg)
+
+		status, body := get(t, baseURL, &botCert, "", "")
+		if status != http.StatusOK || !strings.Contains(body, `"username":"bot"`) || !strings.Contains(body, `"role":"operator"`) {
+			t.Errorf("Certificate alone: got %d %s", status, body)
+		}
+		if status, _ := get(t, baseURL, nil, "", ""); status != http.StatusForbidden {
+			t.Errorf("Nothing: got %d", status)
+		}
+		if status, _ := get(t, baseURL, nil, "üser", "räksmörgås"); status != http.StatusOK {
+			t.Errorf("Password alone: got %d", status)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		t.Parallel()
+		cfg := newMockedConfig()
+		cfg.GUIReturns(guiCfg(config.ClientCertModeRequired))
+		baseURL := startHTTP(t, cfg)
+
+		if status, _ := get(t, baseURL, &userCert, "üser", "räksmörgås"); status != http.StatusOK {
+			t.Errorf("Certificate and password: got %d", status)
+		}
+		if status, _ := get(t, baseURL, &userCert, "", ""); status != http.StatusForbidden {
+			t.Errorf("Certificate alone: got %d", status)
+		}
+		if status, _ := get(t, baseURL, nil, "üser", "räksmörgås"); status != http.StatusForbidden {
+			t.Errorf("Password alone: got %d", status)
+		}
+		if status, _ := get(t, baseURL, &botCert, "üser", "räksmörgås"); status != http.StatusForbidden {
+			t.Errorf("Someone else's certificate: got %d", status)
+		}
+	})
+}
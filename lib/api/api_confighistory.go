@@ -0,0 +1,250 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+)
+
+const (
+	configHistoryLatestDBKey = "configHistoryLatest"
+	configHistoryOldestDBKey = "configHistoryOldest"
+	configHistoryDBKeyPrefix = "configHistory/"
+	maxConfigHistory         = 100
+
+	// cliUserAgentPrefix identifies requests from the syncthing cli
+	// command, to tell its changes apart from other API clients.
+	cliUserAgentPrefix = "syncthing-cli/"
+)
+
+// configVersion is a recorded version of the configuration, and the change
+// that led to it.
+type configVersion struct {
+	Version int64                 `json:"version"`
+	Time    time.Time             `json:"time"`
+	Author  config.Author         `json:"author"`
+	Changes []config.Change       `json:"changes"`
+	Config  *config.Configuration `json:"config,omitempty"`
+}
+
+// configHistory records each committed configuration change, who made it
+// and the resulting configuration, in the misc DB. The most recent versions
+// are kept.
+type configHistory struct {
+	miscDB  *db.Typed
+	timeNow func() time.Time // can be overridden for testing
+
+	mut sync.Mutex
+}
+
+func newConfigHistory(miscDB *db.Typed) *configHistory {
+	return &configHistory{
+		miscDB:  miscDB,
+		timeNow: time.Now,
+	}
+}
+
+func (h *configHistory) String() string {
+	return fmt.Sprintf("configHistory@%p", h)
+}
+
+func (*configHistory) CommitConfiguration(_, _ config.Configuration) bool {
+	return true
+}
+
+// AuditConfiguration implements config.Auditor.
+func (h *configHistory) AuditConfiguration(from, to config.Configuration, author config.Author) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	latest, _, err := h.miscDB.Int64(configHistoryLatestDBKey)
+	if err != nil {
+		slog.Error("Failed to read configuration history", slogutil.Error(err))
+		return
+	}
+	now := h.timeNow()
+
+	if latest == 0 {
+		// Record what we started from, so that the first change can be
+		// rolled back too.
+		latest++
+		if err := h.putLocked(configVersion{Version: latest, Time: now, Author: config.AuthorSystem, Config: &from}); err != nil {
+			slog.Error("Failed to record configuration history", slogutil.Error(err))
+			return
+		}
+		_ = h.miscDB.PutInt64(configHistoryOldestDBKey, latest)
+	}
+
+	latest++
+	v := configVersion{
+		Version: latest,
+		Time:    now,
+		Author:  author,
+		Changes: config.Diff(from, to),
+		Config:  &to,
+	}
+	if err := h.putLocked(v); err != nil {
+		slog.Error("Failed to record configuration history", slogutil.Error(err))
+		return
+	}
+	_ = h.miscDB.PutInt64(configHistoryLatestDBKey, latest)
+	slog.Debug("Recorded configuration change", slog.Int64("version", latest), slog.String("author", author.String()), slog.Int("changes", len(v.Changes)))
+
+	h.pruneLocked(latest)
+}
+
+func (h *configHistory) putLocked(v configVersion) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return h.miscDB.PutBytes(configHistoryDBKey(v.Version), bs)
+}
+
+func (h *configHistory) pruneLocked(latest int64) {
+	oldest, _, _ := h.miscDB.Int64(configHistoryOldestDBKey)
+	for ; oldest <= latest-maxConfigHistory; oldest++ {
+		_ = h.miscDB.Delete(configHistoryDBKey(oldest))
+	}
+	_ = h.miscDB.PutInt64(configHistoryOldestDBKey, oldest)
+}
+
+// Versions returns the recorded versions, most recent first, without the
+// configurations themselves.
+func (h *configHistory) Versions() ([]configVersion, error) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	latest, _, err := h.miscDB.Int64(configHistoryLatestDBKey)
+	if err != nil {
+		return nil, err
+	}
+	oldest, _, err := h.miscDB.Int64(configHistoryOldestDBKey)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]configVersion, 0, latest-oldest+1)
+	for version := latest; version >= oldest && version > 0; version-- {
+		v, ok, err := h.getLocked(version)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		v.Config = nil
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Version returns the given recorded version, including the configuration.
+func (h *configHistory) Version(version int64) (configVersion, bool, error) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return h.getLocked(version)
+}
+
+func (h *configHistory) getLocked(version int64) (configVersion, bool, error) {
+	bs, ok, err := h.miscDB.Bytes(configHistoryDBKey(version))
+	if err != nil || !ok {
+		return configVersion{}, false, err
+	}
+	var v configVersion
+	if err := json.Unmarshal(bs, &v); err != nil {
+		return configVersion{}, false, err
+	}
+	return v, true, nil
+}
+
+func configHistoryDBKey(version int64) string {
+	return configHistoryDBKeyPrefix + strconv.FormatInt(version, 10)
+}
+
+// requestAuthor returns who is making the request, for the configuration
+// history.
+func requestAuthor(r *http.Request) config.Author {
+	user, _ := apiUserFromRequest(r)
+	switch {
+	case user.scopedKey != nil:
+		return config.Author{Kind: config.AuthorKindAPIKey, Name: user.scopedKey.Name}
+	case user.Name != "":
+		return config.Author{Kind: config.AuthorKindUser, Name: user.Name}
+	case apiKeyFromHeader(r) != "" && strings.HasPrefix(r.UserAgent(), cliUserAgentPrefix):
+		return config.Author{Kind: config.AuthorKindCLI}
+	case apiKeyFromHeader(r) != "":
+		return config.Author{Kind: config.AuthorKindAPIKey}
+	default:
+		// The GUI, without authentication.
+		return config.Author{Kind: config.AuthorKindUser}
+	}
+}
+
+func (c *configMuxBuilder) registerConfigHistory(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		versions, err := c.history.Versions()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, versions)
+	})
+
+	c.Handle(http.MethodGet, path+"/:version", func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+		if v, ok := c.configVersionFromParams(w, p); ok {
+			sendJSON(w, v)
+		}
+	})
+
+	// Rolling back applies the old configuration like any other change,
+	// so it's verified, committed and recorded as a new version.
+	c.Handle(http.MethodPost, path+"/:version/rollback", func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		v, ok := c.configVersionFromParams(w, p)
+		if !ok {
+			return
+		}
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			*cfg = *v.Config
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.finish(w, r, waiter)
+	})
+}
+
+func (c *configMuxBuilder) configVersionFromParams(w http.ResponseWriter, p httprouter.Params) (configVersion, bool) {
+	version, err := strconv.ParseInt(p.ByName("version"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return configVersion{}, false
+	}
+	v, ok, err := c.history.Version(version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return configVersion{}, false
+	}
+	if !ok || v.Config == nil {
+		http.Error(w, "No configuration version with given number", http.StatusNotFound)
+		return configVersion{}, false
+	}
+	return v, true
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestConfigHistory(t *testing.T) {
+	t.Parallel()
+
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	h := newConfigHistory(db.NewMiscDB(mdb))
+
+	alice := config.Author{Kind: config.AuthorKindUser, Name: "alice"}
+	from := config.Configuration{Version: config.CurrentVersion}
+	to := from.Copy()
+	to.Options.MaxSendKbps = 100
+	h.AuditConfiguration(from, to, alice)
+
+	versions, err := h.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Got %d versions, expected the initial one and the change", len(versions))
+	}
+	if v := versions[0]; v.Version != 2 || v.Author != alice || len(v.Changes) != 1 || v.Changes[0].Path != "options.maxSendKbps" || v.Config != nil {
+		t.Errorf("Unexpected change %+v", v)
+	}
+	if v := versions[1]; v.Version != 1 || v.Author != config.AuthorSystem || len(v.Changes) != 0 {
+		t.Errorf("Unexpected initial version %+v", v)
+	}
+
+	v, ok, err := h.Version(1)
+	if err != nil || !ok {
+		t.Fatal("Initial version missing", err)
+	}
+	if v.Config == nil || v.Config.Options.MaxSendKbps != 0 {
+		t.Errorf("Unexpected initial configuration %+v", v.Config)
+	}
+
+	// Only the most recent versions are kept.
+	for i := range maxConfigHistory {
+		from, to = to, to.Copy()
+		to.Options.MaxSendKbps = 200 + i
+		h.AuditConfiguration(from, to, config.AuthorSystem)
+	}
+	versions, err = h.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != maxConfigHistory {
+		t.Errorf("Got %d versions, expected %d", len(versions), maxConfigHistory)
+	}
+	if _, ok, _ := h.Version(2); ok {
+		t.Error("Old version not pruned")
+	}
+	if v := versions[0]; v.Version != maxConfigHistory+2 {
+		t.Errorf("Latest version is %d, expected %d", v.Version, maxConfigHistory+2)
+	}
+}
+
+func TestConfigHistoryRollback(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "syncthing-testConfig-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	cfg := config.New(protocol.LocalDeviceID)
+	cfg.GUI.RawAddress = "127.0.0.1:0"
+	cfg.GUI.APIKey = testAPIKey
+	w := config.Wrap(tmpFile.Name(), cfg, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Serve(ctx)
+	baseURL := startHTTP(t, w)
+
+	cli := &http.Client{Timeout: 15 * time.Second}
+	do := func(method, path, userAgent string, body any) *http.Response {
+		t.Helper()
+		var bs []byte
+		if body != nil {
+			bs, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, baseURL+path, bytes.NewReader(bs))
+		req.Header.Set("X-API-Key", testAPIKey)
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: got status %d", method, path, resp.StatusCode)
+		}
+		return resp
+	}
+	history := func() []configVersion {
+		t.Helper()
+		resp := do(http.MethodGet, "/rest/config/history", "test", nil)
+		defer resp.Body.Close()
+		var versions []configVersion
+		if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+			t.Fatal(err)
+		}
+		return versions
+	}
+
+	do(http.MethodPatch, "/rest/config/options", cliUserAgentPrefix+"test", map[string]int{"maxSendKbps": 100}).Body.Close()
+
+	versions := history()
+	if len(versions) != 2 {
+		t.Fatalf("Got %d versions, expected 2", len(versions))
+	}
+	if v := versions[0]; v.Author.Kind != config.AuthorKindCLI || len(v.Changes) != 1 || v.Changes[0].Path != "options.maxSendKbps" {
+		t.Errorf("Unexpected change %+v", v)
+	}
+
+	do(http.MethodPost, fmt.Sprintf("/rest/config/history/%d/rollback", versions[1].Version), "test", nil).Body.Close()
+
+	if kbps := w.Options().MaxSendKbps; kbps != 0 {
+		t.Errorf("Rate limit is %d after rollback, expected 0", kbps)
+	}
+	versions = history()
+	if len(versions) != 3 {
+		t.Fatalf("Got %d versions, expected 3", len(versions))
+	}
+	if v := versions[0]; v.Author.Kind != config.AuthorKindAPIKey || len(v.Changes) != 1 || v.Changes[0].To != 0.0 {
+		t.Errorf("Unexpected rollback %+v", v)
+	}
+}
@@ -47,7 +47,7 @@ func newCsrfManager(unique string, prefix string, apiKeyValidator apiKeyValidato
 
 func (m *csrfManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Allow requests carrying a valid API key
-	if hasValidAPIKeyHeader(r, m.apiKeyValidator) {
+	if hasValidAPIKeyHeader(r, m.apiKeyValidator) || hasScopedAPIKey(r) {
 		// Set the access-control-allow-origin header for CORS requests
 		// since a valid API key has been provided
 		w.Header().Add("Access-Control-Allow-Origin", "*")
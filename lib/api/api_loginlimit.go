@@ -0,0 +1,142 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+const (
+	loginFailuresBeforeLockout = 5
+	loginLockoutBase           = 10 * time.Second
+	maxLoginLockout            = time.Hour
+	loginFailureMemory         = 24 * time.Hour
+)
+
+type loginFailures struct {
+	count int
+	last  time.Time
+}
+
+// loginLimiter locks out logins for user names and from addresses with
+// repeated failed login attempts, for a time that doubles with each further
+// failure. It learns about attempts from the LoginAttempt events.
+type loginLimiter struct {
+	timeNow func() time.Time // can be overridden for testing
+
+	mut      sync.Mutex
+	failures map[string]*loginFailures // "user:name" or "addr:address"
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		timeNow:  time.Now,
+		failures: make(map[string]*loginFailures),
+	}
+}
+
+func (l *loginLimiter) run(ctx context.Context, sub events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			l.handle(ev)
+		}
+	}
+}
+
+func (l *loginLimiter) handle(ev events.Event) {
+	data, ok := ev.Data.(map[string]any)
+	if !ok {
+		return
+	}
+	success, _ := data["success"].(bool)
+	username, _ := data["username"].(string)
+	address, _ := data["remoteAddress"].(string)
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	for key, f := range l.failures {
+		if ev.Time.Sub(f.last) > loginFailureMemory {
+			delete(l.failures, key)
+		}
+	}
+
+	for _, key := range loginLimitKeys(username, address) {
+		if success {
+			delete(l.failures, key)
+			continue
+		}
+		f, ok := l.failures[key]
+		if !ok {
+			f = &loginFailures{}
+			l.failures[key] = f
+		}
+		f.count++
+		f.last = ev.Time
+	}
+}
+
+// lockedFor returns how much longer logins for the user name, or from the
+// address, are locked out.
+func (l *loginLimiter) lockedFor(username, address string) time.Duration {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	now := l.timeNow()
+	var longest time.Duration
+	for _, key := range loginLimitKeys(username, address) {
+		f, ok := l.failures[key]
+		if !ok || f.count < loginFailuresBeforeLockout {
+			continue
+		}
+		lockout := maxLoginLockout
+		if shift := f.count - loginFailuresBeforeLockout; shift < 16 {
+			lockout = min(loginLockoutBase<<shift, maxLoginLockout)
+		}
+		if remaining := f.last.Add(lockout).Sub(now); remaining > longest {
+			longest = remaining
+		}
+	}
+	return longest
+}
+
+func loginLimitKeys(username, address string) []string {
+	var keys []string
+	if username != "" {
+		keys = append(keys, "user:"+username)
+	}
+	if address != "" {
+		keys = append(keys, "addr:"+address)
+	}
+	return keys
+}
+
+// checkLoginLockout responds with 429 Too Many Requests and returns false
+// if logins for the user name, or from the request's address, are locked
+// out.
+func (l *loginLimiter) checkLoginLockout(w http.ResponseWriter, r *http.Request, username string) bool {
+	address, _ := remoteAddress(r)
+	d := l.lockedFor(username, address)
+	if d <= 0 {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(d.Seconds())+1))
+	http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return false
+}
@@ -0,0 +1,624 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	oidcCallbackPath      = "/rest/noauth/auth/oidc/callback"
+	oidcLoginTimeout      = 10 * time.Minute
+	maxPendingOIDCLogins  = 100
+	oidcKeysRefreshPeriod = time.Minute // minimum time between JWKS fetches
+	oidcClockSkew         = time.Minute
+	oidcHTTPTimeout       = 10 * time.Second
+	maxOIDCResponseSize   = 1 << 20
+)
+
+// oidcAuthenticator implements the OpenID Connect authorization code flow
+// with PKCE against a single identity provider. Successful logins result
+// in a regular session cookie, issued by the tokenCookieManager.
+type oidcAuthenticator struct {
+	cfg                config.OIDCConfiguration
+	guiCfg             config.GUIConfiguration
+	tokenCookieManager *tokenCookieManager
+	evLogger           events.Logger
+	client             *http.Client
+
+	mut        sync.Mutex
+	provider   *oidcProvider
+	keys       map[string]crypto.PublicKey // by key ID
+	keysLoaded time.Time
+	pending    map[string]oidcPendingLogin // by state
+}
+
+// oidcProvider is the subset of the provider metadata document that we
+// use.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcPendingLogin struct {
+	nonce        string
+	verifier     string
+	redirectURL  string
+	stayLoggedIn bool
+	expires      time.Time
+}
+
+func newOIDCAuthenticator(cfg config.OIDCConfiguration, guiCfg config.GUIConfiguration, tokenCookieManager *tokenCookieManager, evLogger events.Logger) *oidcAuthenticator {
+	return &oidcAuthenticator{
+		cfg:                cfg,
+		guiCfg:             guiCfg,
+		tokenCookieManager: tokenCookieManager,
+		evLogger:           evLogger,
+		client:             &http.Client{Timeout: oidcHTTPTimeout},
+		pending:            make(map[string]oidcPendingLogin),
+	}
+}
+
+// loginHandler starts a login by redirecting the browser to the identity
+// provider.
+func (a *oidcAuthenticator) loginHandler(w http.ResponseWriter, r *http.Request) {
+	provider, err := a.getProvider(r.Context())
+	if err != nil {
+		slog.Error("Failed to get OpenID Connect provider configuration", slogutil.Error(err))
+		http.Error(w, "Failed to contact identity provider", http.StatusBadGateway)
+		return
+	}
+
+	authURL, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		slog.Error("Bad OpenID Connect authorization endpoint", slogutil.Error(err))
+		http.Error(w, "Failed to contact identity provider", http.StatusBadGateway)
+		return
+	}
+
+	state := rand.String(randomTokenLength)
+	login := oidcPendingLogin{
+		nonce:        rand.String(randomTokenLength),
+		verifier:     rand.String(randomTokenLength),
+		redirectURL:  a.redirectURL(r),
+		stayLoggedIn: r.URL.Query().Get("stayLoggedIn") == "true",
+		expires:      time.Now().Add(oidcLoginTimeout),
+	}
+	a.addPending(state, login)
+
+	challenge := sha256.Sum256([]byte(login.verifier))
+	params := authURL.Query()
+	params.Set("response_type", "code")
+	params.Set("client_id", a.cfg.ClientID)
+	params.Set("redirect_uri", login.redirectURL)
+	params.Set("scope", strings.Join(a.cfg.RequestedScopes(), " "))
+	params.Set("state", state)
+	params.Set("nonce", login.nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+	authURL.RawQuery = params.Encode()
+
+	// The state is also kept in a cookie, binding the login to this
+	// browser. It must survive the cross site redirect back to us, hence
+	// SameSite=Lax.
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.stateCookieName(),
+		Value:    state,
+		Path:     oidcCallbackPath,
+		MaxAge:   int(oidcLoginTimeout / time.Second),
+		Secure:   isHTTPSRequest(r) || a.guiCfg.UseTLS(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL.String(), http.StatusFound)
+}
+
+// callbackHandler completes a login when the identity provider redirects
+// the browser back to us.
+func (a *oidcAuthenticator) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	state := params.Get("state")
+	login, ok := a.takePending(state)
+
+	// Whatever happens, the state cookie is spent.
+	http.SetCookie(w, &http.Cookie{
+		Name:   a.stateCookieName(),
+		Path:   oidcCallbackPath,
+		MaxAge: -1,
+	})
+
+	if !ok || !a.hasStateCookie(r, state) {
+		slog.Warn("OpenID Connect callback with unknown or expired state")
+		forbidden(w)
+		return
+	}
+	if errCode := params.Get("error"); errCode != "" {
+		slog.Warn("OpenID Connect login failed at identity provider", slog.String("error", errCode), slog.String("description", params.Get("error_description")))
+		forbidden(w)
+		return
+	}
+
+	username, err := a.authenticate(r.Context(), params.Get("code"), login)
+	if err != nil {
+		slog.Warn("OpenID Connect login failed", slogutil.Error(err))
+		emitLoginAttempt(false, apiUser{Name: username}, r, a.evLogger)
+		forbidden(w)
+		return
+	}
+
+	// Users admitted by the identity provider are admins.
+	user := apiUser{Name: username, Role: config.GUIRoleAdmin}
+	emitLoginAttempt(true, user, r, a.evLogger)
+	a.tokenCookieManager.createSession(user, login.stayLoggedIn, w, r)
+	http.Redirect(w, r, a.guiRoot()+"/", http.StatusFound)
+}
+
+// authenticate exchanges the authorization code for an ID token, verifies
+// it, and returns the user name if the user is admitted. The user name
+// may be returned along with an error, for logging purposes.
+func (a *oidcAuthenticator) authenticate(ctx context.Context, code string, login oidcPendingLogin) (string, error) {
+	if code == "" {
+		return "", errors.New("no authorization code")
+	}
+	provider, err := a.getProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	idToken, err := a.exchangeCode(ctx, provider, code, login)
+	if err != nil {
+		return "", fmt.Errorf("token exchange: %w", err)
+	}
+	claims, err := a.verifyIDToken(ctx, provider, idToken, login.nonce, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("ID token: %w", err)
+	}
+	return a.admit(claims)
+}
+
+// admit returns the user name for the given ID token claims, or an error
+// if the user is not a member of any of the allowed groups.
+func (a *oidcAuthenticator) admit(claims map[string]any) (string, error) {
+	username, _ := claims[a.cfg.UsernameClaim].(string)
+	if username == "" {
+		// The subject is always present, but usually not very readable.
+		username, _ = claims["sub"].(string)
+	}
+	if username == "" {
+		return "", errors.New("no user name in ID token")
+	}
+
+	if len(a.cfg.AllowedGroups) == 0 {
+		return username, nil
+	}
+	for _, group := range claimStrings(claims[a.cfg.GroupsClaim]) {
+		if slices.Contains(a.cfg.AllowedGroups, group) {
+			return username, nil
+		}
+	}
+	return username, fmt.Errorf("user %q is not a member of an allowed group", username)
+}
+
+func (a *oidcAuthenticator) exchangeCode(ctx context.Context, provider *oidcProvider, code string, login oidcPendingLogin) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {login.redirectURL},
+		"client_id":     {a.cfg.ClientID},
+		"code_verifier": {login.verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.cfg.ClientSecret != "" {
+		// client_secret_basic, with the encoding required by RFC 6749
+		// section 2.3.1
+		req.SetBasicAuth(url.QueryEscape(a.cfg.ClientID), url.QueryEscape(a.cfg.ClientSecret))
+	}
+
+	var res struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := a.doJSON(req, &res); err != nil {
+		return "", err
+	}
+	if res.IDToken == "" {
+		return "", errors.New("no ID token in response")
+	}
+	return res.IDToken, nil
+}
+
+// verifyIDToken checks the signature and standard claims of the ID token
+// and returns its claims.
+func (a *oidcAuthenticator) verifyIDToken(ctx context.Context, provider *oidcProvider, token, nonce string, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	key, err := a.getKey(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if iss, _ := claims["iss"].(string); iss != provider.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !slices.Contains(claimStrings(claims["aud"]), a.cfg.ClientID) {
+		return nil, errors.New("token not issued for us")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, errors.New("token expired")
+	}
+	if got, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return nil, errors.New("nonce mismatch")
+	}
+	return claims, nil
+}
+
+func (a *oidcAuthenticator) getProvider(ctx context.Context) (*oidcProvider, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if a.provider != nil {
+		return a.provider, nil
+	}
+
+	if a.cfg.Issuer == "" || a.cfg.ClientID == "" {
+		return nil, errors.New("issuer and client ID must be configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(a.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var provider oidcProvider
+	if err := a.doJSON(req, &provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != strings.TrimSuffix(a.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("provider claims to be issuer %q", provider.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("incomplete provider configuration")
+	}
+	a.provider = &provider
+	return a.provider, nil
+}
+
+// getKey returns the provider's signing key with the given ID. The key set
+// is refetched when the ID is unknown, as the provider may have rotated
+// its keys.
+func (a *oidcAuthenticator) getKey(ctx context.Context, provider *oidcProvider, kid string) (crypto.PublicKey, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if key, ok := a.lookupKeyLocked(kid); ok {
+		return key, nil
+	}
+	if time.Since(a.keysLoaded) < oidcKeysRefreshPeriod {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := a.doJSON(req, &jwks); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	a.keys = make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.Debug("Skipping unusable OpenID Connect signing key", slog.String("kid", jwk.Kid), slogutil.Error(err))
+			continue
+		}
+		a.keys[jwk.Kid] = key
+	}
+	a.keysLoaded = time.Now()
+
+	if key, ok := a.lookupKeyLocked(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (a *oidcAuthenticator) lookupKeyLocked(kid string) (crypto.PublicKey, bool) {
+	if key, ok := a.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(a.keys) == 1 {
+		// Tokens without key ID are acceptable when there is no
+		// ambiguity.
+		for _, key := range a.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (a *oidcAuthenticator) doJSON(req *http.Request, into any) error {
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(io.LimitReader(resp.Body, maxOIDCResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s: %s", req.URL, resp.Status, strings.TrimSpace(string(bs)))
+	}
+	return json.Unmarshal(bs, into)
+}
+
+func (a *oidcAuthenticator) addPending(state string, login oidcPendingLogin) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	now := time.Now()
+	for s, p := range a.pending {
+		if now.After(p.expires) {
+			delete(a.pending, s)
+		}
+	}
+	for len(a.pending) >= maxPendingOIDCLogins {
+		// Drop the login that would expire first.
+		var oldest string
+		for s, p := range a.pending {
+			if oldest == "" || p.expires.Before(a.pending[oldest].expires) {
+				oldest = s
+			}
+		}
+		delete(a.pending, oldest)
+	}
+	a.pending[state] = login
+}
+
+func (a *oidcAuthenticator) takePending(state string) (oidcPendingLogin, bool) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	login, ok := a.pending[state]
+	delete(a.pending, state)
+	if !ok || time.Now().After(login.expires) {
+		return oidcPendingLogin{}, false
+	}
+	return login, true
+}
+
+func (a *oidcAuthenticator) hasStateCookie(r *http.Request, state string) bool {
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == a.stateCookieName() && subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *oidcAuthenticator) stateCookieName() string {
+	return "oidcstate-" + a.tokenCookieManager.shortID
+}
+
+// redirectURL returns the callback URL registered with the identity
+// provider. Unless configured, it's derived from the request, which works
+// as long as any reverse proxy preserves the host name and serves the GUI
+// on the session cookie path.
+func (a *oidcAuthenticator) redirectURL(r *http.Request) string {
+	if a.cfg.RedirectURL != "" {
+		return a.cfg.RedirectURL
+	}
+	scheme := "http"
+	if isHTTPSRequest(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + a.guiRoot() + oidcCallbackPath
+}
+
+// guiRoot returns the path the GUI is served on, without trailing slash.
+func (a *oidcAuthenticator) guiRoot() string {
+	return strings.TrimSuffix(a.guiCfg.SessionCookiePath, "/")
+}
+
+// Best effort detection of whether the connection is HTTPS -- either
+// directly to us, or as used by the client towards a reverse proxy who
+// sends us headers.
+func isHTTPSRequest(r *http.Request) bool {
+	return r.TLS != nil ||
+		strings.ToLower(r.Header.Get("X-Forwarded-Proto")) == "https" ||
+		strings.Contains(strings.ToLower(r.Header.Get("Forwarded")), "proto=https")
+}
+
+// claimStrings interprets a claim that may be either a single string or
+// an array of strings, as is the case for "aud" and commonly for group
+// claims.
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+func decodeJWTPart(part string, into any) error {
+	bs, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, into)
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %q does not match RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %q does not match EC key", alg)
+		}
+		// The signature is the fixed size concatenation of r and s.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("bad signature length")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("bad signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
+
+// jsonWebKey is an RSA or EC public key as described in RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("bad exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("bad coordinate length")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4 // uncompressed
+		copy(point[1+size-len(x):], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
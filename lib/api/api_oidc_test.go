@@ -0,0 +1,380 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+// mockIdP is a minimal OpenID Connect provider. It issues a code for
+// every authorization request it has been told about, and signs ID tokens
+// with the given key.
+type mockIdP struct {
+	*httptest.Server
+	key    crypto.Signer
+	alg    string
+	claims map[string]any // extra or overridden ID token claims
+
+	mut   sync.Mutex
+	codes map[string]url.Values // authorization request parameters by code
+}
+
+func newMockIdP(t *testing.T, key crypto.Signer, alg string) *mockIdP {
+	t.Helper()
+	idp := &mockIdP{key: key, alg: alg, codes: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		sendJSON(w, map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		jwk := map[string]string{"kid": "key1", "use": "sig"}
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			bs, _ := pub.Bytes()
+			size := (len(bs) - 1) / 2
+			jwk["kty"] = "EC"
+			jwk["crv"] = pub.Curve.Params().Name
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(bs[1 : 1+size])
+			jwk["y"] = base64.RawURLEncoding.EncodeToString(bs[1+size:])
+		}
+		sendJSON(w, map[string]any{"keys": []any{jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "syncthing" || secret != "s3cret" {
+			http.Error(w, "bad client", http.StatusUnauthorized)
+			return
+		}
+		idp.mut.Lock()
+		params, ok := idp.codes[r.FormValue("code")]
+		delete(idp.codes, r.FormValue("code"))
+		idp.mut.Unlock()
+		if !ok || r.FormValue("redirect_uri") != params.Get("redirect_uri") {
+			http.Error(w, "bad code", http.StatusBadRequest)
+			return
+		}
+		challenge := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(challenge[:]) != params.Get("code_challenge") {
+			http.Error(w, "bad verifier", http.StatusBadRequest)
+			return
+		}
+		sendJSON(w, map[string]string{
+			"access_token": "unused",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, params.Get("nonce")),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// authorize plays the part of the user logging in at the provider,
+// returning the callback URL the browser would be redirected to.
+func (idp *mockIdP) authorize(t *testing.T, location string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := u.Query()
+	if params.Get("code_challenge_method") != "S256" {
+		t.Fatal("expected PKCE with S256")
+	}
+	if !strings.Contains(params.Get("scope"), "openid") {
+		t.Fatal("expected openid scope")
+	}
+	code := "code-" + params.Get("state")
+	idp.mut.Lock()
+	idp.codes[code] = params
+	idp.mut.Unlock()
+
+	cb, err := url.Parse(params.Get("redirect_uri"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb.RawQuery = url.Values{"code": {code}, "state": {params.Get("state")}}.Encode()
+	return cb
+}
+
+func (idp *mockIdP) sign(t *testing.T, nonce string) string {
+	t.Helper()
+	claims := map[string]any{
+		"iss":                idp.URL,
+		"sub":                "1234",
+		"aud":                "syncthing",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              nonce,
+		"preferred_username": "alice",
+		"groups":             []string{"staff", "syncthing-admins"},
+	}
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+	header, _ := json.Marshal(map[string]string{"alg": idp.alg, "kid": "key1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch key := idp.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestOIDCAuthenticator(t *testing.T, idp *mockIdP, allowedGroups ...string) *oidcAuthenticator {
+	t.Helper()
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	guiCfg := config.GUIConfiguration{AuthMode: config.AuthModeOIDC, SessionCookiePath: "/"}
+	cfg := config.OIDCConfiguration{
+		Issuer:        idp.URL,
+		ClientID:      "syncthing",
+		ClientSecret:  "s3cret",
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		AllowedGroups: allowedGroups,
+	}
+	tcm := newTokenCookieManager("ABCDEFG", guiCfg, db.NewMiscDB(mdb))
+	return newOIDCAuthenticator(cfg, guiCfg, tcm, events.NoopLogger)
+}
+
+// oidcLogin runs a full login through the authenticator and the mock
+// provider, returning the callback response.
+func oidcLogin(t *testing.T, a *oidcAuthenticator, idp *mockIdP) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "http://syncthing.example.com/rest/noauth/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	a.loginHandler(rec, req)
+	resp := rec.Result()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("login: unexpected status %d", resp.StatusCode)
+	}
+
+	cb := idp.authorize(t, resp.Header.Get("Location"))
+	if cb.Path != oidcCallbackPath || cb.Host != "syncthing.example.com" {
+		t.Fatalf("unexpected redirect URL %v", cb)
+	}
+	req = httptest.NewRequest(http.MethodGet, cb.String(), nil)
+	for _, c := range resp.Cookies() {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	a.callbackHandler(rec, req)
+	return rec.Result()
+}
+
+func hasOIDCSession(a *oidcAuthenticator, resp *http.Response) bool {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range resp.Cookies() {
+		req.AddCookie(c)
+	}
+	_, ok := a.tokenCookieManager.sessionUser(req)
+	return ok
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		key           crypto.Signer
+		alg           string
+		claims        map[string]any
+		allowedGroups []string
+		ok            bool
+	}{
+		{name: "rs256", key: rsaKey, alg: "RS256", ok: true},
+		{name: "es256", key: ecKey, alg: "ES256", ok: true},
+		{name: "allowed group", key: rsaKey, alg: "RS256", allowedGroups: []string{"syncthing-admins"}, ok: true},
+		{name: "not in group", key: rsaKey, alg: "RS256", allowedGroups: []string{"wheel"}},
+		{name: "no groups", key: rsaKey, alg: "RS256", claims: map[string]any{"groups": nil}, allowedGroups: []string{"staff"}},
+		{name: "wrong audience", key: rsaKey, alg: "RS256", claims: map[string]any{"aud": []string{"other"}}},
+		{name: "wrong issuer", key: rsaKey, alg: "RS256", claims: map[string]any{"iss": "https://evil.example.com"}},
+		{name: "expired", key: rsaKey, alg: "RS256", claims: map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "wrong nonce", key: rsaKey, alg: "RS256", claims: map[string]any{"nonce": "foo"}},
+		{name: "alg mismatch", key: rsaKey, alg: "ES256"},
+		{name: "alg none", key: rsaKey, alg: "none"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			idp := newMockIdP(t, tc.key, tc.alg)
+			idp.claims = tc.claims
+			a := newTestOIDCAuthenticator(t, idp, tc.allowedGroups...)
+
+			resp := oidcLogin(t, a, idp)
+			if tc.ok {
+				if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/" {
+					t.Fatalf("unexpected response %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+				}
+				if !hasOIDCSession(a, resp) {
+					t.Fatal("expected a valid session")
+				}
+			} else {
+				if resp.StatusCode != http.StatusForbidden {
+					t.Fatalf("unexpected status %d", resp.StatusCode)
+				}
+				if hasOIDCSession(a, resp) {
+					t.Fatal("expected no session")
+				}
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackRequiresState(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := newMockIdP(t, key, "ES256")
+	a := newTestOIDCAuthenticator(t, idp)
+
+	req := httptest.NewRequest(http.MethodGet, "http://syncthing.example.com/rest/noauth/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	a.loginHandler(rec, req)
+	cb := idp.authorize(t, rec.Result().Header.Get("Location"))
+
+	// Without the state cookie, i.e. in another browser, the callback is
+	// rejected.
+	rec = httptest.NewRecorder()
+	a.callbackHandler(rec, httptest.NewRequest(http.MethodGet, cb.String(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	// A state can only be used once.
+	req = httptest.NewRequest(http.MethodGet, cb.String(), nil)
+	req.AddCookie(&http.Cookie{Name: a.stateCookieName(), Value: cb.Query().Get("state")})
+	rec = httptest.NewRecorder()
+	a.callbackHandler(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestOIDCLoginAttemptEvents(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := newMockIdP(t, key, "ES256")
+	a := newTestOIDCAuthenticator(t, idp, "wheel")
+
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go evLogger.Serve(ctx)
+	sub := evLogger.Subscribe(events.LoginAttempt)
+	defer sub.Unsubscribe()
+	a.evLogger = evLogger
+
+	nextAttempt := func() (events.Event, map[string]any) {
+		t.Helper()
+		ev, err := sub.Poll(time.Second)
+		if err != nil {
+			t.Fatal("no login attempt event:", err)
+		}
+		return ev, ev.Data.(map[string]any)
+	}
+
+	// Failed logins, as alice isn't in the allowed group, lock her out.
+	limiter := newLoginLimiter()
+	for range loginFailuresBeforeLockout {
+		if resp := oidcLogin(t, a, idp); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("unexpected status %d", resp.StatusCode)
+		}
+		ev, data := nextAttempt()
+		if data["success"] != false || data["username"] != "alice" {
+			t.Fatal("unexpected event data", data)
+		}
+		limiter.handle(ev)
+	}
+	if limiter.lockedFor("alice", "") == 0 {
+		t.Fatal("expected lockout")
+	}
+
+	// A successful login is reported as well, which resets the lockout.
+	a.cfg.AllowedGroups = nil
+	if resp := oidcLogin(t, a, idp); resp.StatusCode != http.StatusFound {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	ev, data := nextAttempt()
+	if data["success"] != true || data["username"] != "alice" || data["role"] != config.GUIRoleAdmin.String() {
+		t.Fatal("unexpected event data", data)
+	}
+	limiter.handle(ev)
+	if d := limiter.lockedFor("alice", data["remoteAddress"].(string)); d != 0 {
+		t.Error("still locked out for", d)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+// apiUser is the authenticated user of a request.
+type apiUser struct {
+	Name string         `json:"name"`
+	Role config.GUIRole `json:"role"`
+
+	scopedKey *config.GUIAPIKey // set for requests with a scoped API key
+}
+
+// maxPausePatchSize is plenty for {"paused": false}.
+const maxPausePatchSize = 1 << 10
+
+type apiUserKey struct{}
+
+func withAPIUser(r *http.Request, user apiUser) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), apiUserKey{}, user))
+}
+
+// apiUserFromRequest returns the authenticated user of the request. There
+// is none when authentication is disabled, in which case everyone is an
+// admin, or for requests to paths that don't require authentication.
+func apiUserFromRequest(r *http.Request) (apiUser, bool) {
+	user, ok := r.Context().Value(apiUserKey{}).(apiUser)
+	return user, ok
+}
+
+// requestRole returns the role of the user making the request.
+func requestRole(r *http.Request) config.GUIRole {
+	if user, ok := apiUserFromRequest(r); ok {
+		return user.Role
+	}
+	return config.GUIRoleAdmin
+}
+
+// routeRoles lists the REST routes that need a role other than the
+// default, which is viewer for GET requests and admin for everything else.
+// Path patterns are as for the router, with :name matching a single path
+// element and * matching the rest of the path. The first match applies.
+var routeRoles = []struct {
+	method  string // or "*" for any method
+	pattern string
+	role    config.GUIRole
+}{
+	// Logging in and out is for everyone.
+	{"*", "/rest/noauth/*", config.GUIRoleViewer},
+
+	// Read only, but exposes things beyond the synced data or
+	// credentials.
+	{http.MethodGet, "/rest/debug/*", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/system/browse", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/system/log", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/system/log.txt", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/config/ldap", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/config/oidc", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/system/apikeys", config.GUIRoleAdmin},
+	{http.MethodGet, "/rest/config/history/*", config.GUIRoleAdmin},
+
+	// Changes nothing.
+	{http.MethodPost, "/rest/system/ping", config.GUIRoleViewer},
+
+	// Everyone manages their own second factor.
+	{http.MethodPost, "/rest/system/totp/*", config.GUIRoleViewer},
+
+	// Operating folders and devices.
+	{http.MethodPost, "/rest/db/prio", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/db/override", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/db/revert", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/db/scan", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/folder/versions", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/system/error/clear", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/system/pause", config.GUIRoleOperator},
+	{http.MethodPost, "/rest/system/resume", config.GUIRoleOperator},
+	{http.MethodPatch, "/rest/config/folders/:id", config.GUIRoleOperator}, // pausing only, see isPauseOnlyPatch
+	{http.MethodPatch, "/rest/config/devices/:id", config.GUIRoleOperator}, // pausing only, see isPauseOnlyPatch
+}
+
+// routeRole returns the role required for the given request method and
+// path.
+func routeRole(method, path string) config.GUIRole {
+	for _, rr := range routeRoles {
+		if (rr.method == "*" || rr.method == method) && matchRoutePattern(rr.pattern, path) {
+			return rr.role
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return config.GUIRoleViewer
+	}
+	return config.GUIRoleAdmin
+}
+
+func matchRoutePattern(pattern, path string) bool {
+	pelems := strings.Split(strings.Trim(pattern, "/"), "/")
+	elems := strings.Split(strings.Trim(path, "/"), "/")
+	for i, pe := range pelems {
+		if pe == "*" {
+			return true
+		}
+		if i >= len(elems) {
+			return false
+		}
+		if !strings.HasPrefix(pe, ":") && pe != elems[i] {
+			return false
+		}
+	}
+	return len(pelems) == len(elems)
+}
+
+// roleMiddleware rejects requests from users without the role required for
+// the route.
+func roleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := apiUserFromRequest(r)
+		if !ok {
+			// Authentication is disabled, or not required for the path.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		required := routeRole(r.Method, r.URL.Path)
+		if user.Role < required {
+			slog.Debug("Insufficient role for request", slog.String("username", user.Name), slog.String("role", user.Role.String()), slog.String("method", r.Method), slog.String("path", r.URL.Path))
+			forbidden(w)
+			return
+		}
+		if r.Method == http.MethodPatch && user.Role < config.GUIRoleAdmin && !isPauseOnlyPatch(r) {
+			forbidden(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isPauseOnlyPatch returns true if the request body is a JSON object
+// setting the paused state only. The body is preserved for the next
+// handler.
+func isPauseOnlyPatch(r *http.Request) bool {
+	bs, err := io.ReadAll(io.LimitReader(r.Body, maxPausePatchSize))
+	r.Body.Close()
+	if err != nil {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(bs))
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &fields); err != nil || len(fields) != 1 {
+		return false
+	}
+	var paused bool
+	for key, val := range fields {
+		if !strings.EqualFold(key, "paused") || json.Unmarshal(val, &paused) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// redactedConfig returns the configuration as the user making the request
+// may see it; without credentials, unless they are an admin.
+func redactedConfig(r *http.Request, cfg config.Configuration) config.Configuration {
+	if requestRole(r) >= config.GUIRoleAdmin {
+		return cfg
+	}
+	return cfg.Redacted()
+}
+
+// redactedEvents returns the events as the user making the request may see
+// them; with the configuration in ConfigSaved events redacted, unless they
+// are an admin. The events are modified in place.
+func redactedEvents(r *http.Request, evs []events.Event) []events.Event {
+	if requestRole(r) >= config.GUIRoleAdmin {
+		return evs
+	}
+	for i := range evs {
+		if cfg, ok := evs[i].Data.(config.Configuration); ok {
+			evs[i].Data = cfg.Redacted()
+		}
+	}
+	return evs
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	eventmocks "github.com/syncthing/syncthing/lib/events/mocks"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestRouteRole(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method string
+		path   string
+		role   config.GUIRole
+	}{
+		{http.MethodGet, "/rest/system/status", config.GUIRoleViewer},
+		{http.MethodGet, "/rest/config", config.GUIRoleViewer},
+		{http.MethodGet, "/rest/system/log", config.GUIRoleAdmin},
+		{http.MethodGet, "/rest/debug/cpuprof", config.GUIRoleAdmin},
+		{http.MethodPost, "/rest/noauth/auth/password", config.GUIRoleViewer},
+		{http.MethodPost, "/rest/system/ping", config.GUIRoleViewer},
+		{http.MethodPost, "/rest/db/scan", config.GUIRoleOperator},
+		{http.MethodPost, "/rest/system/pause", config.GUIRoleOperator},
+		{http.MethodPatch, "/rest/config/folders/abcd-1234", config.GUIRoleOperator},
+		{http.MethodPatch, "/rest/config/folders", config.GUIRoleAdmin},
+		{http.MethodPut, "/rest/config/folders/abcd-1234", config.GUIRoleAdmin},
+		{http.MethodPut, "/rest/config", config.GUIRoleAdmin},
+		{http.MethodPost, "/rest/system/restart", config.GUIRoleAdmin},
+	}
+
+	for _, tc := range cases {
+		if role := routeRole(tc.method, tc.path); role != tc.role {
+			t.Errorf("%s %s: got role %v, expected %v", tc.method, tc.path, role, tc.role)
+		}
+	}
+}
+
+func TestIsPauseOnlyPatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		body string
+		ok   bool
+	}{
+		{`{"paused": true}`, true},
+		{`{"Paused": false}`, true},
+		{`{"paused": "yes"}`, false},
+		{`{"paused": true, "path": "/"}`, false},
+		{`{"label": "x"}`, false},
+		{`{}`, false},
+		{`not json`, false},
+	}
+
+	for _, tc := range cases {
+		r, err := http.NewRequest(http.MethodPatch, "/rest/config/folders/default", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok := isPauseOnlyPatch(r); ok != tc.ok {
+			t.Errorf("%s: got %v, expected %v", tc.body, ok, tc.ok)
+		}
+	}
+}
+
+func TestUserRoles(t *testing.T) {
+	t.Parallel()
+
+	const password = "räksmörgås"
+	hash := "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq" // bcrypt of "räksmörgås" in UTF-8
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{
+		RawAddress: "127.0.0.1:0",
+		APIKey:     testAPIKey,
+		Users: []config.GUIUser{
+			{Name: "viewer", Password: hash, Role: config.GUIRoleViewer},
+			{Name: "operator", Password: hash, Role: config.GUIRoleOperator},
+			{Name: "admin", Password: hash, Role: config.GUIRoleAdmin},
+		},
+	})
+	baseURL := startHTTP(t, cfg)
+
+	// Fetching the GUI as any user hands out a CSRF token.
+	resp := httpGet(baseURL, "viewer", password, "", "", nil, t)
+	resp.Body.Close()
+	var csrfTokenName, csrfTokenValue string
+	for _, cookie := range resp.Cookies() {
+		if strings.HasPrefix(cookie.Name, "CSRF-Token") {
+			csrfTokenName, csrfTokenValue = cookie.Name, cookie.Value
+		}
+	}
+	if csrfTokenValue == "" {
+		t.Fatal("No CSRF cookie returned")
+	}
+
+	cases := []struct {
+		user   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"viewer", http.MethodGet, "/rest/system/status", nil, http.StatusOK},
+		{"viewer", http.MethodPost, "/rest/db/scan?folder=default", nil, http.StatusForbidden},
+		{"viewer", http.MethodGet, "/rest/system/log", nil, http.StatusForbidden},
+		{"operator", http.MethodPost, "/rest/system/pause", nil, http.StatusOK},
+		{"operator", http.MethodPut, "/rest/config/options", map[string]any{}, http.StatusForbidden},
+		{"operator", http.MethodPatch, "/rest/config/devices/" + testAPIKey, map[string]any{"name": "x"}, http.StatusForbidden},
+		{"admin", http.MethodGet, "/rest/system/log", nil, http.StatusOK},
+		{"nobody", http.MethodGet, "/rest/system/status", nil, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		resp := httpRequest(tc.method, baseURL+tc.path, tc.body, tc.user, password, "", "", csrfTokenName, csrfTokenValue, nil, t)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s as %s: got status %d, expected %d", tc.method, tc.path, tc.user, resp.StatusCode, tc.status)
+		}
+	}
+
+	t.Run("viewer sees no credentials", func(t *testing.T) {
+		resp := httpRequest(http.MethodGet, baseURL+"/rest/config/gui", nil, "viewer", password, "", "", csrfTokenName, csrfTokenValue, nil, t)
+		defer resp.Body.Close()
+		var gui config.GUIConfiguration
+		if err := unmarshalTo(resp.Body, &gui); err != nil {
+			t.Fatal(err)
+		}
+		if gui.APIKey != "" {
+			t.Error("API key exposed to viewer")
+		}
+		for _, u := range gui.Users {
+			if u.Password != "" {
+				t.Errorf("Password of %s exposed to viewer", u.Name)
+			}
+		}
+	})
+}
+
+func TestEventsRedacted(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Configuration{
+		GUI: config.GUIConfiguration{
+			APIKey:     "secret-apikey",
+			Password:   "secret-hash",
+			TOTPSecret: "secret-totp",
+			APIKeys:    []config.GUIAPIKey{{Name: "k", Key: "secret-scopedkey", Scopes: []config.APIKeyScope{config.APIKeyScopeEvents}}},
+		},
+		OIDC: config.OIDCConfiguration{ClientSecret: "secret-oidc"},
+		Folders: []config.FolderConfiguration{{
+			ID:      "f",
+			Devices: []config.FolderDeviceConfiguration{{DeviceID: protocol.LocalDeviceID, EncryptionPassword: "secret-folder"}},
+		}},
+		ShareRules: []config.ShareRule{{Name: "r", EncryptionPassword: "secret-rule"}},
+	}
+
+	cases := []struct {
+		name    string
+		user    *apiUser
+		secrets bool
+	}{
+		{"no auth", nil, true},
+		{"admin", &apiUser{Name: "admin", Role: config.GUIRoleAdmin}, true},
+		{"viewer", &apiUser{Name: "viewer", Role: config.GUIRoleViewer}, false},
+		{"scoped key", &apiUser{Name: "k", Role: config.GUIRoleViewer, scopedKey: &cfg.GUI.APIKeys[0]}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := new(eventmocks.BufferedSubscription)
+			sub.SinceReturns([]events.Event{{SubscriptionID: 1, Type: events.ConfigSaved, Data: cfg}})
+
+			req := httptest.NewRequest(http.MethodGet, "/rest/events", nil)
+			if tc.user != nil {
+				req = withAPIUser(req, *tc.user)
+			}
+			rec := httptest.NewRecorder()
+			new(service).getEvents(rec, req, sub)
+
+			body := rec.Body.String()
+			if !strings.Contains(body, "ConfigSaved") {
+				t.Fatal("event missing from response")
+			}
+			if got := strings.Contains(body, "secret-"); got != tc.secrets {
+				t.Errorf("credentials in event stream: %v, expected %v", got, tc.secrets)
+			}
+		})
+	}
+
+	if cfg.GUI.APIKey == "" || cfg.Folders[0].Devices[0].EncryptionPassword == "" || cfg.ShareRules[0].EncryptionPassword == "" {
+		t.Error("original configuration was modified")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -430,6 +431,12 @@ func TestAPIServiceRequests(t *testing.T) {
 			Type:   "application/json",
 			Prefix: "{",
 		},
+		{
+			URL:    "/rest/config/oidc",
+			Code:   200,
+			Type:   "application/json",
+			Prefix: "{",
+		},
 	}
 
 	for _, tc := range cases {
@@ -980,6 +987,44 @@ func TestHtmlFormLogin(t *testing.T) {
 	})
 }
 
+func TestAuthMethods(t *testing.T) {
+	t.Parallel()
+
+	const hash = "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq" // bcrypt of "räksmörgås" in UTF-8
+
+	cases := []struct {
+		name    string
+		gui     config.GUIConfiguration
+		methods map[string]bool
+	}{
+		{"single user", config.GUIConfiguration{User: "üser", Password: hash}, map[string]bool{"password": true, "oidc": false}},
+		{"users only", config.GUIConfiguration{Users: []config.GUIUser{{Name: "bob", Password: hash, Role: config.GUIRoleViewer}}}, map[string]bool{"password": true, "oidc": false}},
+		{"ldap", config.GUIConfiguration{AuthMode: config.AuthModeLDAP}, map[string]bool{"password": true, "oidc": false}},
+		{"oidc", config.GUIConfiguration{AuthMode: config.AuthModeOIDC}, map[string]bool{"password": false, "oidc": true}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.gui.RawAddress = "127.0.0.1:0"
+			cfg := newMockedConfig()
+			cfg.GUIReturns(tc.gui)
+			baseURL := startHTTP(t, cfg)
+
+			resp := httpGet(baseURL+"/rest/noauth/auth/methods", "", "", "", "", nil, t)
+			defer resp.Body.Close()
+			var methods map[string]bool
+			if err := json.NewDecoder(resp.Body).Decode(&methods); err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(methods, tc.methods) {
+				t.Errorf("Got methods %v, expected %v", methods, tc.methods)
+			}
+		})
+	}
+}
+
 func TestApiCache(t *testing.T) {
 	t.Parallel()
 
@@ -1809,6 +1854,72 @@ func TestConfigChanges(t *testing.T) {
 	}
 }
 
+func TestConfigApply(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "syncthing-testConfig-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	cfg := config.New(protocol.LocalDeviceID)
+	cfg.GUI.RawAddress = "127.0.0.1:0"
+	cfg.GUI.APIKey = testAPIKey
+	w := config.Wrap(tmpFile.Name(), cfg, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Serve(ctx)
+	baseURL := startHTTP(t, w)
+
+	cli := &http.Client{Timeout: 15 * time.Second}
+	apply := func(query, doc string, status int) config.ApplyPlan {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, baseURL+"/rest/config/apply"+query, strings.NewReader(doc))
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("Expected status %v, got %v", status, resp.StatusCode)
+		}
+		var plan config.ApplyPlan
+		if status == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&plan); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return plan
+	}
+
+	doc := "devices:\n- deviceID: " + dev1.String() + "\n  name: one\noptions:\n  maxSendKbps: 100\n"
+
+	plan := apply("?dryRun=true", doc, http.StatusOK)
+	if len(plan.AddedDevices) != 1 || plan.AddedDevices[0] != dev1 || !plan.ChangedOptions {
+		t.Errorf("Unexpected plan %+v", plan)
+	}
+	if _, ok := w.Device(dev1); ok {
+		t.Error("Dry run added the device")
+	}
+
+	apply("", doc, http.StatusOK)
+	if d, ok := w.Device(dev1); !ok || d.Name != "one" {
+		t.Errorf("Device not added: %+v", d)
+	}
+	if _, ok := w.Device(protocol.LocalDeviceID); !ok {
+		t.Error("Own device removed")
+	}
+	if w.Options().MaxSendKbps != 100 {
+		t.Error("Options not applied")
+	}
+
+	if plan := apply("", doc, http.StatusOK); !plan.Empty() {
+		t.Errorf("Applying again planned %+v", plan.Changes)
+	}
+	apply("", `{"options": {"maxSendKbps": "fast"}}`, http.StatusBadRequest)
+}
+
 func TestSanitizedHostname(t *testing.T) {
 	cases := []struct {
 		in, out string
@@ -1850,3 +1961,322 @@ func runningInContainer() bool {
 	}
 	return false
 }
+
+func TestConfigLocked(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{RawAddress: "127.0.0.1:0", APIKey: testAPIKey})
+	baseURL := startHTTP(t, cfg)
+
+	get := func() []string {
+		t.Helper()
+		resp := httpGet(baseURL+"/rest/config/locked", "", "", testAPIKey, "", nil, t)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var locked []string
+		if err := json.NewDecoder(resp.Body).Decode(&locked); err != nil {
+			t.Fatal(err)
+		}
+		return locked
+	}
+
+	if locked := get(); locked == nil || len(locked) != 0 {
+		t.Errorf("Expected an empty list, got %v", locked)
+	}
+	cfg.LockedPathsReturns([]string{"options.maxSendKbps"})
+	if locked := get(); !slices.Equal(locked, []string{"options.maxSendKbps"}) {
+		t.Errorf("Unexpected locked paths %v", locked)
+	}
+}
+
+func TestConfigSchemaAndValidate(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "syncthing-testConfig-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	cfg := config.New(protocol.LocalDeviceID)
+	cfg.GUI.RawAddress = "127.0.0.1:0"
+	cfg.GUI.APIKey = testAPIKey
+	w := config.Wrap(tmpFile.Name(), cfg, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Serve(ctx)
+	baseURL := startHTTP(t, w)
+
+	resp := httpGet(baseURL+"/rest/config/schema", "", "", testAPIKey, "", nil, t)
+	var schema struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&schema)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.Defs["FolderConfiguration"]; !ok {
+		t.Error("Schema lacks the folder configuration")
+	}
+
+	cli := &http.Client{Timeout: 15 * time.Second}
+	type result struct {
+		Valid bool
+		Error string
+	}
+	validate := func(body string, status int) result {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, baseURL+"/rest/config/validate", strings.NewReader(body))
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("Expected status %v, got %v", status, resp.StatusCode)
+		}
+		var res result
+		if status == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return res
+	}
+
+	changed := w.RawCopy()
+	changed.Options.MaxSendKbps = 100
+	bs, _ := json.Marshal(changed)
+	if res := validate(string(bs), http.StatusOK); !res.Valid || res.Error != "" {
+		t.Errorf("Valid configuration rejected: %+v", res)
+	}
+	if w.Options().MaxSendKbps != 0 {
+		t.Error("Validation changed the configuration")
+	}
+
+	changed.GUI.APIKey = "env:SYNCTHING_TEST_MISSING"
+	bs, _ = json.Marshal(changed)
+	if res := validate(string(bs), http.StatusOK); res.Valid || !strings.Contains(res.Error, "gui.apiKey") {
+		t.Errorf("Invalid configuration accepted: %+v", res)
+	}
+
+	validate("{", http.StatusBadRequest)
+}
+
+func TestConfigShareRules(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "syncthing-testConfig-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	cfg := config.New(protocol.LocalDeviceID)
+	cfg.GUI.RawAddress = "127.0.0.1:0"
+	cfg.GUI.APIKey = testAPIKey
+	cfg.GUI.APIKeys = []config.GUIAPIKey{{Name: "reader", Key: "readkey", Scopes: []config.APIKeyScope{config.APIKeyScopeRead}}}
+	cfg.SetDevice(config.DeviceConfiguration{DeviceID: dev1, Group: "workstations"})
+	cfg.SetFolder(config.FolderConfiguration{ID: "a", Path: "/a", Group: "projects"})
+	w := config.Wrap(tmpFile.Name(), cfg, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Serve(ctx)
+	baseURL := startHTTP(t, w)
+
+	cli := &http.Client{Timeout: 15 * time.Second}
+	put := func(body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, baseURL+"/rest/config/sharerules", strings.NewReader(body))
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := put(`[{"name": "work", "deviceGroup": "workstations", "folderGroup": "projects", "encryptionPassword": "secret"}]`); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if f, _ := w.Folder("a"); !slices.Contains(f.DeviceIDs(), dev1) {
+		t.Errorf("Folder not shared by the rule: %v", f.DeviceIDs())
+	}
+
+	getRules := func(apiKey string) []config.ShareRule {
+		t.Helper()
+		resp := httpGet(baseURL+"/rest/config/sharerules", "", "", apiKey, "", nil, t)
+		defer resp.Body.Close()
+		var rules []config.ShareRule
+		if err := json.NewDecoder(resp.Body).Decode(&rules); err != nil {
+			t.Fatal(err)
+		}
+		return rules
+	}
+
+	rules := getRules(testAPIKey)
+	if len(rules) != 1 || rules[0].Name != "work" || rules[0].EncryptionPassword != "secret" {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+	rules = getRules("readkey")
+	if len(rules) != 1 || rules[0].Name != "work" || rules[0].EncryptionPassword != "" {
+		t.Errorf("Unexpected rules for viewer %+v", rules)
+	}
+
+	if status := put(`[{"name": "broken", "deviceGroup": "workstations"}]`); status == http.StatusOK {
+		t.Error("Rule without folder group accepted")
+	}
+}
+
+func TestConfigAutoAcceptRules(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{RawAddress: "127.0.0.1:0", APIKey: testAPIKey})
+	cfg.AutoAcceptRulesReturns([]config.AutoAcceptRule{{Name: "projects", FolderID: "proj-.*"}})
+	baseURL := startHTTP(t, cfg)
+
+	resp := httpGet(baseURL+"/rest/config/autoacceptrules", "", "", testAPIKey, "", nil, t)
+	var rules []config.AutoAcceptRule
+	err := json.NewDecoder(resp.Body).Decode(&rules)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Name != "projects" || rules[0].FolderID != "proj-.*" {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, baseURL+"/rest/config/autoacceptrules", strings.NewReader(`[{"name": "all"}]`))
+	req.Header.Set("X-API-Key", testAPIKey)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if cfg.ModifyAsCallCount() != 1 {
+		t.Fatal("Configuration not modified")
+	}
+	var modified config.Configuration
+	_, fn := cfg.ModifyAsArgsForCall(0)
+	fn(&modified)
+	if len(modified.AutoAcceptRules) != 1 || modified.AutoAcceptRules[0].Name != "all" {
+		t.Errorf("Unexpected rules set %+v", modified.AutoAcceptRules)
+	}
+}
+
+func TestDBSearchParams(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{RawAddress: "127.0.0.1:0", APIKey: testAPIKey})
+	baseURL := startHTTP(t, cfg)
+
+	for _, query := range []string{"", "q=+", "q=report&limit=many"} {
+		req, _ := http.NewRequest(http.MethodGet, baseURL+"/rest/db/search?"+query, nil)
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestDBBackupParams(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{RawAddress: "127.0.0.1:0", APIKey: testAPIKey})
+	baseURL := startHTTP(t, cfg)
+
+	for _, query := range []string{"", "path=relative/dir"} {
+		req, _ := http.NewRequest(http.MethodPost, baseURL+"/rest/db/backup?"+query, nil)
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestFolderJournalParams(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{RawAddress: "127.0.0.1:0", APIKey: testAPIKey})
+	baseURL := startHTTP(t, cfg)
+
+	cases := []struct {
+		query  string
+		exists bool
+		status int
+	}{
+		{"folder=missing", false, http.StatusNotFound},
+		{"folder=default&since=yesterday", true, http.StatusBadRequest},
+		{"folder=default&until=2026-01-02", true, http.StatusBadRequest},
+		{"folder=default&device=nope", true, http.StatusBadRequest},
+		{"folder=default&limit=many", true, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		cfg.FolderReturns(config.FolderConfiguration{ID: "default"}, tc.exists)
+		req, _ := http.NewRequest(http.MethodGet, baseURL+"/rest/folder/journal?"+tc.query, nil)
+		req.Header.Set("X-API-Key", testAPIKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.query, tc.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestSupportBundleRedactedConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	cfg.CopyWithSecretReferencesReturns(config.Configuration{
+		GUI: config.GUIConfiguration{
+			User:     "üser",
+			Password: "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq",
+			APIKey:   "env:SYNCTHING_APIKEY",
+			Users:    []config.GUIUser{{Name: "bob", Password: "file:/run/secrets/bob"}},
+		},
+		OIDC: config.OIDCConfiguration{ClientSecret: "secret"},
+		Folders: []config.FolderConfiguration{{
+			ID:      "a",
+			Devices: []config.FolderDeviceConfiguration{{DeviceID: dev1, EncryptionPassword: "password"}},
+		}},
+		ShareRules: []config.ShareRule{{Name: "r", EncryptionPassword: "password"}},
+	})
+
+	redacted := getRedactedConfig(&service{cfg: cfg})
+	if redacted.GUI.User != "REDACTED" || redacted.GUI.Password != "REDACTED" || redacted.OIDC.ClientSecret != "REDACTED" {
+		t.Errorf("Secrets not redacted: %+v, %+v", redacted.GUI, redacted.OIDC)
+	}
+	if pw := redacted.Folders[0].Devices[0].EncryptionPassword; pw != "REDACTED" {
+		t.Errorf("Encryption password not redacted: %q", pw)
+	}
+	if pw := redacted.ShareRules[0].EncryptionPassword; pw != "REDACTED" {
+		t.Errorf("Share rule encryption password not redacted: %q", pw)
+	}
+	if redacted.GUI.APIKey != "env:SYNCTHING_APIKEY" || redacted.GUI.Users[0].Password != "file:/run/secrets/bob" {
+		t.Errorf("References not shown: %+v", redacted.GUI)
+	}
+}
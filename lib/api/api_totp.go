@@ -0,0 +1,402 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // as per RFC 6238, and what authenticator apps support
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	totpPeriod          = 30 // seconds
+	totpDigits          = 6
+	totpSkew            = 1 // time steps accepted before and after the current one
+	totpSecretBytes     = 20
+	totpIssuer          = "Syncthing"
+	pendingTOTPLifetime = 10 * time.Minute
+	numRecoveryCodes    = 10
+	recoveryCodeLength  = 10
+	maxTOTPRequestSize  = 1 << 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newTOTPSecret() string {
+	bs := make([]byte, totpSecretBytes)
+	_, _ = rand.Read(bs) // can't fail
+	return totpEncoding.EncodeToString(bs)
+}
+
+// totpCode returns the code for the given time step, as per RFC 6238.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the time step the code is valid for, if any.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI returns the key URI used by authenticator apps, typically
+// scanned as a QR code.
+func totpURI(username, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + totpIssuer + ":" + username,
+		RawQuery: params.Encode(),
+	}
+	return u.String()
+}
+
+// newRecoveryCodes returns a set of recovery codes, and their hashes to be
+// stored.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for range numRecoveryCodes {
+		code := strings.ToLower(rand.String(recoveryCodeLength))
+		code = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hash, err := hashRecoveryCode(code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(normalizeRecoveryCode(code)), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+type pendingTOTP struct {
+	secret  string
+	expires time.Time
+}
+
+// totpAuthenticator checks the second factor of users who have enrolled in
+// two factor authentication, and handles their enrolment. Enrolment is
+// looked up in the current configuration, so that it takes effect without
+// restarting the GUI.
+type totpAuthenticator struct {
+	cfg     config.Wrapper
+	timeNow func() time.Time // can be overridden for testing
+
+	mut       sync.Mutex
+	lastSteps map[string]int64       // username -> last accepted time step, against replay
+	pending   map[string]pendingTOTP // username -> secret being enrolled
+}
+
+func newTOTPAuthenticator(cfg config.Wrapper) *totpAuthenticator {
+	return &totpAuthenticator{
+		cfg:       cfg,
+		timeNow:   time.Now,
+		lastSteps: make(map[string]int64),
+		pending:   make(map[string]pendingTOTP),
+	}
+}
+
+// enrolled returns true if the named user has a second factor.
+func (a *totpAuthenticator) enrolled(username string) bool {
+	secret, _, _ := a.cfg.GUI().UserTOTP(username)
+	return secret != ""
+}
+
+// verify returns true if the code is a current TOTP code, or an unused
+// recovery code, of the named user. Recovery codes can be used only once.
+func (a *totpAuthenticator) verify(username, code string) bool {
+	secret, hashes, _ := a.cfg.GUI().UserTOTP(username)
+	if secret == "" {
+		return false
+	}
+	code = strings.TrimSpace(code)
+
+	if len(code) == totpDigits {
+		step, ok := matchTOTP(secret, code, a.timeNow())
+		if !ok {
+			return false
+		}
+		a.mut.Lock()
+		defer a.mut.Unlock()
+		if step <= a.lastSteps[username] {
+			// Each code works once, or it could be replayed by someone
+			// watching.
+			return false
+		}
+		a.lastSteps[username] = step
+		return true
+	}
+
+	code = normalizeRecoveryCode(code)
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil {
+			a.removeRecoveryCode(username, hash)
+			slog.Warn("Two-factor recovery code used", slog.String("username", username), slog.Int("remaining", len(hashes)-1))
+			return true
+		}
+	}
+	return false
+}
+
+func (a *totpAuthenticator) removeRecoveryCode(username, hash string) {
+	a.setUserTOTP(config.Author{Kind: config.AuthorKindUser, Name: username}, username, func(secret string, hashes []string) (string, []string) {
+		return secret, slices.DeleteFunc(slices.Clone(hashes), func(h string) bool { return h == hash })
+	})
+}
+
+// setUserTOTP updates the TOTP secret and recovery codes of the user in the
+// configuration, and saves it.
+func (a *totpAuthenticator) setUserTOTP(author config.Author, username string, fn func(secret string, hashes []string) (string, []string)) bool {
+	found := false
+	waiter, err := a.cfg.ModifyAs(author, func(cfg *config.Configuration) {
+		secret, hashes, ok := cfg.GUI.UserTOTP(username)
+		if !ok {
+			return
+		}
+		secret, hashes = fn(secret, hashes)
+		found = cfg.GUI.SetUserTOTP(username, secret, hashes)
+	})
+	if err != nil {
+		slog.Error("Failed to update two-factor authentication", slog.String("username", username), slogutil.Error(err))
+		return false
+	}
+	waiter.Wait()
+	if err := a.cfg.Save(); err != nil {
+		slog.Error("Failed to save config", slogutil.Error(err))
+	}
+	return found
+}
+
+// begin starts enrolment of the user, returning the new secret. It takes
+// effect when confirmed with a code.
+func (a *totpAuthenticator) begin(username string) string {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	now := a.timeNow()
+	for name, p := range a.pending {
+		if now.After(p.expires) {
+			delete(a.pending, name)
+		}
+	}
+	secret := newTOTPSecret()
+	a.pending[username] = pendingTOTP{secret: secret, expires: now.Add(pendingTOTPLifetime)}
+	return secret
+}
+
+// confirm completes enrolment of the user if the code matches the pending
+// secret, returning the recovery codes.
+func (a *totpAuthenticator) confirm(username, code string) ([]string, error) {
+	a.mut.Lock()
+	p, ok := a.pending[username]
+	var step int64
+	if ok && a.timeNow().Before(p.expires) {
+		step, ok = matchTOTP(p.secret, strings.TrimSpace(code), a.timeNow())
+	} else {
+		ok = false
+	}
+	if ok {
+		delete(a.pending, username)
+		a.lastSteps[username] = step
+	}
+	a.mut.Unlock()
+	if !ok {
+		return nil, errTOTPCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	author := config.Author{Kind: config.AuthorKindUser, Name: username}
+	if !a.setUserTOTP(author, username, func(string, []string) (string, []string) { return p.secret, hashes }) {
+		return nil, errTOTPUser
+	}
+	return codes, nil
+}
+
+var (
+	errTOTPCode = errors.New("incorrect or expired code")
+	errTOTPUser = errors.New("two-factor authentication is available for users in the GUI configuration only")
+)
+
+// totpUser returns the name of the user making the request, who may manage
+// their own two factor authentication.
+func totpUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := apiUserFromRequest(r)
+	if !ok || user.Name == "" || user.scopedKey != nil {
+		http.Error(w, "Two-factor authentication requires being logged in as a GUI user", http.StatusBadRequest)
+		return "", false
+	}
+	return user.Name, true
+}
+
+func readTOTPCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := unmarshalTo(http.MaxBytesReader(w, r.Body, maxTOTPRequestSize), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return req.Code, true
+}
+
+func (s *service) getTOTP(w http.ResponseWriter, r *http.Request) {
+	username, ok := totpUser(w, r)
+	if !ok {
+		return
+	}
+	secret, hashes, _ := s.cfg.GUI().UserTOTP(username)
+	sendJSON(w, map[string]any{
+		"enabled":           secret != "",
+		"recoveryCodesLeft": len(hashes),
+	})
+}
+
+// postTOTPEnroll starts enrolment, returning the secret and the URI to
+// show as a QR code, using /qr/.
+func (s *service) postTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	username, ok := totpUser(w, r)
+	if !ok {
+		return
+	}
+	if _, _, ok := s.cfg.GUI().UserTOTP(username); !ok {
+		http.Error(w, errTOTPUser.Error(), http.StatusBadRequest)
+		return
+	}
+	secret := s.totp.begin(username)
+	sendJSON(w, map[string]string{
+		"secret": secret,
+		"uri":    totpURI(username, secret),
+	})
+}
+
+// postTOTPConfirm completes enrolment with a code from the authenticator,
+// returning the recovery codes. They are not shown again.
+func (s *service) postTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	username, ok := totpUser(w, r)
+	if !ok {
+		return
+	}
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+	codes, err := s.totp.confirm(username, code)
+	switch {
+	case errors.Is(err, errTOTPCode):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, errTOTPUser):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	emitConfigChanged(r, s.evLogger)
+	sendJSON(w, map[string][]string{"recoveryCodes": codes})
+}
+
+// postTOTPRecoveryCodes replaces the recovery codes of a user who gives a
+// current code.
+func (s *service) postTOTPRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	username, ok := totpUser(w, r)
+	if !ok {
+		return
+	}
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+	if !s.totp.verify(username, code) {
+		http.Error(w, errTOTPCode.Error(), http.StatusForbidden)
+		return
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.totp.setUserTOTP(requestAuthor(r), username, func(secret string, _ []string) (string, []string) { return secret, hashes })
+	emitConfigChanged(r, s.evLogger)
+	sendJSON(w, map[string][]string{"recoveryCodes": codes})
+}
+
+// postTOTPDisable removes the second factor of a user who gives a current
+// code.
+func (s *service) postTOTPDisable(w http.ResponseWriter, r *http.Request) {
+	username, ok := totpUser(w, r)
+	if !ok {
+		return
+	}
+	code, ok := readTOTPCode(w, r)
+	if !ok {
+		return
+	}
+	if !s.totp.verify(username, code) {
+		http.Error(w, errTOTPCode.Error(), http.StatusForbidden)
+		return
+	}
+	s.totp.setUserTOTP(requestAuthor(r), username, func(string, []string) (string, []string) { return "", nil })
+	emitConfigChanged(r, s.evLogger)
+}
+
+// deleteTOTP lets admins remove the second factor of any user, such as one
+// who lost both their authenticator and recovery codes.
+func (s *service) deleteTOTP(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("user")
+	if !s.totp.setUserTOTP(requestAuthor(r), username, func(string, []string) (string, []string) { return "", nil }) {
+		http.Error(w, "No user with given name", http.StatusNotFound)
+		return
+	}
+	emitConfigChanged(r, s.evLogger)
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestTOTPCode(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors from RFC 6238, truncated to six digits.
+	key := []byte("12345678901234567890")
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		if code := totpCode(key, tc.unix/totpPeriod); code != tc.code {
+			t.Errorf("At %d: got %s, expected %s", tc.unix, code, tc.code)
+		}
+	}
+
+	secret := totpEncoding.EncodeToString(key)
+	now := time.Unix(1234567890, 0)
+	if _, ok := matchTOTP(secret, "005924", now); !ok {
+		t.Error("Current code not accepted")
+	}
+	if _, ok := matchTOTP(secret, "005924", now.Add(totpPeriod*time.Second)); !ok {
+		t.Error("Previous code not accepted")
+	}
+	if _, ok := matchTOTP(secret, "005924", now.Add(2*totpPeriod*time.Second)); ok {
+		t.Error("Old code accepted")
+	}
+	if _, ok := matchTOTP(secret, "123456", now); ok {
+		t.Error("Wrong code accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse(totpURI("üser", "ABCDEFGH"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Syncthing:üser" {
+		t.Errorf("Unexpected URI %s", u)
+	}
+	if u.Query().Get("secret") != "ABCDEFGH" || u.Query().Get("issuer") != "Syncthing" {
+		t.Errorf("Unexpected parameters in %s", u)
+	}
+}
+
+func TestTOTPAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := os.CreateTemp(t.TempDir(), "syncthing-testConfig-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	cfg := config.Wrap(tmpFile.Name(), config.Configuration{
+		GUI: config.GUIConfiguration{
+			User:  "üser",
+			Users: []config.GUIUser{{Name: "bob", Role: config.GUIRoleViewer}},
+		},
+	}, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.Serve(ctx)
+
+	a := newTOTPAuthenticator(cfg)
+	now := time.Unix(1234567890, 0)
+	a.timeNow = func() time.Time { return now }
+
+	secret := a.begin("bob")
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.confirm("bob", "abcdef"); err != errTOTPCode {
+		t.Error("Enrolment confirmed with wrong code")
+	}
+	codes, err := a.confirm("bob", totpCode(key, now.Unix()/totpPeriod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != numRecoveryCodes {
+		t.Errorf("Got %d recovery codes, expected %d", len(codes), numRecoveryCodes)
+	}
+	if _, err := a.confirm("bob", totpCode(key, now.Unix()/totpPeriod)); err != errTOTPCode {
+		t.Error("Enrolment confirmed twice")
+	}
+	if _, err := a.confirm("nobody", ""); err != errTOTPCode {
+		t.Error("Enrolment confirmed without starting it")
+	}
+
+	if !a.enrolled("bob") || a.enrolled("üser") {
+		t.Error("Unexpected enrolment")
+	}
+
+	// The code used to confirm enrolment is spent.
+	if a.verify("bob", totpCode(key, now.Unix()/totpPeriod)) {
+		t.Error("Code used for enrolment accepted again")
+	}
+	now = now.Add(totpPeriod * time.Second)
+	next := totpCode(key, now.Unix()/totpPeriod)
+	if !a.verify("bob", next) {
+		t.Error("Next code not accepted")
+	}
+	if a.verify("bob", next) {
+		t.Error("Code replayed")
+	}
+	if !a.verify("bob", strings.ToUpper(codes[0])) {
+		t.Error("Recovery code not accepted")
+	}
+	if a.verify("bob", codes[0]) {
+		t.Error("Recovery code used twice")
+	}
+	if _, hashes, _ := cfg.GUI().UserTOTP("bob"); len(hashes) != numRecoveryCodes-1 {
+		t.Errorf("Got %d recovery codes left, expected %d", len(hashes), numRecoveryCodes-1)
+	}
+	if a.verify("bob", "abcde-fghij") {
+		t.Error("Wrong recovery code accepted")
+	}
+	if a.verify("üser", next) {
+		t.Error("Code accepted for user without TOTP")
+	}
+}
+
+func TestTOTPLogin(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("12345678901234567890")
+	cfg := newMockedConfig()
+	cfg.GUIReturns(config.GUIConfiguration{
+		User:       "üser",
+		Password:   "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq", // bcrypt of "räksmörgås" in UTF-8
+		RawAddress: "127.0.0.1:0",
+		TOTPSecret: totpEncoding.EncodeToString(key),
+	})
+	baseURL := startHTTP(t, cfg)
+	loginURL := baseURL + "/rest/noauth/auth/password"
+
+	login := func(password, code string) *http.Response {
+		t.Helper()
+		return httpPost(loginURL, map[string]string{"username": "üser", "password": password, "totpCode": code}, nil, t)
+	}
+
+	resp := login("räksmörgås", "")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Password alone: got %d", resp.StatusCode)
+	}
+	var body map[string]bool
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || !body["totpRequired"] {
+		t.Errorf("Password alone: expected totpRequired, got %v, %v", body, err)
+	}
+	if hasSessionCookie(resp.Cookies()) {
+		t.Error("Unexpected session cookie for password alone")
+	}
+
+	code := totpCode(key, time.Now().Unix()/totpPeriod)
+	if resp := login("wrong", code); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Wrong password: got %d", resp.StatusCode)
+	}
+	resp = login("räksmörgås", code)
+	if resp.StatusCode != http.StatusNoContent || !hasSessionCookie(resp.Cookies()) {
+		t.Errorf("Password and code: got %d", resp.StatusCode)
+	}
+	if resp := login("räksmörgås", code); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Replayed code: got %d", resp.StatusCode)
+	}
+
+	// Basic auth can't carry the second factor.
+	if resp := httpGet(baseURL+"/meta.js", "üser", "räksmörgås", "", "", nil, t); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Basic auth: got %d", resp.StatusCode)
+	}
+}
+
+func TestLoginLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := newLoginLimiter()
+	now := time.Unix(1234567890, 0)
+	l.timeNow = func() time.Time { return now }
+
+	attempt := func(success bool, username, address string) {
+		l.handle(events.Event{
+			Time: now,
+			Type: events.LoginAttempt,
+			Data: map[string]any{"success": success, "username": username, "remoteAddress": address},
+		})
+	}
+
+	for i := range loginFailuresBeforeLockout {
+		if d := l.lockedFor("alice", "192.0.2.1"); d != 0 {
+			t.Fatalf("Locked out for %v after %d failures", d, i)
+		}
+		attempt(false, "alice", "192.0.2.1")
+	}
+	if d := l.lockedFor("alice", "198.51.100.1"); d != loginLockoutBase {
+		t.Errorf("User locked out for %v, expected %v", d, loginLockoutBase)
+	}
+	if d := l.lockedFor("bob", "192.0.2.1"); d != loginLockoutBase {
+		t.Errorf("Address locked out for %v, expected %v", d, loginLockoutBase)
+	}
+	if d := l.lockedFor("bob", "198.51.100.1"); d != 0 {
+		t.Errorf("Others locked out for %v", d)
+	}
+
+	// Each further failure doubles the lockout, up to the maximum.
+	attempt(false, "alice", "192.0.2.1")
+	if d := l.lockedFor("alice", ""); d != 2*loginLockoutBase {
+		t.Errorf("Locked out for %v, expected %v", d, 2*loginLockoutBase)
+	}
+	for range 20 {
+		attempt(false, "alice", "192.0.2.1")
+	}
+	if d := l.lockedFor("alice", ""); d != maxLoginLockout {
+		t.Errorf("Locked out for %v, expected %v", d, maxLoginLockout)
+	}
+
+	now = now.Add(maxLoginLockout)
+	if d := l.lockedFor("alice", "192.0.2.1"); d != 0 {
+		t.Errorf("Still locked out for %v", d)
+	}
+
+	// A successful login resets the count.
+	attempt(false, "alice", "192.0.2.1")
+	attempt(true, "alice", "192.0.2.1")
+	attempt(false, "alice", "192.0.2.1")
+	if d := l.lockedFor("alice", "192.0.2.1"); d != 0 {
+		t.Errorf("Locked out for %v after successful login", d)
+	}
+}
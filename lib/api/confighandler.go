@@ -11,25 +11,34 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/syncthing/syncthing/internal/slogutil"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/structutil"
 )
 
+// maxApplyDocumentSize is plenty for the folders and devices of any
+// reasonable setup.
+const maxApplyDocumentSize = 16 << 20
+
 type configMuxBuilder struct {
 	*httprouter.Router
 
-	id  protocol.DeviceID
-	cfg config.Wrapper
+	id       protocol.DeviceID
+	cfg      config.Wrapper
+	evLogger events.Logger
+	history  *configHistory
 }
 
 func (c *configMuxBuilder) registerConfig(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.RawCopy())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, redactedConfig(r, c.cfg.CopyWithSecretReferences()))
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -38,8 +47,8 @@ func (c *configMuxBuilder) registerConfig(path string) {
 }
 
 func (c *configMuxBuilder) registerConfigDeprecated(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.RawCopy())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, redactedConfig(r, c.cfg.CopyWithSecretReferences()))
 	})
 
 	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
@@ -59,9 +68,113 @@ func (c *configMuxBuilder) registerConfigRequiresRestart(path string) {
 	})
 }
 
-func (c *configMuxBuilder) registerFolders(path string) {
+// registerConfigLocked lists the settings owned by the configuration
+// overlay, which can't be changed through the API.
+func (c *configMuxBuilder) registerConfigLocked(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		locked := c.cfg.LockedPaths()
+		if locked == nil {
+			locked = []string{}
+		}
+		sendJSON(w, locked)
+	})
+}
+
+// registerConfigSchema serves the JSON Schema of the configuration.
+func (c *configMuxBuilder) registerConfigSchema(path string) {
 	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.FolderList())
+		sendJSON(w, config.JSONSchema())
+	})
+}
+
+// registerConfigValidate checks a full configuration as if it were set,
+// without setting it. A configuration that can't be decoded is a bad
+// request; one that is rejected is reported as invalid, with the reason.
+func (c *configMuxBuilder) registerConfigValidate(path string) {
+	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
+		cfg, err := config.ReadJSON(r.Body, c.id)
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res := struct {
+			Valid bool   `json:"valid"`
+			Error string `json:"error,omitempty"`
+		}{Valid: true}
+		if err := c.cfg.ValidateAs(requestAuthor(r), cfg); err != nil {
+			res.Valid = false
+			res.Error = err.Error()
+		}
+		sendJSON(w, res)
+	})
+}
+
+// registerConfigApply reconciles the configuration with a declarative
+// document in YAML or JSON, returning the plan of changes. With dryRun set
+// the plan is only computed.
+func (c *configMuxBuilder) registerConfigApply(path string) {
+	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
+		bs, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxApplyDocumentSize))
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		doc, err := config.ParseApplyDocument(bs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		plan, err := doc.Plan(c.cfg.RawCopy(), c.id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun")); dryRun || plan.Empty() {
+			sendJSON(w, plan)
+			return
+		}
+
+		// The document is applied again to the configuration as it is when
+		// modified, so that concurrent changes elsewhere aren't undone. It's
+		// applied to a copy, as a failure halfway would otherwise leave the
+		// configuration partly changed.
+		var applyErr error
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			applied := cfg.Copy()
+			if applyErr = doc.Apply(&applied, c.id); applyErr != nil {
+				return
+			}
+			*cfg = applied
+		})
+		if applyErr != nil {
+			err = applyErr
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		waiter.Wait()
+		emitConfigChanged(r, c.evLogger)
+		if err := c.cfg.Save(); err != nil {
+			slog.Error("Failed to save config", slogutil.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, plan)
+	})
+}
+
+func (c *configMuxBuilder) registerFolders(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		folders := c.cfg.CopyWithSecretReferences().Folders
+		if requestRole(r) < config.GUIRoleAdmin {
+			for i := range folders {
+				folders[i] = folders[i].Redacted()
+			}
+		}
+		sendJSON(w, folders)
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -79,14 +192,14 @@ func (c *configMuxBuilder) registerFolders(path string) {
 				return
 			}
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 			cfg.SetFolders(folders)
 		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		c.finish(w, waiter)
+		c.finish(w, r, waiter)
 	})
 
 	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
@@ -114,14 +227,14 @@ func (c *configMuxBuilder) registerDevices(path string) {
 				return
 			}
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 			cfg.SetDevices(devices)
 		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		c.finish(w, waiter)
+		c.finish(w, r, waiter)
 	})
 
 	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
@@ -130,12 +243,16 @@ func (c *configMuxBuilder) registerDevices(path string) {
 }
 
 func (c *configMuxBuilder) registerFolder(path string) {
-	c.Handle(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
-		folder, ok := c.cfg.Folder(p.ByName("id"))
+	c.Handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		cfg := c.cfg.CopyWithSecretReferences()
+		folder, _, ok := cfg.Folder(p.ByName("id"))
 		if !ok {
 			http.Error(w, "No folder with given ID", http.StatusNotFound)
 			return
 		}
+		if requestRole(r) < config.GUIRoleAdmin {
+			folder = folder.Redacted()
+		}
 		sendJSON(w, folder)
 	})
 
@@ -152,13 +269,17 @@ func (c *configMuxBuilder) registerFolder(path string) {
 		c.adjustFolder(w, r, folder, false)
 	})
 
-	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
-		waiter, err := c.cfg.RemoveFolder(p.ByName("id"))
+	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			if _, i, ok := cfg.Folder(p.ByName("id")); ok {
+				cfg.Folders = slices.Delete(cfg.Folders, i, i+1)
+			}
+		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		c.finish(w, waiter)
+		c.finish(w, r, waiter)
 	})
 }
 
@@ -193,24 +314,32 @@ func (c *configMuxBuilder) registerDevice(path string) {
 		}
 	})
 
-	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		id, err := protocol.DeviceIDFromString(p.ByName("id"))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		waiter, err := c.cfg.RemoveDevice(id)
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			if _, i, ok := cfg.Device(id); ok {
+				cfg.Devices = slices.Delete(cfg.Devices, i, i+1)
+			}
+		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		c.finish(w, waiter)
+		c.finish(w, r, waiter)
 	})
 }
 
 func (c *configMuxBuilder) registerDefaultFolder(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.DefaultFolder())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		folder := c.cfg.DefaultFolder()
+		if requestRole(r) < config.GUIRoleAdmin {
+			folder = folder.Redacted()
+		}
+		sendJSON(w, folder)
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -251,14 +380,64 @@ func (c *configMuxBuilder) registerDefaultIgnores(path string) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 			cfg.Defaults.Ignores = ignores
 		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		c.finish(w, waiter)
+		c.finish(w, r, waiter)
+	})
+}
+
+func (c *configMuxBuilder) registerShareRules(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		rules := c.cfg.CopyWithSecretReferences().ShareRules
+		if requestRole(r) < config.GUIRoleAdmin {
+			for i := range rules {
+				rules[i] = rules[i].Redacted()
+			}
+		}
+		sendJSON(w, rules)
+	})
+
+	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
+		var rules []config.ShareRule
+		if err := unmarshalTo(r.Body, &rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			cfg.ShareRules = rules
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.finish(w, r, waiter)
+	})
+}
+
+func (c *configMuxBuilder) registerAutoAcceptRules(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		sendJSON(w, c.cfg.AutoAcceptRules())
+	})
+
+	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
+		var rules []config.AutoAcceptRule
+		if err := unmarshalTo(r.Body, &rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+			cfg.AutoAcceptRules = rules
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.finish(w, r, waiter)
 	})
 }
 
@@ -280,7 +459,7 @@ func (c *configMuxBuilder) registerOptions(path string) {
 
 func (c *configMuxBuilder) registerLDAP(path string) {
 	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.LDAP())
+		sendJSON(w, c.cfg.CopyWithSecretReferences().LDAP)
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -294,9 +473,29 @@ func (c *configMuxBuilder) registerLDAP(path string) {
 	})
 }
 
-func (c *configMuxBuilder) registerGUI(path string) {
+func (c *configMuxBuilder) registerOIDC(path string) {
 	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.GUI())
+		sendJSON(w, c.cfg.CopyWithSecretReferences().OIDC)
+	})
+
+	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
+		var cfg config.OIDCConfiguration
+		structutil.SetDefaults(&cfg)
+		c.adjustOIDC(w, r, cfg)
+	})
+
+	c.HandlerFunc(http.MethodPatch, path, func(w http.ResponseWriter, r *http.Request) {
+		c.adjustOIDC(w, r, c.cfg.OIDC())
+	})
+}
+
+func (c *configMuxBuilder) registerGUI(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		gui := c.cfg.CopyWithSecretReferences().GUI
+		if requestRole(r) < config.GUIRoleAdmin {
+			gui = gui.Redacted()
+		}
+		sendJSON(w, gui)
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -320,7 +519,7 @@ func (c *configMuxBuilder) adjustConfig(w http.ResponseWriter, r *http.Request)
 	}
 	var errMsg string
 	var status int
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		if err := c.postAdjustGui(&cfg.GUI, &to.GUI); err != nil {
 			errMsg = err.Error()
 			status = http.StatusInternalServerError
@@ -334,7 +533,7 @@ func (c *configMuxBuilder) adjustConfig(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
 }
 
 func (c *configMuxBuilder) adjustFolder(w http.ResponseWriter, r *http.Request, folder config.FolderConfiguration, defaults bool) {
@@ -342,7 +541,7 @@ func (c *configMuxBuilder) adjustFolder(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		if defaults {
 			cfg.Defaults.Folder = folder
 		} else {
@@ -353,7 +552,7 @@ func (c *configMuxBuilder) adjustFolder(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
 }
 
 func (c *configMuxBuilder) adjustDevice(w http.ResponseWriter, r *http.Request, device config.DeviceConfiguration, defaults bool) {
@@ -361,7 +560,7 @@ func (c *configMuxBuilder) adjustDevice(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		if defaults {
 			cfg.Defaults.Device = device
 		} else {
@@ -372,7 +571,7 @@ func (c *configMuxBuilder) adjustDevice(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
 }
 
 func (c *configMuxBuilder) adjustOptions(w http.ResponseWriter, r *http.Request, opts config.OptionsConfiguration) {
@@ -380,14 +579,14 @@ func (c *configMuxBuilder) adjustOptions(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		cfg.Options = opts
 	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
 }
 
 func (c *configMuxBuilder) adjustGUI(w http.ResponseWriter, r *http.Request, gui config.GUIConfiguration) {
@@ -398,7 +597,7 @@ func (c *configMuxBuilder) adjustGUI(w http.ResponseWriter, r *http.Request, gui
 	}
 	var errMsg string
 	var status int
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		if err := c.postAdjustGui(&cfg.GUI, &gui); err != nil {
 			errMsg = err.Error()
 			status = http.StatusInternalServerError
@@ -412,7 +611,7 @@ func (c *configMuxBuilder) adjustGUI(w http.ResponseWriter, r *http.Request, gui
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
 }
 
 func (c *configMuxBuilder) postAdjustGui(from *config.GUIConfiguration, to *config.GUIConfiguration) error {
@@ -422,6 +621,16 @@ func (c *configMuxBuilder) postAdjustGui(from *config.GUIConfiguration, to *conf
 			return err
 		}
 	}
+	for i, user := range to.Users {
+		idx := slices.IndexFunc(from.Users, func(u config.GUIUser) bool { return u.Name == user.Name })
+		if idx >= 0 && from.Users[idx].Password == user.Password {
+			continue
+		}
+		if err := to.Users[i].SetPassword(user.Password); err != nil {
+			slog.Error("Failed to hash password", slog.String("username", user.Name), slogutil.Error(err))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -430,14 +639,29 @@ func (c *configMuxBuilder) adjustLDAP(w http.ResponseWriter, r *http.Request, ld
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
 		cfg.LDAP = ldap
 	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	c.finish(w, waiter)
+	c.finish(w, r, waiter)
+}
+
+func (c *configMuxBuilder) adjustOIDC(w http.ResponseWriter, r *http.Request, oidc config.OIDCConfiguration) {
+	if err := unmarshalTo(r.Body, &oidc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	waiter, err := c.cfg.ModifyAs(requestAuthor(r), func(cfg *config.Configuration) {
+		cfg.OIDC = oidc
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.finish(w, r, waiter)
 }
 
 // Unmarshals the content of the given body and stores it in to (i.e. to must be a pointer).
@@ -456,10 +680,30 @@ func unmarshalToRawMessages(body io.ReadCloser) ([]json.RawMessage, error) {
 	return data, err
 }
 
-func (c *configMuxBuilder) finish(w http.ResponseWriter, waiter config.Waiter) {
+func (c *configMuxBuilder) finish(w http.ResponseWriter, r *http.Request, waiter config.Waiter) {
 	waiter.Wait()
+	emitConfigChanged(r, c.evLogger)
 	if err := c.cfg.Save(); err != nil {
 		slog.Error("Failed to save config", slogutil.Error(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// emitConfigChanged records a change of the configuration through the
+// API, and who made it.
+func emitConfigChanged(r *http.Request, evLogger events.Logger) {
+	remoteAddress, proxy := remoteAddress(r)
+	evData := map[string]any{
+		"method":        r.Method,
+		"path":          r.URL.Path,
+		"remoteAddress": remoteAddress,
+	}
+	if user, ok := apiUserFromRequest(r); ok {
+		evData["username"] = user.Name
+		evData["role"] = user.Role.String()
+	}
+	if proxy != "" {
+		evData["proxy"] = proxy
+	}
+	evLogger.Log(events.ConfigChanged, evData)
+}
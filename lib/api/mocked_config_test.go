@@ -13,6 +13,7 @@ import (
 func newMockedConfig() *mocks.Wrapper {
 	m := &mocks.Wrapper{}
 	m.ModifyReturns(noopWaiter{}, nil)
+	m.ModifyAsReturns(noopWaiter{}, nil)
 	m.RemoveFolderReturns(noopWaiter{}, nil)
 	m.RemoveDeviceReturns(noopWaiter{}, nil)
 	return m
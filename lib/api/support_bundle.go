@@ -13,24 +13,42 @@ import (
 	"github.com/syncthing/syncthing/lib/config"
 )
 
-// getRedactedConfig redacting some parts of config
+// getRedactedConfig redacting some parts of config. Secrets given as
+// references show the references, which tell where the secrets are kept
+// but not what they are.
 func getRedactedConfig(s *service) config.Configuration {
-	rawConf := s.cfg.RawCopy()
-	rawConf.GUI.APIKey = "REDACTED"
-	if rawConf.GUI.Password != "" {
-		rawConf.GUI.Password = "REDACTED"
+	rawConf := s.cfg.CopyWithSecretReferences()
+	redact := func(value *string) {
+		if *value != "" && !config.IsSecretReference(*value) {
+			*value = "REDACTED"
+		}
+	}
+
+	if !config.IsSecretReference(rawConf.GUI.APIKey) {
+		rawConf.GUI.APIKey = "REDACTED"
 	}
-	if rawConf.GUI.User != "" {
-		rawConf.GUI.User = "REDACTED"
+	redact(&rawConf.GUI.Password)
+	redact(&rawConf.GUI.User)
+	redact(&rawConf.GUI.TOTPSecret)
+	rawConf.GUI.TOTPRecoveryCodes = nil
+	redact(&rawConf.OIDC.ClientSecret)
+	for i := range rawConf.GUI.Users {
+		redact(&rawConf.GUI.Users[i].Password)
+		redact(&rawConf.GUI.Users[i].TOTPSecret)
+		rawConf.GUI.Users[i].TOTPRecoveryCodes = nil
+	}
+	for i := range rawConf.GUI.APIKeys {
+		redact(&rawConf.GUI.APIKeys[i].Key)
 	}
 
 	for folderIdx, folderCfg := range rawConf.Folders {
-		for deviceIdx, deviceCfg := range folderCfg.Devices {
-			if deviceCfg.EncryptionPassword != "" {
-				rawConf.Folders[folderIdx].Devices[deviceIdx].EncryptionPassword = "REDACTED"
-			}
+		for deviceIdx := range folderCfg.Devices {
+			redact(&rawConf.Folders[folderIdx].Devices[deviceIdx].EncryptionPassword)
 		}
 	}
+	for i := range rawConf.ShareRules {
+		redact(&rawConf.ShareRules[i].EncryptionPassword)
+	}
 
 	return rawConf
 }
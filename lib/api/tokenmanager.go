@@ -7,10 +7,10 @@
 package api
 
 import (
+	"encoding/json"
 	"math"
 	"net/http"
 	"slices"
-	"strings"
 	"sync"
 	"time"
 
@@ -19,7 +19,6 @@ import (
 	"github.com/syncthing/syncthing/internal/db"
 	"github.com/syncthing/syncthing/internal/gen/apiproto"
 	"github.com/syncthing/syncthing/lib/config"
-	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/rand"
 )
 
@@ -33,6 +32,7 @@ type tokenManager struct {
 
 	mut       sync.Mutex
 	tokens    *apiproto.TokenSet
+	data      map[string]string // token -> data associated with the token
 	saveTimer *time.Timer
 }
 
@@ -46,6 +46,10 @@ func newTokenManager(key string, miscDB *db.Typed, lifetime time.Duration, maxIt
 	if tokens.Tokens == nil {
 		tokens.Tokens = make(map[string]int64)
 	}
+	data := make(map[string]string)
+	if bs, ok, _ := miscDB.Bytes(key + "Data"); ok {
+		_ = json.Unmarshal(bs, &data) // best effort
+	}
 	return &tokenManager{
 		key:      key,
 		miscDB:   miscDB,
@@ -53,6 +57,7 @@ func newTokenManager(key string, miscDB *db.Typed, lifetime time.Duration, maxIt
 		maxItems: maxItems,
 		timeNow:  time.Now,
 		tokens:   &tokens,
+		data:     data,
 	}
 }
 
@@ -80,17 +85,33 @@ func (m *tokenManager) Check(token string) bool {
 
 // New creates a new token and returns it.
 func (m *tokenManager) New() string {
+	return m.NewWithData("")
+}
+
+// NewWithData creates a new token with associated data and returns it.
+func (m *tokenManager) NewWithData(data string) string {
 	token := rand.String(randomTokenLength)
 
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
 	m.tokens.Tokens[token] = m.newExpiryNanos()
+	if data != "" {
+		m.data[token] = data
+	}
 	m.saveLocked()
 
 	return token
 }
 
+// Data returns the data associated with a token. It does not check the
+// validity of the token.
+func (m *tokenManager) Data(token string) string {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.data[token]
+}
+
 func (m *tokenManager) newExpiryNanos() int64 {
 	if m.lifetime <= 0 {
 		return 0
@@ -104,6 +125,7 @@ func (m *tokenManager) Delete(token string) {
 	defer m.mut.Unlock()
 
 	delete(m.tokens.Tokens, token)
+	delete(m.data, token)
 	m.saveLocked()
 }
 
@@ -136,6 +158,13 @@ func (m *tokenManager) saveLocked() {
 		}
 	}
 
+	// Remove data for tokens that are gone.
+	for token := range m.data {
+		if _, ok := m.tokens.Tokens[token]; !ok {
+			delete(m.data, token)
+		}
+	}
+
 	// Postpone saving until one second of inactivity.
 	if m.saveTimer == nil {
 		m.saveTimer = time.AfterFunc(time.Second, m.scheduledSave)
@@ -152,17 +181,22 @@ func (m *tokenManager) scheduledSave() {
 
 	bs, _ := proto.Marshal(m.tokens) // can't fail
 	_ = m.miscDB.PutBytes(m.key, bs) // can fail, but what are we going to do?
+	if len(m.data) > 0 {
+		bs, _ = json.Marshal(m.data)
+		_ = m.miscDB.PutBytes(m.key+"Data", bs)
+	} else {
+		_ = m.miscDB.Delete(m.key + "Data")
+	}
 }
 
 type tokenCookieManager struct {
 	cookieName string
 	shortID    string
 	guiCfg     config.GUIConfiguration
-	evLogger   events.Logger
 	tokens     *tokenManager
 }
 
-func newTokenCookieManager(shortID string, guiCfg config.GUIConfiguration, evLogger events.Logger, miscDB *db.Typed) *tokenCookieManager {
+func newTokenCookieManager(shortID string, guiCfg config.GUIConfiguration, miscDB *db.Typed) *tokenCookieManager {
 	sessionLifetimeS := guiCfg.SessionCookieDurationS
 	if sessionLifetimeS == 0 {
 		sessionLifetimeS = defaultSessionCookieDurationS
@@ -171,23 +205,17 @@ func newTokenCookieManager(shortID string, guiCfg config.GUIConfiguration, evLog
 		cookieName: "sessionid-" + shortID,
 		shortID:    shortID,
 		guiCfg:     guiCfg,
-		evLogger:   evLogger,
 		tokens:     newTokenManager("sessions", miscDB, time.Duration(sessionLifetimeS)*time.Second, maxActiveSessions),
 	}
 }
 
-func (m *tokenCookieManager) createSession(username string, persistent bool, w http.ResponseWriter, r *http.Request) {
-	sessionid := m.tokens.New()
+func (m *tokenCookieManager) createSession(user apiUser, persistent bool, w http.ResponseWriter, r *http.Request) {
+	data, _ := json.Marshal(user) // can't fail
+	sessionid := m.tokens.NewWithData(string(data))
 
-	// Best effort detection of whether the connection is HTTPS --
-	// either directly to us, or as used by the client towards a reverse
-	// proxy who sends us headers.
-	connectionIsHTTPS := r.TLS != nil ||
-		strings.ToLower(r.Header.Get("X-Forwarded-Proto")) == "https" ||
-		strings.Contains(strings.ToLower(r.Header.Get("Forwarded")), "proto=https")
 	// If the connection is HTTPS, or *should* be HTTPS, set the Secure
 	// bit in cookies.
-	useSecureCookie := connectionIsHTTPS || m.guiCfg.UseTLS()
+	useSecureCookie := isHTTPSRequest(r) || m.guiCfg.UseTLS()
 
 	maxAge := 0
 	if persistent {
@@ -206,8 +234,6 @@ func (m *tokenCookieManager) createSession(username string, persistent bool, w h
 		Secure: useSecureCookie,
 		Path:   path,
 	})
-
-	emitLoginAttempt(true, username, r, m.evLogger)
 }
 
 func (m *tokenCookieManager) sessionCookieMaxAge() int {
@@ -224,7 +250,9 @@ func (m *tokenCookieManager) sessionCookieMaxAge() int {
 	}
 }
 
-func (m *tokenCookieManager) hasValidSession(r *http.Request) bool {
+// sessionUser returns the user of the request's session, if it has a
+// valid one.
+func (m *tokenCookieManager) sessionUser(r *http.Request) (apiUser, bool) {
 	for _, cookie := range r.Cookies() {
 		// We iterate here since there may, historically, be multiple
 		// cookies with the same name but different path. Any "old" ones
@@ -232,11 +260,16 @@ func (m *tokenCookieManager) hasValidSession(r *http.Request) bool {
 		// later removed on logout or when timing out.
 		if cookie.Name == m.cookieName {
 			if m.tokens.Check(cookie.Value) {
-				return true
+				var user apiUser
+				// Sessions created before users had roles don't
+				// unmarshal, and the user needs to log in again.
+				if err := json.Unmarshal([]byte(m.tokens.Data(cookie.Value)), &user); err == nil {
+					return user, true
+				}
 			}
 		}
 	}
-	return false
+	return apiUser{}, false
 }
 
 func (m *tokenCookieManager) destroySession(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,237 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// An ApplyDocument is the desired state of parts of the configuration, to
+// be reconciled against the running configuration. Folders and devices,
+// when given, are the complete sets: those not listed are removed, except
+// for this device. Each listed folder or device is merged over the
+// existing one with the same ID, or over the defaults for new ones, and
+// the options over the current options. Parts that aren't given are left
+// alone.
+type ApplyDocument struct {
+	Folders []json.RawMessage `json:"folders,omitempty"`
+	Devices []json.RawMessage `json:"devices,omitempty"`
+	Options json.RawMessage   `json:"options,omitempty"`
+}
+
+// ParseApplyDocument parses a document in YAML or JSON, with the field
+// names of the JSON configuration.
+func ParseApplyDocument(bs []byte) (ApplyDocument, error) {
+	bs, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return ApplyDocument{}, err
+	}
+	var doc ApplyDocument
+	if err := decodeStrict(bs, &doc); err != nil {
+		return ApplyDocument{}, err
+	}
+	return doc, nil
+}
+
+// Apply changes the configuration to the state described by the document.
+func (d ApplyDocument) Apply(cfg *Configuration, myID protocol.DeviceID) error {
+	if d.Folders != nil {
+		existing := cfg.FolderMap()
+		folders := make([]FolderConfiguration, 0, len(d.Folders))
+		seen := make(map[string]bool, len(d.Folders))
+		for _, bs := range d.Folders {
+			var key struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(bs, &key); err != nil {
+				return fmt.Errorf("folder: %w", err)
+			}
+			if key.ID == "" {
+				return errors.New("folder without ID")
+			}
+			if seen[key.ID] {
+				return fmt.Errorf("folder %s: listed twice", key.ID)
+			}
+			seen[key.ID] = true
+			folder, ok := existing[key.ID]
+			if !ok {
+				folder = cfg.Defaults.Folder
+			}
+			folder = folder.Copy()
+			if err := decodeReplacing(bs, &folder); err != nil {
+				return fmt.Errorf("folder %s: %w", key.ID, err)
+			}
+			folders = append(folders, folder)
+		}
+		cfg.Folders = folders
+	}
+
+	if d.Devices != nil {
+		existing := cfg.DeviceMap()
+		devices := make([]DeviceConfiguration, 0, len(d.Devices))
+		seen := make(map[protocol.DeviceID]bool, len(d.Devices))
+		for _, bs := range d.Devices {
+			var key struct {
+				DeviceID protocol.DeviceID `json:"deviceID"`
+			}
+			if err := json.Unmarshal(bs, &key); err != nil {
+				return fmt.Errorf("device: %w", err)
+			}
+			if key.DeviceID == protocol.EmptyDeviceID {
+				return errors.New("device without ID")
+			}
+			if seen[key.DeviceID] {
+				return fmt.Errorf("device %s: listed twice", key.DeviceID)
+			}
+			seen[key.DeviceID] = true
+			device, ok := existing[key.DeviceID]
+			if !ok {
+				device = cfg.Defaults.Device
+			}
+			device = device.Copy()
+			if err := decodeReplacing(bs, &device); err != nil {
+				return fmt.Errorf("device %s: %w", key.DeviceID, err)
+			}
+			devices = append(devices, device)
+		}
+		if my, ok := existing[myID]; ok && !seen[myID] {
+			devices = append(devices, my)
+		}
+		cfg.Devices = devices
+	}
+
+	if d.Options != nil {
+		if err := decodeReplacing(d.Options, &cfg.Options); err != nil {
+			return fmt.Errorf("options: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Plan returns what applying the document to cfg changes, once the result
+// is prepared as it would be when committed.
+func (d ApplyDocument) Plan(cfg Configuration, myID protocol.DeviceID) (ApplyPlan, error) {
+	to := cfg.Copy()
+	if err := d.Apply(&to, myID); err != nil {
+		return ApplyPlan{}, err
+	}
+	if err := to.prepare(myID); err != nil {
+		return ApplyPlan{}, err
+	}
+
+	plan := ApplyPlan{Changes: Diff(cfg, to)}
+	fromFolders, toFolders := cfg.FolderMap(), to.FolderMap()
+	for _, f := range to.Folders {
+		if _, ok := fromFolders[f.ID]; !ok {
+			plan.AddedFolders = append(plan.AddedFolders, f.ID)
+		} else if plan.changes("folders[" + f.ID + "]") {
+			plan.ChangedFolders = append(plan.ChangedFolders, f.ID)
+		}
+	}
+	for _, f := range cfg.Folders {
+		if _, ok := toFolders[f.ID]; !ok {
+			plan.RemovedFolders = append(plan.RemovedFolders, f.ID)
+		}
+	}
+	fromDevices, toDevices := cfg.DeviceMap(), to.DeviceMap()
+	for _, dev := range to.Devices {
+		if _, ok := fromDevices[dev.DeviceID]; !ok {
+			plan.AddedDevices = append(plan.AddedDevices, dev.DeviceID)
+		} else if plan.changes("devices[" + dev.DeviceID.String() + "]") {
+			plan.ChangedDevices = append(plan.ChangedDevices, dev.DeviceID)
+		}
+	}
+	for _, dev := range cfg.Devices {
+		if _, ok := toDevices[dev.DeviceID]; !ok {
+			plan.RemovedDevices = append(plan.RemovedDevices, dev.DeviceID)
+		}
+	}
+	plan.ChangedOptions = plan.changes("options")
+
+	return plan, nil
+}
+
+// An ApplyPlan is what applying a document changes. The changes are those
+// reported by Diff, the other fields summarize them.
+type ApplyPlan struct {
+	AddedFolders   []string            `json:"addedFolders"`
+	ChangedFolders []string            `json:"changedFolders"`
+	RemovedFolders []string            `json:"removedFolders"`
+	AddedDevices   []protocol.DeviceID `json:"addedDevices"`
+	ChangedDevices []protocol.DeviceID `json:"changedDevices"`
+	RemovedDevices []protocol.DeviceID `json:"removedDevices"`
+	ChangedOptions bool                `json:"changedOptions"`
+	Changes        []Change            `json:"changes"`
+}
+
+// Empty returns true if applying the document changes nothing.
+func (p ApplyPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// changes returns true if there are changes at or below the given path.
+func (p ApplyPlan) changes(path string) bool {
+	for _, c := range p.Changes {
+		if c.Path == path || strings.HasPrefix(c.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeStrict unmarshals the JSON, refusing unknown fields so that typos
+// in a document aren't silently ignored.
+func decodeStrict(bs []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// decodeReplacing unmarshals the JSON over v, a pointer to a struct, like
+// decodeStrict. The slices and maps the JSON sets are replaced rather than
+// decoded into, as encoding/json would otherwise keep the fields of
+// existing elements and the keys of existing maps that the JSON leaves out.
+func decodeReplacing(bs []byte, v any) error {
+	clearNamed(bs, reflect.ValueOf(v).Elem())
+	return decodeStrict(bs, v)
+}
+
+// clearNamed zeroes the slice and map fields of the struct that the JSON
+// object sets, recursing into the struct fields it sets. Malformed JSON is
+// left for the decoder to report.
+func clearNamed(bs []byte, v reflect.Value) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &keys); err != nil {
+		return
+	}
+	t := v.Type()
+	for key, val := range keys {
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if !strings.EqualFold(name, key) {
+				continue
+			}
+			switch f := v.Field(i); f.Kind() {
+			case reflect.Slice, reflect.Map:
+				f.SetZero()
+			case reflect.Struct:
+				clearNamed(val, f)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestApplyDocument(t *testing.T) {
+	cfg := New(device1)
+	cfg.Options.MaxRecvKbps = 50
+	cfg.SetDevice(DeviceConfiguration{DeviceID: device2, Name: "two", Compression: CompressionNever})
+	cfg.SetDevice(DeviceConfiguration{DeviceID: device3, Name: "three"})
+	cfg.SetFolders([]FolderConfiguration{
+		{ID: "keep", Path: "/keep", Label: "Keep"},
+		{ID: "change", Path: "/change", Label: "Change"},
+		{ID: "remove", Path: "/remove"},
+	})
+	if err := cfg.prepare(device1); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := ParseApplyDocument([]byte(`
+folders:
+  - id: keep
+  - id: change
+    label: Changed
+    devices:
+      - deviceID: ` + device2.String() + `
+  - id: add
+    path: /add
+devices:
+  - deviceID: ` + device2.String() + `
+    name: second
+  - deviceID: ` + device4.String() + `
+options:
+  maxSendKbps: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := doc.Plan(cfg, device1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(plan.AddedFolders, []string{"add"}) {
+		t.Errorf("Added folders %v", plan.AddedFolders)
+	}
+	if !slices.Equal(plan.ChangedFolders, []string{"change"}) {
+		t.Errorf("Changed folders %v", plan.ChangedFolders)
+	}
+	if !slices.Equal(plan.RemovedFolders, []string{"remove"}) {
+		t.Errorf("Removed folders %v", plan.RemovedFolders)
+	}
+	if !slices.Equal(plan.AddedDevices, []protocol.DeviceID{device4}) {
+		t.Errorf("Added devices %v", plan.AddedDevices)
+	}
+	if !slices.Equal(plan.ChangedDevices, []protocol.DeviceID{device2}) {
+		t.Errorf("Changed devices %v", plan.ChangedDevices)
+	}
+	if !slices.Equal(plan.RemovedDevices, []protocol.DeviceID{device3}) {
+		t.Errorf("Removed devices %v", plan.RemovedDevices)
+	}
+	if !plan.ChangedOptions {
+		t.Error("Options change not planned")
+	}
+
+	to := cfg.Copy()
+	if err := doc.Apply(&to, device1); err != nil {
+		t.Fatal(err)
+	}
+	if f, _, _ := to.Folder("keep"); f.Path != "/keep" || f.Label != "Keep" {
+		t.Errorf("Unlisted fields of folder not kept: %+v", f)
+	}
+	if f, _, _ := to.Folder("add"); f.Path != "/add" || f.RescanIntervalS != cfg.Defaults.Folder.RescanIntervalS {
+		t.Errorf("New folder not based on the defaults: %+v", f)
+	}
+	if d, _, _ := to.Device(device2); d.Name != "second" || d.Compression != CompressionNever {
+		t.Errorf("Device not merged: %+v", d)
+	}
+	if _, _, ok := to.Device(device1); !ok {
+		t.Error("Own device removed")
+	}
+	if to.Options.MaxSendKbps != 100 || to.Options.MaxRecvKbps != 50 {
+		t.Errorf("Options not merged: %+v", to.Options)
+	}
+
+	// Applying the result again changes nothing.
+	if err := to.prepare(device1); err != nil {
+		t.Fatal(err)
+	}
+	if plan, err := doc.Plan(to, device1); err != nil || !plan.Empty() {
+		t.Errorf("Second apply planned %+v, %v", plan.Changes, err)
+	}
+}
+
+func TestApplyDocumentReplacesLists(t *testing.T) {
+	cfg := New(device1)
+	cfg.SetFolder(FolderConfiguration{
+		ID:         "f",
+		Path:       "/f",
+		Devices:    []FolderDeviceConfiguration{{DeviceID: device2, EncryptionPassword: "pw"}},
+		Versioning: VersioningConfiguration{Type: "simple", Params: map[string]string{"keep": "5", "cleanoutDays": "7"}},
+	})
+
+	doc, err := ParseApplyDocument([]byte(`
+folders:
+  - id: f
+    devices:
+      - deviceID: ` + device3.String() + `
+    versioning:
+      type: simple
+      params:
+        keep: "10"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Apply(&cfg, device1); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _, _ := cfg.Folder("f")
+	if len(f.Devices) != 1 || f.Devices[0].DeviceID != device3 || f.Devices[0].EncryptionPassword != "" {
+		t.Errorf("Devices not replaced: %+v", f.Devices)
+	}
+	if len(f.Versioning.Params) != 1 || f.Versioning.Params["keep"] != "10" {
+		t.Errorf("Versioning parameters not replaced: %v", f.Versioning.Params)
+	}
+	if f.Path != "/f" {
+		t.Errorf("Unlisted field not kept: %q", f.Path)
+	}
+}
+
+func TestApplyDocumentErrors(t *testing.T) {
+	cases := []string{
+		`{"gui": {}}`,
+		`{"folders": [{"path": "/a"}]}`,
+		`{"folders": [{"id": "a", "path": "/a", "pth": "/b"}]}`,
+		`{"folders": [{"id": "a", "path": "/a"}, {"id": "a", "path": "/b"}]}`,
+		`{"devices": [{"name": "x"}]}`,
+		`{"options": {"maxSendKbps": "fast"}}`,
+	}
+	for _, tc := range cases {
+		doc, err := ParseApplyDocument([]byte(tc))
+		if err == nil {
+			_, err = doc.Plan(New(device1), device1)
+		}
+		if err == nil {
+			t.Errorf("No error for %s", tc)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// AuthorKind is the kind of party making a configuration change.
+type AuthorKind int32
+
+const (
+	// Syncthing itself, e.g. when accepting a folder or at startup.
+	AuthorKindSystem AuthorKind = 0
+	// A GUI user.
+	AuthorKindUser AuthorKind = 1
+	// A REST API client using an API key.
+	AuthorKindAPIKey AuthorKind = 2
+	// The syncthing cli command.
+	AuthorKindCLI AuthorKind = 3
+)
+
+func (k AuthorKind) String() string {
+	switch k {
+	case AuthorKindSystem:
+		return "system"
+	case AuthorKindUser:
+		return "user"
+	case AuthorKindAPIKey:
+		return "apiKey"
+	case AuthorKindCLI:
+		return "cli"
+	default:
+		return "unknown"
+	}
+}
+
+func (k AuthorKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *AuthorKind) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "user":
+		*k = AuthorKindUser
+	case "apiKey":
+		*k = AuthorKindAPIKey
+	case "cli":
+		*k = AuthorKindCLI
+	default:
+		*k = AuthorKindSystem
+	}
+	return nil
+}
+
+// Author is who made a configuration change. The name is that of the user
+// or API key, if known.
+type Author struct {
+	Kind AuthorKind `json:"kind"`
+	Name string     `json:"name,omitempty"`
+}
+
+// AuthorSystem is the author of changes made by Syncthing itself.
+var AuthorSystem = Author{Kind: AuthorKindSystem}
+
+func (a Author) String() string {
+	if a.Name == "" {
+		return a.Kind.String()
+	}
+	return a.Kind.String() + " " + a.Name
+}
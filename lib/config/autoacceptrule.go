@@ -0,0 +1,152 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+var (
+	errAutoAcceptRuleNameEmpty     = errors.New("auto-accept rule has empty name")
+	errAutoAcceptRuleNameDuplicate = errors.New("auto-accept rule has duplicate name")
+	errAutoAcceptRuleEncryptedType = errors.New("auto-accept rule has folder type receiveencrypted; set receiveEncrypted instead")
+	errAutoAcceptPathUnsafe        = errors.New("offered folder would be outside the auto-accept rule's directory")
+)
+
+// AutoAcceptRule accepts folders offered by devices, in addition to or
+// instead of DeviceConfiguration.AutoAcceptFolders. The first rule matching
+// an offered folder applies. Empty conditions match anything; the folder
+// ID and label are regular expressions matching the whole value.
+//
+// The path is a template which may contain %FOLDER_ID%, %FOLDER_LABEL%,
+// %DEVICE_ID% and %DEVICE_NAME%; relative paths are below the default
+// folder path. Without a path the folder is put where AutoAcceptFolders
+// would put it. Folders sent encrypted are accepted as receive encrypted if
+// ReceiveEncrypted is set, and only then; the type and versioning are for
+// other folders.
+type AutoAcceptRule struct {
+	Name             string                  `json:"name" xml:"name,attr"`
+	FolderID         string                  `json:"folderID" xml:"folderID,omitempty"`
+	FolderLabel      string                  `json:"folderLabel" xml:"folderLabel,omitempty"`
+	Device           protocol.DeviceID       `json:"device" xml:"device,omitempty"`
+	DeviceGroup      string                  `json:"deviceGroup" xml:"deviceGroup,omitempty"`
+	Path             string                  `json:"path" xml:"path,omitempty"`
+	FolderType       FolderType              `json:"folderType" xml:"folderType"`
+	Versioning       VersioningConfiguration `json:"versioning" xml:"versioning"`
+	ReceiveEncrypted bool                    `json:"receiveEncrypted" xml:"receiveEncrypted"`
+}
+
+func (r AutoAcceptRule) Copy() AutoAcceptRule {
+	r.Versioning = r.Versioning.Copy()
+	return r
+}
+
+// Matches returns whether the rule applies to the folder offered by the
+// device.
+func (r AutoAcceptRule) Matches(folderID, folderLabel string, device DeviceConfiguration) bool {
+	if r.Device != protocol.EmptyDeviceID && r.Device != device.DeviceID {
+		return false
+	}
+	if r.DeviceGroup != "" && r.DeviceGroup != device.Group {
+		return false
+	}
+	return matchWhole(r.FolderID, folderID) && matchWhole(r.FolderLabel, folderLabel)
+}
+
+// FolderPath returns the path of the folder offered by the device,
+// expanding the template. It's empty if the rule has no path. The values
+// put into the template are chosen by the offering device, so it's an
+// error if one of them is empty or "." or "..", or if the result is not
+// below the directory the template starts with.
+func (r AutoAcceptRule) FolderPath(folderID, folderLabel string, device DeviceConfiguration) (string, error) {
+	if r.Path == "" {
+		return "", nil
+	}
+	if folderLabel == "" {
+		folderLabel = folderID
+	}
+	vars := []struct{ name, value string }{
+		{"%FOLDER_ID%", fs.SanitizePath(folderID)},
+		{"%FOLDER_LABEL%", fs.SanitizePath(folderLabel)},
+		{"%DEVICE_ID%", device.DeviceID.String()},
+		{"%DEVICE_NAME%", fs.SanitizePath(device.Name)},
+	}
+	static := r.Path // the template up to the first variable
+	var oldnew []string
+	for _, v := range vars {
+		i := strings.Index(r.Path, v.name)
+		if i < 0 {
+			continue
+		}
+		if v.value == "" || v.value == "." || v.value == ".." {
+			return "", fmt.Errorf("%s is %q: %w", v.name, v.value, errAutoAcceptPathUnsafe)
+		}
+		if i < len(static) {
+			static = r.Path[:i]
+		}
+		oldnew = append(oldnew, v.name, v.value)
+	}
+	path := strings.NewReplacer(oldnew...).Replace(r.Path)
+
+	dir := static[:strings.LastIndexAny(static, `/`+string(filepath.Separator))+1]
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s: %w", path, errAutoAcceptPathUnsafe)
+	}
+	return path, nil
+}
+
+// MatchAutoAcceptRule returns the first of the rules matching the folder
+// offered by the device.
+func MatchAutoAcceptRule(rules []AutoAcceptRule, folderID, folderLabel string, device DeviceConfiguration) (AutoAcceptRule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(folderID, folderLabel, device) {
+			return rule.Copy(), true
+		}
+	}
+	return AutoAcceptRule{}, false
+}
+
+func matchWhole(expr, value string) bool {
+	if expr == "" {
+		return true
+	}
+	re, err := regexp.Compile(`^(?:` + expr + `)$`)
+	if err != nil {
+		// Rejected when preparing the configuration.
+		return false
+	}
+	return re.MatchString(value)
+}
+
+func (cfg *Configuration) prepareAutoAcceptRules() error {
+	names := make(map[string]bool, len(cfg.AutoAcceptRules))
+	for _, rule := range cfg.AutoAcceptRules {
+		switch {
+		case rule.Name == "":
+			return errAutoAcceptRuleNameEmpty
+		case names[rule.Name]:
+			return fmt.Errorf("auto-accept rule %q: %w", rule.Name, errAutoAcceptRuleNameDuplicate)
+		case rule.FolderType == FolderTypeReceiveEncrypted:
+			return fmt.Errorf("auto-accept rule %q: %w", rule.Name, errAutoAcceptRuleEncryptedType)
+		}
+		names[rule.Name] = true
+		for _, expr := range []string{rule.FolderID, rule.FolderLabel} {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("auto-accept rule %q: %w", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAutoAcceptRuleMatch(t *testing.T) {
+	rules := []AutoAcceptRule{
+		{Name: "one", Device: device1, FolderLabel: "Photos"},
+		{Name: "team", DeviceGroup: "team", FolderID: "proj-[a-z]+"},
+		{Name: "any", FolderID: "shared-.*"},
+	}
+	team := DeviceConfiguration{DeviceID: device2, Group: "team"}
+	cases := []struct {
+		id, label string
+		device    DeviceConfiguration
+		rule      string
+	}{
+		{"abc", "Photos", DeviceConfiguration{DeviceID: device1}, "one"},
+		{"abc", "Photos", team, ""},
+		{"proj-x", "", team, "team"},
+		{"proj-x1", "", team, ""}, // the whole ID must match
+		{"proj-x", "", DeviceConfiguration{DeviceID: device3}, ""},
+		{"shared-x", "", DeviceConfiguration{DeviceID: device3}, "any"},
+	}
+	for _, tc := range cases {
+		rule, ok := MatchAutoAcceptRule(rules, tc.id, tc.label, tc.device)
+		if ok != (tc.rule != "") || rule.Name != tc.rule {
+			t.Errorf("%s/%s from %v: got %q, expected %q", tc.id, tc.label, tc.device.DeviceID, rule.Name, tc.rule)
+		}
+	}
+}
+
+func TestAutoAcceptRuleFolderPath(t *testing.T) {
+	rule := AutoAcceptRule{Path: "/data/%DEVICE_NAME%/%FOLDER_LABEL% (%FOLDER_ID%)"}
+	device := DeviceConfiguration{DeviceID: device1, Name: "laptop"}
+	if p, err := rule.FolderPath("abcd-1234", "My: Files", device); err != nil || p != "/data/laptop/My Files (abcd-1234)" {
+		t.Errorf("Unexpected path %q, %v", p, err)
+	}
+	if p, err := rule.FolderPath("abcd-1234", "", device); err != nil || p != "/data/laptop/abcd-1234 (abcd-1234)" {
+		t.Errorf("Unexpected path %q, %v without label", p, err)
+	}
+
+	// The offering device can't move the folder out of the rule's
+	// directory.
+	cases := []struct {
+		path, label string
+	}{
+		{"/srv/sync/%FOLDER_LABEL%/inbox", ".."},
+		{"/srv/sync/%FOLDER_LABEL%", "."},
+		{"/srv/sync/%FOLDER_LABEL%", " : "},
+		{"%FOLDER_LABEL%/inbox", ".."},
+	}
+	for _, tc := range cases {
+		rule := AutoAcceptRule{Path: tc.path}
+		if p, err := rule.FolderPath("abcd-1234", tc.label, device); !errors.Is(err, errAutoAcceptPathUnsafe) {
+			t.Errorf("Label %q in %s: got %q, %v", tc.label, tc.path, p, err)
+		}
+	}
+	for _, path := range []string{"/srv/sync/%FOLDER_LABEL%/inbox", "sync/%FOLDER_LABEL%", "~/%FOLDER_LABEL%", "/srv/%DEVICE_NAME%-%FOLDER_LABEL%"} {
+		rule := AutoAcceptRule{Path: path}
+		if _, err := rule.FolderPath("abcd-1234", "..files", device); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestAutoAcceptRuleErrors(t *testing.T) {
+	cases := []struct {
+		rules []AutoAcceptRule
+		err   error
+	}{
+		{[]AutoAcceptRule{{}}, errAutoAcceptRuleNameEmpty},
+		{[]AutoAcceptRule{{Name: "a"}, {Name: "a"}}, errAutoAcceptRuleNameDuplicate},
+		{[]AutoAcceptRule{{Name: "a", FolderType: FolderTypeReceiveEncrypted}}, errAutoAcceptRuleEncryptedType},
+	}
+	for _, tc := range cases {
+		cfg := New(device1)
+		cfg.AutoAcceptRules = tc.rules
+		if err := cfg.prepare(device1); !errors.Is(err, tc.err) {
+			t.Errorf("Expected %v for %v, got %v", tc.err, tc.rules, err)
+		}
+	}
+
+	cfg := New(device1)
+	cfg.AutoAcceptRules = []AutoAcceptRule{{Name: "a", FolderLabel: "("}}
+	if err := cfg.prepare(device1); err == nil {
+		t.Error("Invalid regular expression accepted")
+	}
+}
@@ -107,3 +107,52 @@ func TestReplaceCommit(t *testing.T) {
 		t.Fatal("Config should not have changed")
 	}
 }
+
+type auditor struct {
+	authors chan Author
+}
+
+func (a auditor) AuditConfiguration(_, _ Configuration, author Author) {
+	a.authors <- author
+}
+
+func (auditor) CommitConfiguration(_, _ Configuration) bool {
+	return true
+}
+
+func (auditor) String() string {
+	return "auditor"
+}
+
+func TestAuditCommit(t *testing.T) {
+	w := wrap("/dev/null", Configuration{Version: CurrentVersion}, device1)
+	defer w.stop()
+
+	sub := auditor{authors: make(chan Author, 10)}
+	w.Subscribe(sub)
+
+	alice := Author{Kind: AuthorKindUser, Name: "alice"}
+	waiter, err := w.ModifyAs(alice, func(cfg *Configuration) {
+		cfg.Options.MaxSendKbps = 100
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if author := <-sub.authors; author != alice {
+		t.Errorf("Got author %v, expected %v", author, alice)
+	}
+
+	replace(t, w, Configuration{Version: CurrentVersion})
+	if author := <-sub.authors; author != AuthorSystem {
+		t.Errorf("Got author %v, expected %v", author, AuthorSystem)
+	}
+
+	// Changes that change nothing aren't audited.
+	replace(t, w, w.RawCopy())
+	select {
+	case author := <-sub.authors:
+		t.Errorf("Unexpected audit of non-change by %v", author)
+	default:
+	}
+}
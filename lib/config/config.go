@@ -95,8 +95,11 @@ type Configuration struct {
 	Version                  int                   `json:"version" xml:"version,attr"`
 	Folders                  []FolderConfiguration `json:"folders" xml:"folder"`
 	Devices                  []DeviceConfiguration `json:"devices" xml:"device"`
+	ShareRules               []ShareRule           `json:"shareRules" xml:"shareRule"`
+	AutoAcceptRules          []AutoAcceptRule      `json:"autoAcceptRules" xml:"autoAcceptRule"`
 	GUI                      GUIConfiguration      `json:"gui" xml:"gui"`
 	LDAP                     LDAPConfiguration     `json:"ldap" xml:"ldap"`
+	OIDC                     OIDCConfiguration     `json:"oidc" xml:"oidc"`
 	Options                  OptionsConfiguration  `json:"options" xml:"options"`
 	IgnoredDevices           []ObservedDevice      `json:"remoteIgnoredDevices" xml:"remoteIgnoredDevice"`
 	DeprecatedPendingDevices []ObservedDevice      `json:"-" xml:"pendingDevice,omitempty"` // Deprecated: Do not use.
@@ -250,8 +253,16 @@ func (cfg Configuration) Copy() Configuration {
 		newCfg.Devices[i] = cfg.Devices[i].Copy()
 	}
 
+	newCfg.ShareRules = slices.Clone(cfg.ShareRules)
+	newCfg.AutoAcceptRules = make([]AutoAcceptRule, len(cfg.AutoAcceptRules))
+	for i := range newCfg.AutoAcceptRules {
+		newCfg.AutoAcceptRules[i] = cfg.AutoAcceptRules[i].Copy()
+	}
+
 	newCfg.Options = cfg.Options.Copy()
 	newCfg.GUI = cfg.GUI.Copy()
+	newCfg.LDAP = cfg.LDAP.Copy()
+	newCfg.OIDC = cfg.OIDC.Copy()
 
 	// DeviceIDs are values
 	newCfg.IgnoredDevices = make([]ObservedDevice, len(cfg.IgnoredDevices))
@@ -260,6 +271,22 @@ func (cfg Configuration) Copy() Configuration {
 	return newCfg
 }
 
+// Redacted returns a copy of the configuration without credentials, for
+// users that may look at but not change the configuration.
+func (cfg Configuration) Redacted() Configuration {
+	cfg = cfg.Copy()
+	cfg.GUI = cfg.GUI.Redacted()
+	cfg.OIDC.ClientSecret = ""
+	cfg.Defaults.Folder = cfg.Defaults.Folder.Redacted()
+	for i := range cfg.Folders {
+		cfg.Folders[i] = cfg.Folders[i].Redacted()
+	}
+	for i := range cfg.ShareRules {
+		cfg.ShareRules[i] = cfg.ShareRules[i].Redacted()
+	}
+	return cfg
+}
+
 func (cfg *Configuration) WriteXML(w io.Writer) error {
 	e := xml.NewEncoder(w)
 	e.Indent("", "    ")
@@ -275,6 +302,13 @@ func (cfg *Configuration) WriteXML(w io.Writer) error {
 func (cfg *Configuration) prepare(myID protocol.DeviceID) error {
 	cfg.ensureMyDevice(myID)
 
+	if err := cfg.applyShareRules(myID); err != nil {
+		return err
+	}
+	if err := cfg.prepareAutoAcceptRules(); err != nil {
+		return err
+	}
+
 	existingDevices, err := cfg.prepareFoldersAndDevices(myID)
 	if err != nil {
 		return err
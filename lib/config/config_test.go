@@ -98,6 +98,9 @@ func TestDefaultValues(t *testing.T) {
 			ConnectionPriorityTCPWAN:  30,
 			ConnectionPriorityQUICWAN: 40,
 			ConnectionPriorityRelay:   50,
+
+			ChangeJournalRetentionDays: 30,
+			ChangeJournalMaxEntries:    100000,
 		},
 		Defaults: Defaults{
 			Folder: FolderConfiguration{
@@ -137,7 +140,9 @@ func TestDefaultValues(t *testing.T) {
 				Lines: []string{},
 			},
 		},
-		IgnoredDevices: []ObservedDevice{},
+		IgnoredDevices:  []ObservedDevice{},
+		ShareRules:      []ShareRule{},
+		AutoAcceptRules: []AutoAcceptRule{},
 	}
 	expected.Devices = []DeviceConfiguration{expected.Defaults.Device.Copy()}
 	expected.Devices[0].DeviceID = device1
@@ -146,6 +151,7 @@ func TestDefaultValues(t *testing.T) {
 	cfg := New(device1)
 	cfg.GUI = GUIConfiguration{}
 	cfg.LDAP = LDAPConfiguration{}
+	cfg.OIDC = OIDCConfiguration{}
 
 	if diff, equal := messagediff.PrettyDiff(expected, cfg); !equal {
 		t.Errorf("Default config differs. Diff:\n%s", diff)
@@ -304,6 +310,9 @@ func TestOverriddenValues(t *testing.T) {
 		ConnectionPriorityTCPWAN:  50,
 		ConnectionPriorityQUICWAN: 55,
 		ConnectionPriorityRelay:   9000,
+
+		ChangeJournalRetentionDays: 7,
+		ChangeJournalMaxEntries:    1000,
 	}
 	expectedPath := "/media/syncthing"
 
@@ -844,6 +853,118 @@ func TestGUIPasswordHash(t *testing.T) {
 	}
 }
 
+func TestGUIUsers(t *testing.T) {
+	c := GUIConfiguration{User: "legacy"}
+	if err := c.SetPassword("legacypass"); err != nil {
+		t.Fatal(err)
+	}
+	op := GUIUser{Name: "op", Role: GUIRoleOperator}
+	if err := op.SetPassword("oppass"); err != nil {
+		t.Fatal(err)
+	}
+	c.Users = append(c.Users, op, GUIUser{Name: "nopass", Role: GUIRoleAdmin})
+
+	if role, ok := c.AuthenticateUser("legacy", "legacypass"); !ok || role != GUIRoleAdmin {
+		t.Errorf("Legacy user: got %v, %v", role, ok)
+	}
+	if role, ok := c.AuthenticateUser("op", "oppass"); !ok || role != GUIRoleOperator {
+		t.Errorf("Operator: got %v, %v", role, ok)
+	}
+	if _, ok := c.AuthenticateUser("op", "legacypass"); ok {
+		t.Error("Operator authenticated with wrong password")
+	}
+	if _, ok := c.AuthenticateUser("nopass", ""); ok {
+		t.Error("User without password authenticated")
+	}
+
+	red := c.Redacted()
+	if red.Password != "" || red.Users[0].Password != "" {
+		t.Error("Redacted configuration contains passwords")
+	}
+	if c.Users[0].Password == "" {
+		t.Error("Redacting modified the original")
+	}
+}
+
+func TestGUIUserTOTP(t *testing.T) {
+	c := GUIConfiguration{User: "legacy", Users: []GUIUser{{Name: "op", Role: GUIRoleOperator}}}
+
+	if !c.SetUserTOTP("legacy", "SECRET1", []string{"h1"}) || !c.SetUserTOTP("op", "SECRET2", []string{"h2", "h3"}) {
+		t.Fatal("Failed to set TOTP")
+	}
+	if c.SetUserTOTP("nobody", "SECRET3", nil) || c.SetUserTOTP("", "SECRET3", nil) {
+		t.Error("Set TOTP for unknown user")
+	}
+	if secret, codes, ok := c.UserTOTP("legacy"); !ok || secret != "SECRET1" || len(codes) != 1 {
+		t.Errorf("Legacy user: got %q, %v, %v", secret, codes, ok)
+	}
+	if secret, codes, ok := c.UserTOTP("op"); !ok || secret != "SECRET2" || len(codes) != 2 {
+		t.Errorf("Operator: got %q, %v, %v", secret, codes, ok)
+	}
+	if _, _, ok := c.UserTOTP("nobody"); ok {
+		t.Error("Got TOTP for unknown user")
+	}
+
+	cp := c.Copy()
+	cp.Users[0].TOTPRecoveryCodes[0] = "changed"
+	if c.Users[0].TOTPRecoveryCodes[0] != "h2" {
+		t.Error("Copy shares recovery codes with the original")
+	}
+
+	red := c.Redacted()
+	if red.TOTPSecret != "" || red.Users[0].TOTPSecret != "" || red.Users[0].TOTPRecoveryCodes != nil {
+		t.Error("Redacted configuration contains TOTP secrets")
+	}
+}
+
+func TestGUIAPIKeysPrepare(t *testing.T) {
+	c := GUIConfiguration{
+		APIKey: "main",
+		APIKeys: []GUIAPIKey{
+			{Name: "a", Key: "k1"},
+			{Name: "a", Key: "k2"},   // duplicate name
+			{Name: "b", Key: "k1"},   // duplicate key
+			{Name: "c", Key: "main"}, // same as the full access key
+			{Name: "", Key: "k3"},
+			{Name: "d", Key: ""},
+			{Name: "e", Key: "k4"},
+		},
+	}
+	c.prepare()
+
+	var names []string
+	for _, k := range c.APIKeys {
+		names = append(names, k.Name)
+	}
+	if !slices.Equal(names, []string{"a", "e"}) {
+		t.Errorf("Unexpected keys after prepare: %v", names)
+	}
+
+	if _, ok := c.ScopedAPIKey("k4"); !ok {
+		t.Error("Key not found")
+	}
+	if _, ok := c.ScopedAPIKey("main"); ok {
+		t.Error("Full access key returned as scoped key")
+	}
+}
+
+func TestLDAPGroupRole(t *testing.T) {
+	c := LDAPConfiguration{
+		AdminGroups:    []string{"cn=admins,dc=example,dc=com"},
+		OperatorGroups: []string{"cn=ops,dc=example,dc=com"},
+	}
+
+	if role, ok := c.GroupRole([]string{"cn=ops,dc=example,dc=com", "CN=Admins,DC=example,DC=com"}); !ok || role != GUIRoleAdmin {
+		t.Errorf("Got %v, %v, expected admin", role, ok)
+	}
+	if role, ok := c.GroupRole([]string{"cn=ops,dc=example,dc=com"}); !ok || role != GUIRoleOperator {
+		t.Errorf("Got %v, %v, expected operator", role, ok)
+	}
+	if _, ok := c.GroupRole([]string{"cn=others,dc=example,dc=com"}); ok {
+		t.Error("Unexpected role for unmapped group")
+	}
+}
+
 func TestDuplicateDevices(t *testing.T) {
 	// Duplicate devices should be removed
 
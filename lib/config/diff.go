@@ -0,0 +1,233 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// A Change is a difference between two configurations. The path is made of
+// the JSON field names, with list elements identified by their ID or name
+// where they have one, e.g. "folders[abcd-1234].devices". From is nil for
+// additions and To for removals.
+type Change struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+const redactedValue = "<redacted>"
+
+// secretFields are the JSON fields whose values are not shown in diffs.
+var secretFields = []string{
+	"apiKey",
+	"clientSecret",
+	"encryptionPassword",
+	"key",
+	"password",
+	"totpRecoveryCodes",
+	"totpSecret",
+}
+
+// listIdentities are the JSON fields identifying elements of lists, in
+// order of preference.
+var listIdentities = []string{"id", "deviceID", "name"}
+
+// Diff returns the differences between the two configurations, in the
+// order of the fields. Values of credentials are redacted.
+func Diff(from, to Configuration) []Change {
+	var changes []Change
+	diffValues("", "", jsonValue(from), jsonValue(to), &changes)
+	return changes
+}
+
+func jsonValue(cfg Configuration) any {
+	bs, err := json.Marshal(cfg)
+	if err != nil {
+		panic("bug: configuration can't be marshalled: " + err.Error())
+	}
+	var v any
+	_ = json.Unmarshal(bs, &v) // can't fail on what we just marshalled
+	return v
+}
+
+func diffValues(path, field string, from, to any, changes *[]Change) {
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	if from == nil || to == nil {
+		*changes = append(*changes, Change{Path: path, From: redact(field, from), To: redact(field, to)})
+		return
+	}
+
+	switch fromV := from.(type) {
+	case map[string]any:
+		toV, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(fromV)+len(toV))
+		for k := range fromV {
+			keys = append(keys, k)
+		}
+		for k := range toV {
+			if _, ok := fromV[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fv, tv := fromV[k], toV[k]
+			if isEmptyJSON(fv) && isEmptyJSON(tv) {
+				// Nil and empty lists and objects are the same
+				// thing here.
+				continue
+			}
+			fv, tv = emptyAsType(fv, tv), emptyAsType(tv, fv)
+			diffValues(joinPath(path, k), k, fv, tv, changes)
+		}
+		return
+
+	case []any:
+		toV, ok := to.([]any)
+		if !ok {
+			break
+		}
+		if id, ok := listIdentity(fromV, toV); ok && !isSecret(field) {
+			diffLists(path, field, id, fromV, toV, changes)
+			return
+		}
+	}
+
+	*changes = append(*changes, Change{Path: path, From: redact(field, from), To: redact(field, to)})
+}
+
+// diffLists compares lists of objects identified by the given field,
+// matching the elements by it.
+func diffLists(path, field, id string, from, to []any, changes *[]Change) {
+	elemPath := func(v any) string {
+		return fmt.Sprintf("%s[%v]", path, v.(map[string]any)[id])
+	}
+	toByID := make(map[any]any, len(to))
+	for _, v := range to {
+		toByID[v.(map[string]any)[id]] = v
+	}
+	fromIDs := make(map[any]bool, len(from))
+	for _, v := range from {
+		key := v.(map[string]any)[id]
+		fromIDs[key] = true
+		diffValues(elemPath(v), field, v, toByID[key], changes)
+	}
+	for _, v := range to {
+		if !fromIDs[v.(map[string]any)[id]] {
+			diffValues(elemPath(v), field, nil, v, changes)
+		}
+	}
+}
+
+// listIdentity returns the field that identifies the elements of both
+// lists, if they are all objects with unique values of one.
+func listIdentity(lists ...[]any) (string, bool) {
+	for _, id := range listIdentities {
+		if identifiesAll(id, lists) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func identifiesAll(id string, lists [][]any) bool {
+	for _, list := range lists {
+		seen := make(map[any]bool, len(list))
+		for _, v := range list {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return false
+			}
+			key, ok := m[id]
+			if !ok || seen[key] {
+				return false
+			}
+			if _, ok := key.(string); !ok {
+				return false
+			}
+			seen[key] = true
+		}
+	}
+	return true
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func isSecret(field string) bool {
+	return slices.Contains(secretFields, field)
+}
+
+// redact returns the value with the values of secret fields replaced.
+func redact(field string, v any) any {
+	if v == nil {
+		return nil
+	}
+	if isSecret(field) {
+		if v == "" || isEmptyJSON(v) {
+			return v
+		}
+		return redactedValue
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, fv := range v {
+			m[k] = redact(k, fv)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, ev := range v {
+			l[i] = redact(field, ev)
+		}
+		return l
+	}
+	return v
+}
+
+// isEmptyJSON returns true for null, and empty lists and objects.
+func isEmptyJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// emptyAsType returns an empty list or object for null, when the other
+// value is a list or an object, so that they are compared element by
+// element.
+func emptyAsType(v, other any) any {
+	if v != nil {
+		return v
+	}
+	switch other.(type) {
+	case []any:
+		return []any{}
+	case map[string]any:
+		return map[string]any{}
+	}
+	return nil
+}
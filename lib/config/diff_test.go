@@ -0,0 +1,81 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	from := Configuration{
+		Folders: []FolderConfiguration{
+			{ID: "a", Path: "/a"},
+			{ID: "b", Path: "/b"},
+		},
+		GUI: GUIConfiguration{User: "user", Password: "secret"},
+	}
+	to := from.Copy()
+	to.Folders[0].Path = "/aa"
+	to.Folders = append(to.Folders[:1], FolderConfiguration{ID: "c", Path: "/c"})
+	to.GUI.Password = "other secret"
+	to.Options.MaxSendKbps = 100
+
+	changes := Diff(from, to)
+	byPath := make(map[string]Change)
+	for _, c := range changes {
+		byPath[c.Path] = c
+	}
+
+	if c, ok := byPath["folders[a].path"]; !ok || c.From != "/a" || c.To != "/aa" {
+		t.Errorf("Folder path change: got %+v", c)
+	}
+	if c, ok := byPath["folders[b]"]; !ok || c.From == nil || c.To != nil {
+		t.Errorf("Folder removal: got %+v", c)
+	}
+	if c, ok := byPath["folders[c]"]; !ok || c.From != nil || c.To == nil {
+		t.Errorf("Folder addition: got %+v", c)
+	}
+	if c, ok := byPath["options.maxSendKbps"]; !ok || c.From != 0.0 || c.To != 100.0 {
+		t.Errorf("Rate limit change: got %+v", c)
+	}
+	if c, ok := byPath["gui.password"]; !ok || c.From != redactedValue || c.To != redactedValue {
+		t.Errorf("Password change: got %+v", c)
+	}
+	if len(changes) != 5 {
+		t.Errorf("Got %d changes, expected 5: %+v", len(changes), changes)
+	}
+
+	if changes := Diff(from, from.Copy()); len(changes) != 0 {
+		t.Errorf("Unexpected changes between equal configurations: %+v", changes)
+	}
+}
+
+func TestDiffRedactsAdditions(t *testing.T) {
+	from := Configuration{}
+	to := Configuration{
+		GUI: GUIConfiguration{
+			APIKeys: []GUIAPIKey{{Name: "backup", Key: "abc123"}},
+		},
+	}
+
+	changes := Diff(from, to)
+	expected := []Change{{
+		Path: "gui.apiKeys[backup]",
+		From: nil,
+		To: map[string]any{
+			"name":    "backup",
+			"key":     redactedValue,
+			"scopes":  nil,
+			"folders": nil,
+			"expires": "0001-01-01T00:00:00Z",
+		},
+	}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Got %+v, expected %+v", changes, expected)
+	}
+}
@@ -120,6 +120,16 @@ func (f FolderConfiguration) Copy() FolderConfiguration {
 	return c
 }
 
+// Redacted returns a copy of the folder configuration without the
+// encryption passwords of its devices.
+func (f FolderConfiguration) Redacted() FolderConfiguration {
+	c := f.Copy()
+	for i := range c.Devices {
+		c.Devices[i].EncryptionPassword = ""
+	}
+	return c
+}
+
 // Filesystem creates a filesystem for the path and options of this folder.
 // The fset parameter may be nil, in which case no mtime handling on top of
 // the filesystem is provided.
@@ -0,0 +1,92 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+// APIKeyScope is a part of the API a scoped API key gives access to.
+type APIKeyScope int32
+
+const (
+	APIKeyScopeUnknown APIKeyScope = 0
+	// Everything a GUI viewer can see.
+	APIKeyScopeRead APIKeyScope = 1
+	// Looking at and operating on the folders listed with the key.
+	APIKeyScopeFolder APIKeyScope = 2
+	// The event streams.
+	APIKeyScopeEvents APIKeyScope = 3
+	// The Prometheus metrics.
+	APIKeyScopeMetrics APIKeyScope = 4
+)
+
+func (t APIKeyScope) String() string {
+	switch t {
+	case APIKeyScopeRead:
+		return "read"
+	case APIKeyScopeFolder:
+		return "folder"
+	case APIKeyScopeEvents:
+		return "events"
+	case APIKeyScopeMetrics:
+		return "metrics"
+	default:
+		return "unknown"
+	}
+}
+
+func (t APIKeyScope) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *APIKeyScope) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "read":
+		*t = APIKeyScopeRead
+	case "folder":
+		*t = APIKeyScopeFolder
+	case "events":
+		*t = APIKeyScopeEvents
+	case "metrics":
+		*t = APIKeyScopeMetrics
+	default:
+		*t = APIKeyScopeUnknown
+	}
+	return nil
+}
+
+// GUIAPIKey is a named API key in addition to GUIConfiguration.APIKey,
+// which gives access to the given scopes only and may expire.
+type GUIAPIKey struct {
+	Name    string        `json:"name" xml:"name,attr"`
+	Key     string        `json:"key" xml:"key"`
+	Scopes  []APIKeyScope `json:"scopes" xml:"scope"`
+	Folders []string      `json:"folders" xml:"folder"` // for APIKeyScopeFolder
+	Expires time.Time     `json:"expires" xml:"expires,omitempty"`
+}
+
+func (k GUIAPIKey) Copy() GUIAPIKey {
+	k.Scopes = slices.Clone(k.Scopes)
+	k.Folders = slices.Clone(k.Folders)
+	return k
+}
+
+// Expired returns true if the key has an expiry time that has passed.
+func (k GUIAPIKey) Expired(now time.Time) bool {
+	return !k.Expires.IsZero() && !now.Before(k.Expires)
+}
+
+func (k GUIAPIKey) HasScope(scope APIKeyScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// HasFolder returns true if the key is scoped to the given folder.
+func (k GUIAPIKey) HasFolder(folder string) bool {
+	return k.HasScope(APIKeyScopeFolder) && slices.Contains(k.Folders, folder)
+}
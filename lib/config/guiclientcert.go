@@ -0,0 +1,76 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ClientCertMode is how TLS client certificates are used to authenticate
+// GUI and API requests.
+type ClientCertMode int32
+
+const (
+	// Client certificates are not asked for.
+	ClientCertModeOff ClientCertMode = 0
+	// A valid client certificate authenticates the request by itself;
+	// without one the other authentication methods apply.
+	ClientCertModeSufficient ClientCertMode = 1
+	// A valid client certificate is required, in addition to the other
+	// authentication methods.
+	ClientCertModeRequired ClientCertMode = 2
+)
+
+func (t ClientCertMode) String() string {
+	switch t {
+	case ClientCertModeOff:
+		return "off"
+	case ClientCertModeSufficient:
+		return "sufficient"
+	case ClientCertModeRequired:
+		return "required"
+	default:
+		return "unknown"
+	}
+}
+
+func (t ClientCertMode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ClientCertMode) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "sufficient":
+		*t = ClientCertModeSufficient
+	case "required":
+		*t = ClientCertModeRequired
+	default:
+		*t = ClientCertModeOff
+	}
+	return nil
+}
+
+// GUIClientCert maps client certificates to a GUI user. A certificate
+// matches either by its pinned SHA-256 fingerprint, regardless of issuer,
+// or by its subject when it is signed by the configured client CA.
+type GUIClientCert struct {
+	Subject     string `json:"subject" xml:"subject,attr,omitempty"`         // common name or full distinguished name
+	Fingerprint string `json:"fingerprint" xml:"fingerprint,attr,omitempty"` // hex, optionally colon separated
+	User        string `json:"user" xml:"user,attr"`
+}
+
+// MatchesFingerprint returns true if the certificate with the given DER
+// encoding has the pinned fingerprint.
+func (c GUIClientCert) MatchesFingerprint(der []byte) bool {
+	if c.Fingerprint == "" {
+		return false
+	}
+	sum := sha256.Sum256(der)
+	return strings.EqualFold(strings.ReplaceAll(c.Fingerprint, ":", ""), hex.EncodeToString(sum[:]))
+}
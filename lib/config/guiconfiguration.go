@@ -7,9 +7,11 @@
 package config
 
 import (
+	"log/slog"
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,27 +21,142 @@ import (
 )
 
 type GUIConfiguration struct {
-	Enabled                   bool     `json:"enabled" xml:"enabled,attr" default:"true"`
-	RawAddress                string   `json:"address" xml:"address" default:"127.0.0.1:8384"`
-	RawUnixSocketPermissions  string   `json:"unixSocketPermissions" xml:"unixSocketPermissions,omitempty"`
-	User                      string   `json:"user" xml:"user,omitempty"`
-	Password                  string   `json:"password" xml:"password,omitempty"`
-	AuthMode                  AuthMode `json:"authMode" xml:"authMode,omitempty"`
-	MetricsWithoutAuth        bool     `json:"metricsWithoutAuth" xml:"metricsWithoutAuth" default:"false"`
-	RawUseTLS                 bool     `json:"useTLS" xml:"tls,attr"`
-	APIKey                    string   `json:"apiKey" xml:"apikey,omitempty"`
-	InsecureAdminAccess       bool     `json:"insecureAdminAccess" xml:"insecureAdminAccess,omitempty"`
-	Theme                     string   `json:"theme" xml:"theme" default:"default"`
-	InsecureSkipHostCheck     bool     `json:"insecureSkipHostcheck" xml:"insecureSkipHostcheck,omitempty"`
-	InsecureAllowFrameLoading bool     `json:"insecureAllowFrameLoading" xml:"insecureAllowFrameLoading,omitempty"`
-	SendBasicAuthPrompt       bool     `json:"sendBasicAuthPrompt" xml:"sendBasicAuthPrompt,attr"`
-	SessionCookieDurationS    int      `json:"sessionCookieDurationS" xml:"sessionCookieDurationS,omitempty" default:"604800"`
-	SessionCookiePath         string   `json:"sessionCookiePath" xml:"sessionCookiePath,omitempty" default:"/"`
+	Enabled                   bool            `json:"enabled" xml:"enabled,attr" default:"true"`
+	RawAddress                string          `json:"address" xml:"address" default:"127.0.0.1:8384"`
+	RawUnixSocketPermissions  string          `json:"unixSocketPermissions" xml:"unixSocketPermissions,omitempty"`
+	User                      string          `json:"user" xml:"user,omitempty"`
+	Password                  string          `json:"password" xml:"password,omitempty"`
+	AuthMode                  AuthMode        `json:"authMode" xml:"authMode,omitempty"`
+	MetricsWithoutAuth        bool            `json:"metricsWithoutAuth" xml:"metricsWithoutAuth" default:"false"`
+	RawUseTLS                 bool            `json:"useTLS" xml:"tls,attr"`
+	APIKey                    string          `json:"apiKey" xml:"apikey,omitempty"`
+	InsecureAdminAccess       bool            `json:"insecureAdminAccess" xml:"insecureAdminAccess,omitempty"`
+	Theme                     string          `json:"theme" xml:"theme" default:"default"`
+	InsecureSkipHostCheck     bool            `json:"insecureSkipHostcheck" xml:"insecureSkipHostcheck,omitempty"`
+	InsecureAllowFrameLoading bool            `json:"insecureAllowFrameLoading" xml:"insecureAllowFrameLoading,omitempty"`
+	SendBasicAuthPrompt       bool            `json:"sendBasicAuthPrompt" xml:"sendBasicAuthPrompt,attr"`
+	SessionCookieDurationS    int             `json:"sessionCookieDurationS" xml:"sessionCookieDurationS,omitempty" default:"604800"`
+	SessionCookiePath         string          `json:"sessionCookiePath" xml:"sessionCookiePath,omitempty" default:"/"`
+	Users                     []GUIUser       `json:"users" xml:"users>user"`
+	APIKeys                   []GUIAPIKey     `json:"apiKeys" xml:"apiKeys>apiKey"`
+	ClientCertMode            ClientCertMode  `json:"clientCertMode" xml:"clientCertMode,omitempty"`
+	ClientCAFile              string          `json:"clientCAFile" xml:"clientCAFile,omitempty"`
+	ClientCerts               []GUIClientCert `json:"clientCerts" xml:"clientCerts>clientCert"`
+	TOTPSecret                string          `json:"totpSecret" xml:"totpSecret,omitempty"`
+	TOTPRecoveryCodes         []string        `json:"totpRecoveryCodes" xml:"totpRecoveryCode"`
+}
+
+// GUIUser is an additional user of the GUI, with a given role. The user
+// configured in GUIConfiguration.User is always an admin.
+type GUIUser struct {
+	Name     string  `json:"name" xml:"name,attr"`
+	Password string  `json:"password" xml:"password"`
+	Role     GUIRole `json:"role" xml:"role,attr"`
+
+	// Two factor authentication, when enrolled. The recovery codes are
+	// bcrypt hashed.
+	TOTPSecret        string   `json:"totpSecret" xml:"totpSecret,omitempty"`
+	TOTPRecoveryCodes []string `json:"totpRecoveryCodes" xml:"totpRecoveryCode"`
 }
 
 func (c GUIConfiguration) IsAuthEnabled() bool {
 	// This function should match isAuthEnabled() in syncthingController.js
-	return c.AuthMode == AuthModeLDAP || (len(c.User) > 0 && len(c.Password) > 0)
+	return c.AuthMode == AuthModeLDAP || c.AuthMode == AuthModeOIDC || c.HasPasswordUsers() || c.ClientCertMode != ClientCertModeOff
+}
+
+// HasPasswordUsers returns true when there is at least one user with a
+// name and password set.
+func (c GUIConfiguration) HasPasswordUsers() bool {
+	if len(c.User) > 0 && len(c.Password) > 0 {
+		return true
+	}
+	for _, u := range c.Users {
+		if len(u.Name) > 0 && len(u.Password) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthenticateUser returns the role of the named user if the password
+// matches.
+func (c GUIConfiguration) AuthenticateUser(username, password string) (GUIRole, bool) {
+	if username == c.User && c.CompareHashedPassword(password) == nil {
+		return GUIRoleAdmin, true
+	}
+	for _, u := range c.Users {
+		if u.Name == username && u.Password != "" && bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil {
+			return u.Role, true
+		}
+	}
+	return 0, false
+}
+
+// UserRole returns the current role of the named password user, if there
+// is such a user.
+func (c GUIConfiguration) UserRole(username string) (GUIRole, bool) {
+	if username == c.User && c.Password != "" {
+		return GUIRoleAdmin, true
+	}
+	for _, u := range c.Users {
+		if u.Name == username && u.Password != "" {
+			return u.Role, true
+		}
+	}
+	return 0, false
+}
+
+// CertUserRole returns the role of the named user, who need not have a
+// password, for users authenticated by client certificate.
+func (c GUIConfiguration) CertUserRole(username string) (GUIRole, bool) {
+	if username == "" {
+		return 0, false
+	}
+	if username == c.User {
+		return GUIRoleAdmin, true
+	}
+	for _, u := range c.Users {
+		if u.Name == username {
+			return u.Role, true
+		}
+	}
+	return 0, false
+}
+
+// UserTOTP returns the TOTP secret and hashed recovery codes of the named
+// password user. The secret is empty if the user hasn't enrolled.
+func (c GUIConfiguration) UserTOTP(username string) (secret string, recoveryCodes []string, ok bool) {
+	if username == "" {
+		return "", nil, false
+	}
+	if username == c.User {
+		return c.TOTPSecret, c.TOTPRecoveryCodes, true
+	}
+	for _, u := range c.Users {
+		if u.Name == username {
+			return u.TOTPSecret, u.TOTPRecoveryCodes, true
+		}
+	}
+	return "", nil, false
+}
+
+// SetUserTOTP sets the TOTP secret and hashed recovery codes of the named
+// password user, returning false if there is no such user.
+func (c *GUIConfiguration) SetUserTOTP(username, secret string, recoveryCodes []string) bool {
+	if username == "" {
+		return false
+	}
+	if username == c.User {
+		c.TOTPSecret, c.TOTPRecoveryCodes = secret, recoveryCodes
+		return true
+	}
+	for i := range c.Users {
+		if c.Users[i].Name == username {
+			c.Users[i].TOTPSecret, c.Users[i].TOTPRecoveryCodes = secret, recoveryCodes
+			return true
+		}
+	}
+	return false
 }
 
 func (GUIConfiguration) IsOverridden() bool {
@@ -139,19 +256,38 @@ var bcryptExpr = regexp.MustCompile(`^\$2[aby]\$\d+\$.{50,}`)
 // Plaintext passwords are hashed. Returns an error if the password is not
 // valid.
 func (c *GUIConfiguration) SetPassword(password string) error {
-	if bcryptExpr.MatchString(password) {
-		// Already hashed
-		c.Password = password
-		return nil
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
 	}
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	c.Password = hash
+	return nil
+}
+
+// SetPassword takes a bcrypt hash or a plaintext password and stores it.
+// Plaintext passwords are hashed. Returns an error if the password is not
+// valid.
+func (u *GUIUser) SetPassword(password string) error {
+	hash, err := hashPassword(password)
 	if err != nil {
 		return err
 	}
-	c.Password = string(hash)
+	u.Password = hash
 	return nil
 }
 
+func hashPassword(password string) (string, error) {
+	if bcryptExpr.MatchString(password) || IsSecretReference(password) {
+		// Already hashed, or a reference to the hash
+		return password, nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
 // CompareHashedPassword returns nil when the given plaintext password matches the stored hash.
 func (c GUIConfiguration) CompareHashedPassword(password string) error {
 	configPasswordBytes := []byte(c.Password)
@@ -174,6 +310,20 @@ func (c GUIConfiguration) IsValidAPIKey(apiKey string) bool {
 	}
 }
 
+// ScopedAPIKey returns the scoped API key with the given value, if there is
+// one. The key may be expired.
+func (c GUIConfiguration) ScopedAPIKey(apiKey string) (GUIAPIKey, bool) {
+	if apiKey == "" {
+		return GUIAPIKey{}, false
+	}
+	for _, k := range c.APIKeys {
+		if k.Key == apiKey {
+			return k, true
+		}
+	}
+	return GUIAPIKey{}, false
+}
+
 func (c *GUIConfiguration) prepare() {
 	if c.APIKey == "" {
 		c.APIKey = rand.String(32)
@@ -184,8 +334,63 @@ func (c *GUIConfiguration) prepare() {
 		}
 		c.SessionCookiePath = path
 	}
+
+	// User names must be unique, or we couldn't tell which role applies.
+	seen := make(map[string]bool)
+	if c.User != "" {
+		seen[c.User] = true
+	}
+	c.Users = slices.DeleteFunc(c.Users, func(u GUIUser) bool {
+		if u.Name == "" || seen[u.Name] {
+			slog.Warn("Ignoring GUI user with empty or duplicate name", slog.String("username", u.Name))
+			return true
+		}
+		seen[u.Name] = true
+		return false
+	})
+
+	// Scoped API keys are managed by name, and each key value must give
+	// one well defined set of permissions.
+	seenNames := make(map[string]bool)
+	seenKeys := map[string]bool{c.APIKey: true}
+	c.APIKeys = slices.DeleteFunc(c.APIKeys, func(k GUIAPIKey) bool {
+		if k.Name == "" || k.Key == "" || seenNames[k.Name] || seenKeys[k.Key] {
+			slog.Warn("Ignoring API key with empty or duplicate name or key", slog.String("name", k.Name))
+			return true
+		}
+		seenNames[k.Name] = true
+		seenKeys[k.Key] = true
+		return false
+	})
 }
 
 func (c GUIConfiguration) Copy() GUIConfiguration {
+	c.Users = slices.Clone(c.Users)
+	c.ClientCerts = slices.Clone(c.ClientCerts)
+	c.TOTPRecoveryCodes = slices.Clone(c.TOTPRecoveryCodes)
+	for i := range c.Users {
+		c.Users[i].TOTPRecoveryCodes = slices.Clone(c.Users[i].TOTPRecoveryCodes)
+	}
+	c.APIKeys = slices.Clone(c.APIKeys)
+	for i := range c.APIKeys {
+		c.APIKeys[i] = c.APIKeys[i].Copy()
+	}
+	return c
+}
+
+// Redacted returns a copy of the configuration without passwords, API keys
+// and TOTP secrets, for users that may look at but not change the configuration.
+func (c GUIConfiguration) Redacted() GUIConfiguration {
+	c = c.Copy()
+	c.Password = ""
+	c.APIKey = ""
+	c.TOTPSecret, c.TOTPRecoveryCodes = "", nil
+	for i := range c.Users {
+		c.Users[i].Password = ""
+		c.Users[i].TOTPSecret, c.Users[i].TOTPRecoveryCodes = "", nil
+	}
+	for i := range c.APIKeys {
+		c.APIKeys[i].Key = ""
+	}
 	return c
 }
@@ -0,0 +1,50 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// GUIRole is the level of access a GUI user has. Each role includes the
+// permissions of the ones below it.
+type GUIRole int32
+
+const (
+	// Viewers can look at everything but change nothing.
+	GUIRoleViewer GUIRole = 0
+	// Operators can additionally scan, pause and resume, and override or
+	// revert folders.
+	GUIRoleOperator GUIRole = 1
+	// Admins can additionally change the configuration.
+	GUIRoleAdmin GUIRole = 2
+)
+
+func (t GUIRole) String() string {
+	switch t {
+	case GUIRoleViewer:
+		return "viewer"
+	case GUIRoleOperator:
+		return "operator"
+	case GUIRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func (t GUIRole) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *GUIRole) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "operator":
+		*t = GUIRoleOperator
+	case "admin":
+		*t = GUIRoleAdmin
+	default:
+		*t = GUIRoleViewer
+	}
+	return nil
+}
@@ -6,6 +6,11 @@
 
 package config
 
+import (
+	"slices"
+	"strings"
+)
+
 type LDAPConfiguration struct {
 	Address            string        `json:"address" xml:"address,omitempty"`
 	BindDN             string        `json:"bindDN" xml:"bindDN,omitempty"`
@@ -13,8 +18,47 @@ type LDAPConfiguration struct {
 	InsecureSkipVerify bool          `json:"insecureSkipVerify" xml:"insecureSkipVerify,omitempty" default:"false"`
 	SearchBaseDN       string        `json:"searchBaseDN" xml:"searchBaseDN,omitempty"`
 	SearchFilter       string        `json:"searchFilter" xml:"searchFilter,omitempty"`
+	GroupAttribute     string        `json:"groupAttribute" xml:"groupAttribute,omitempty" default:"memberOf"`
+	AdminGroups        []string      `json:"adminGroups" xml:"adminGroup"`
+	OperatorGroups     []string      `json:"operatorGroups" xml:"operatorGroup"`
+	ViewerGroups       []string      `json:"viewerGroups" xml:"viewerGroup"`
 }
 
 func (c LDAPConfiguration) Copy() LDAPConfiguration {
+	c.AdminGroups = slices.Clone(c.AdminGroups)
+	c.OperatorGroups = slices.Clone(c.OperatorGroups)
+	c.ViewerGroups = slices.Clone(c.ViewerGroups)
 	return c
 }
+
+// HasGroupRoles returns true when roles are assigned based on group
+// membership. Otherwise all LDAP users are admins.
+func (c LDAPConfiguration) HasGroupRoles() bool {
+	return len(c.AdminGroups) > 0 || len(c.OperatorGroups) > 0 || len(c.ViewerGroups) > 0
+}
+
+// GroupRole returns the highest role given by membership of the given
+// groups. Group names are typically distinguished names and compared
+// case insensitively.
+func (c LDAPConfiguration) GroupRole(groups []string) (GUIRole, bool) {
+	member := func(roleGroups []string) bool {
+		for _, rg := range roleGroups {
+			for _, g := range groups {
+				if strings.EqualFold(rg, g) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch {
+	case member(c.AdminGroups):
+		return GUIRoleAdmin, true
+	case member(c.OperatorGroups):
+		return GUIRoleOperator, true
+	case member(c.ViewerGroups):
+		return GUIRoleViewer, true
+	default:
+		return 0, false
+	}
+}
@@ -10,6 +10,16 @@ import (
 )
 
 type Wrapper struct {
+	AutoAcceptRulesStub        func() []config.AutoAcceptRule
+	autoAcceptRulesMutex       sync.RWMutex
+	autoAcceptRulesArgsForCall []struct {
+	}
+	autoAcceptRulesReturns struct {
+		result1 []config.AutoAcceptRule
+	}
+	autoAcceptRulesReturnsOnCall map[int]struct {
+		result1 []config.AutoAcceptRule
+	}
 	ConfigPathStub        func() string
 	configPathMutex       sync.RWMutex
 	configPathArgsForCall []struct {
@@ -20,6 +30,16 @@ type Wrapper struct {
 	configPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	CopyWithSecretReferencesStub        func() config.Configuration
+	copyWithSecretReferencesMutex       sync.RWMutex
+	copyWithSecretReferencesArgsForCall []struct {
+	}
+	copyWithSecretReferencesReturns struct {
+		result1 config.Configuration
+	}
+	copyWithSecretReferencesReturnsOnCall map[int]struct {
+		result1 config.Configuration
+	}
 	DefaultDeviceStub        func() config.DeviceConfiguration
 	defaultDeviceMutex       sync.RWMutex
 	defaultDeviceArgsForCall []struct {
@@ -180,6 +200,16 @@ type Wrapper struct {
 	lDAPReturnsOnCall map[int]struct {
 		result1 config.LDAPConfiguration
 	}
+	LockedPathsStub        func() []string
+	lockedPathsMutex       sync.RWMutex
+	lockedPathsArgsForCall []struct {
+	}
+	lockedPathsReturns struct {
+		result1 []string
+	}
+	lockedPathsReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	ModifyStub        func(config.ModifyFunction) (config.Waiter, error)
 	modifyMutex       sync.RWMutex
 	modifyArgsForCall []struct {
@@ -193,6 +223,20 @@ type Wrapper struct {
 		result1 config.Waiter
 		result2 error
 	}
+	ModifyAsStub        func(config.Author, config.ModifyFunction) (config.Waiter, error)
+	modifyAsMutex       sync.RWMutex
+	modifyAsArgsForCall []struct {
+		arg1 config.Author
+		arg2 config.ModifyFunction
+	}
+	modifyAsReturns struct {
+		result1 config.Waiter
+		result2 error
+	}
+	modifyAsReturnsOnCall map[int]struct {
+		result1 config.Waiter
+		result2 error
+	}
 	MyIDStub        func() protocol.DeviceID
 	myIDMutex       sync.RWMutex
 	myIDArgsForCall []struct {
@@ -203,6 +247,16 @@ type Wrapper struct {
 	myIDReturnsOnCall map[int]struct {
 		result1 protocol.DeviceID
 	}
+	OIDCStub        func() config.OIDCConfiguration
+	oIDCMutex       sync.RWMutex
+	oIDCArgsForCall []struct {
+	}
+	oIDCReturns struct {
+		result1 config.OIDCConfiguration
+	}
+	oIDCReturnsOnCall map[int]struct {
+		result1 config.OIDCConfiguration
+	}
 	OptionsStub        func() config.OptionsConfiguration
 	optionsMutex       sync.RWMutex
 	optionsArgsForCall []struct {
@@ -296,10 +350,75 @@ type Wrapper struct {
 	unsubscribeArgsForCall []struct {
 		arg1 config.Committer
 	}
+	ValidateAsStub        func(config.Author, config.Configuration) error
+	validateAsMutex       sync.RWMutex
+	validateAsArgsForCall []struct {
+		arg1 config.Author
+		arg2 config.Configuration
+	}
+	validateAsReturns struct {
+		result1 error
+	}
+	validateAsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *Wrapper) AutoAcceptRules() []config.AutoAcceptRule {
+	fake.autoAcceptRulesMutex.Lock()
+	ret, specificReturn := fake.autoAcceptRulesReturnsOnCall[len(fake.autoAcceptRulesArgsForCall)]
+	fake.autoAcceptRulesArgsForCall = append(fake.autoAcceptRulesArgsForCall, struct {
+	}{})
+	stub := fake.AutoAcceptRulesStub
+	fakeReturns := fake.autoAcceptRulesReturns
+	fake.recordInvocation("AutoAcceptRules", []interface{}{})
+	fake.autoAcceptRulesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Wrapper) AutoAcceptRulesCallCount() int {
+	fake.autoAcceptRulesMutex.RLock()
+	defer fake.autoAcceptRulesMutex.RUnlock()
+	return len(fake.autoAcceptRulesArgsForCall)
+}
+
+func (fake *Wrapper) AutoAcceptRulesCalls(stub func() []config.AutoAcceptRule) {
+	fake.autoAcceptRulesMutex.Lock()
+	defer fake.autoAcceptRulesMutex.Unlock()
+	fake.AutoAcceptRulesStub = stub
+}
+
+func (fake *Wrapper) AutoAcceptRulesReturns(result1 []config.AutoAcceptRule) {
+	fake.autoAcceptRulesMutex.Lock()
+	defer fake.autoAcceptRulesMutex.Unlock()
+	fake.AutoAcceptRulesStub = nil
+	fake.autoAcceptRulesReturns = struct {
+		result1 []config.AutoAcceptRule
+	}{result1}
+}
+
+func (fake *Wrapper) AutoAcceptRulesReturnsOnCall(i int, result1 []config.AutoAcceptRule) {
+	fake.autoAcceptRulesMutex.Lock()
+	defer fake.autoAcceptRulesMutex.Unlock()
+	fake.AutoAcceptRulesStub = nil
+	if fake.autoAcceptRulesReturnsOnCall == nil {
+		fake.autoAcceptRulesReturnsOnCall = make(map[int]struct {
+			result1 []config.AutoAcceptRule
+		})
+	}
+	fake.autoAcceptRulesReturnsOnCall[i] = struct {
+		result1 []config.AutoAcceptRule
+	}{result1}
+}
+
 func (fake *Wrapper) ConfigPath() string {
 	fake.configPathMutex.Lock()
 	ret, specificReturn := fake.configPathReturnsOnCall[len(fake.configPathArgsForCall)]
@@ -353,6 +472,59 @@ func (fake *Wrapper) ConfigPathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *Wrapper) CopyWithSecretReferences() config.Configuration {
+	fake.copyWithSecretReferencesMutex.Lock()
+	ret, specificReturn := fake.copyWithSecretReferencesReturnsOnCall[len(fake.copyWithSecretReferencesArgsForCall)]
+	fake.copyWithSecretReferencesArgsForCall = append(fake.copyWithSecretReferencesArgsForCall, struct {
+	}{})
+	stub := fake.CopyWithSecretReferencesStub
+	fakeReturns := fake.copyWithSecretReferencesReturns
+	fake.recordInvocation("CopyWithSecretReferences", []interface{}{})
+	fake.copyWithSecretReferencesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Wrapper) CopyWithSecretReferencesCallCount() int {
+	fake.copyWithSecretReferencesMutex.RLock()
+	defer fake.copyWithSecretReferencesMutex.RUnlock()
+	return len(fake.copyWithSecretReferencesArgsForCall)
+}
+
+func (fake *Wrapper) CopyWithSecretReferencesCalls(stub func() config.Configuration) {
+	fake.copyWithSecretReferencesMutex.Lock()
+	defer fake.copyWithSecretReferencesMutex.Unlock()
+	fake.CopyWithSecretReferencesStub = stub
+}
+
+func (fake *Wrapper) CopyWithSecretReferencesReturns(result1 config.Configuration) {
+	fake.copyWithSecretReferencesMutex.Lock()
+	defer fake.copyWithSecretReferencesMutex.Unlock()
+	fake.CopyWithSecretReferencesStub = nil
+	fake.copyWithSecretReferencesReturns = struct {
+		result1 config.Configuration
+	}{result1}
+}
+
+func (fake *Wrapper) CopyWithSecretReferencesReturnsOnCall(i int, result1 config.Configuration) {
+	fake.copyWithSecretReferencesMutex.Lock()
+	defer fake.copyWithSecretReferencesMutex.Unlock()
+	fake.CopyWithSecretReferencesStub = nil
+	if fake.copyWithSecretReferencesReturnsOnCall == nil {
+		fake.copyWithSecretReferencesReturnsOnCall = make(map[int]struct {
+			result1 config.Configuration
+		})
+	}
+	fake.copyWithSecretReferencesReturnsOnCall[i] = struct {
+		result1 config.Configuration
+	}{result1}
+}
+
 func (fake *Wrapper) DefaultDevice() config.DeviceConfiguration {
 	fake.defaultDeviceMutex.Lock()
 	ret, specificReturn := fake.defaultDeviceReturnsOnCall[len(fake.defaultDeviceArgsForCall)]
@@ -1195,6 +1367,59 @@ func (fake *Wrapper) LDAPReturnsOnCall(i int, result1 config.LDAPConfiguration)
 	}{result1}
 }
 
+func (fake *Wrapper) LockedPaths() []string {
+	fake.lockedPathsMutex.Lock()
+	ret, specificReturn := fake.lockedPathsReturnsOnCall[len(fake.lockedPathsArgsForCall)]
+	fake.lockedPathsArgsForCall = append(fake.lockedPathsArgsForCall, struct {
+	}{})
+	stub := fake.LockedPathsStub
+	fakeReturns := fake.lockedPathsReturns
+	fake.recordInvocation("LockedPaths", []interface{}{})
+	fake.lockedPathsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Wrapper) LockedPathsCallCount() int {
+	fake.lockedPathsMutex.RLock()
+	defer fake.lockedPathsMutex.RUnlock()
+	return len(fake.lockedPathsArgsForCall)
+}
+
+func (fake *Wrapper) LockedPathsCalls(stub func() []string) {
+	fake.lockedPathsMutex.Lock()
+	defer fake.lockedPathsMutex.Unlock()
+	fake.LockedPathsStub = stub
+}
+
+func (fake *Wrapper) LockedPathsReturns(result1 []string) {
+	fake.lockedPathsMutex.Lock()
+	defer fake.lockedPathsMutex.Unlock()
+	fake.LockedPathsStub = nil
+	fake.lockedPathsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *Wrapper) LockedPathsReturnsOnCall(i int, result1 []string) {
+	fake.lockedPathsMutex.Lock()
+	defer fake.lockedPathsMutex.Unlock()
+	fake.LockedPathsStub = nil
+	if fake.lockedPathsReturnsOnCall == nil {
+		fake.lockedPathsReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.lockedPathsReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *Wrapper) Modify(arg1 config.ModifyFunction) (config.Waiter, error) {
 	fake.modifyMutex.Lock()
 	ret, specificReturn := fake.modifyReturnsOnCall[len(fake.modifyArgsForCall)]
@@ -1259,6 +1484,71 @@ func (fake *Wrapper) ModifyReturnsOnCall(i int, result1 config.Waiter, result2 e
 	}{result1, result2}
 }
 
+func (fake *Wrapper) ModifyAs(arg1 config.Author, arg2 config.ModifyFunction) (config.Waiter, error) {
+	fake.modifyAsMutex.Lock()
+	ret, specificReturn := fake.modifyAsReturnsOnCall[len(fake.modifyAsArgsForCall)]
+	fake.modifyAsArgsForCall = append(fake.modifyAsArgsForCall, struct {
+		arg1 config.Author
+		arg2 config.ModifyFunction
+	}{arg1, arg2})
+	stub := fake.ModifyAsStub
+	fakeReturns := fake.modifyAsReturns
+	fake.recordInvocation("ModifyAs", []interface{}{arg1, arg2})
+	fake.modifyAsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Wrapper) ModifyAsCallCount() int {
+	fake.modifyAsMutex.RLock()
+	defer fake.modifyAsMutex.RUnlock()
+	return len(fake.modifyAsArgsForCall)
+}
+
+func (fake *Wrapper) ModifyAsCalls(stub func(config.Author, config.ModifyFunction) (config.Waiter, error)) {
+	fake.modifyAsMutex.Lock()
+	defer fake.modifyAsMutex.Unlock()
+	fake.ModifyAsStub = stub
+}
+
+func (fake *Wrapper) ModifyAsArgsForCall(i int) (config.Author, config.ModifyFunction) {
+	fake.modifyAsMutex.RLock()
+	defer fake.modifyAsMutex.RUnlock()
+	argsForCall := fake.modifyAsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Wrapper) ModifyAsReturns(result1 config.Waiter, result2 error) {
+	fake.modifyAsMutex.Lock()
+	defer fake.modifyAsMutex.Unlock()
+	fake.ModifyAsStub = nil
+	fake.modifyAsReturns = struct {
+		result1 config.Waiter
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Wrapper) ModifyAsReturnsOnCall(i int, result1 config.Waiter, result2 error) {
+	fake.modifyAsMutex.Lock()
+	defer fake.modifyAsMutex.Unlock()
+	fake.ModifyAsStub = nil
+	if fake.modifyAsReturnsOnCall == nil {
+		fake.modifyAsReturnsOnCall = make(map[int]struct {
+			result1 config.Waiter
+			result2 error
+		})
+	}
+	fake.modifyAsReturnsOnCall[i] = struct {
+		result1 config.Waiter
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Wrapper) MyID() protocol.DeviceID {
 	fake.myIDMutex.Lock()
 	ret, specificReturn := fake.myIDReturnsOnCall[len(fake.myIDArgsForCall)]
@@ -1312,6 +1602,59 @@ func (fake *Wrapper) MyIDReturnsOnCall(i int, result1 protocol.DeviceID) {
 	}{result1}
 }
 
+func (fake *Wrapper) OIDC() config.OIDCConfiguration {
+	fake.oIDCMutex.Lock()
+	ret, specificReturn := fake.oIDCReturnsOnCall[len(fake.oIDCArgsForCall)]
+	fake.oIDCArgsForCall = append(fake.oIDCArgsForCall, struct {
+	}{})
+	stub := fake.OIDCStub
+	fakeReturns := fake.oIDCReturns
+	fake.recordInvocation("OIDC", []interface{}{})
+	fake.oIDCMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Wrapper) OIDCCallCount() int {
+	fake.oIDCMutex.RLock()
+	defer fake.oIDCMutex.RUnlock()
+	return len(fake.oIDCArgsForCall)
+}
+
+func (fake *Wrapper) OIDCCalls(stub func() config.OIDCConfiguration) {
+	fake.oIDCMutex.Lock()
+	defer fake.oIDCMutex.Unlock()
+	fake.OIDCStub = stub
+}
+
+func (fake *Wrapper) OIDCReturns(result1 config.OIDCConfiguration) {
+	fake.oIDCMutex.Lock()
+	defer fake.oIDCMutex.Unlock()
+	fake.OIDCStub = nil
+	fake.oIDCReturns = struct {
+		result1 config.OIDCConfiguration
+	}{result1}
+}
+
+func (fake *Wrapper) OIDCReturnsOnCall(i int, result1 config.OIDCConfiguration) {
+	fake.oIDCMutex.Lock()
+	defer fake.oIDCMutex.Unlock()
+	fake.OIDCStub = nil
+	if fake.oIDCReturnsOnCall == nil {
+		fake.oIDCReturnsOnCall = make(map[int]struct {
+			result1 config.OIDCConfiguration
+		})
+	}
+	fake.oIDCReturnsOnCall[i] = struct {
+		result1 config.OIDCConfiguration
+	}{result1}
+}
+
 func (fake *Wrapper) Options() config.OptionsConfiguration {
 	fake.optionsMutex.Lock()
 	ret, specificReturn := fake.optionsReturnsOnCall[len(fake.optionsArgsForCall)]
@@ -1806,6 +2149,68 @@ func (fake *Wrapper) UnsubscribeArgsForCall(i int) config.Committer {
 	return argsForCall.arg1
 }
 
+func (fake *Wrapper) ValidateAs(arg1 config.Author, arg2 config.Configuration) error {
+	fake.validateAsMutex.Lock()
+	ret, specificReturn := fake.validateAsReturnsOnCall[len(fake.validateAsArgsForCall)]
+	fake.validateAsArgsForCall = append(fake.validateAsArgsForCall, struct {
+		arg1 config.Author
+		arg2 config.Configuration
+	}{arg1, arg2})
+	stub := fake.ValidateAsStub
+	fakeReturns := fake.validateAsReturns
+	fake.recordInvocation("ValidateAs", []interface{}{arg1, arg2})
+	fake.validateAsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Wrapper) ValidateAsCallCount() int {
+	fake.validateAsMutex.RLock()
+	defer fake.validateAsMutex.RUnlock()
+	return len(fake.validateAsArgsForCall)
+}
+
+func (fake *Wrapper) ValidateAsCalls(stub func(config.Author, config.Configuration) error) {
+	fake.validateAsMutex.Lock()
+	defer fake.validateAsMutex.Unlock()
+	fake.ValidateAsStub = stub
+}
+
+func (fake *Wrapper) ValidateAsArgsForCall(i int) (config.Author, config.Configuration) {
+	fake.validateAsMutex.RLock()
+	defer fake.validateAsMutex.RUnlock()
+	argsForCall := fake.validateAsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Wrapper) ValidateAsReturns(result1 error) {
+	fake.validateAsMutex.Lock()
+	defer fake.validateAsMutex.Unlock()
+	fake.ValidateAsStub = nil
+	fake.validateAsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Wrapper) ValidateAsReturnsOnCall(i int, result1 error) {
+	fake.validateAsMutex.Lock()
+	defer fake.validateAsMutex.Unlock()
+	fake.ValidateAsStub = nil
+	if fake.validateAsReturnsOnCall == nil {
+		fake.validateAsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.validateAsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Wrapper) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -0,0 +1,43 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import "slices"
+
+type OIDCConfiguration struct {
+	Issuer        string   `json:"issuer" xml:"issuer,omitempty"`
+	ClientID      string   `json:"clientID" xml:"clientID,omitempty"`
+	ClientSecret  string   `json:"clientSecret" xml:"clientSecret,omitempty"`
+	RedirectURL   string   `json:"redirectURL" xml:"redirectURL,omitempty"`
+	Scopes        []string `json:"scopes" xml:"scope"`
+	UsernameClaim string   `json:"usernameClaim" xml:"usernameClaim,omitempty" default:"preferred_username"`
+	GroupsClaim   string   `json:"groupsClaim" xml:"groupsClaim,omitempty" default:"groups"`
+	AllowedGroups []string `json:"allowedGroups" xml:"allowedGroup"`
+}
+
+func (c OIDCConfiguration) Copy() OIDCConfiguration {
+	c.Scopes = slices.Clone(c.Scopes)
+	c.AllowedGroups = slices.Clone(c.AllowedGroups)
+	return c
+}
+
+// RequestedScopes returns the scopes to request from the identity
+// provider. The "openid" scope is always included, as without it we
+// would not get an ID token; when nothing else is configured we also ask
+// for the standard profile and email claims.
+func (c OIDCConfiguration) RequestedScopes() []string {
+	scopes := []string{"openid"}
+	for _, scope := range c.Scopes {
+		if scope != "" && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 1 {
+		scopes = append(scopes, "profile", "email")
+	}
+	return scopes
+}
@@ -81,6 +81,11 @@ type OptionsConfiguration struct {
 	ConnectionPriorityQUICWAN          int `json:"connectionPriorityQuicWan" xml:"connectionPriorityQuicWan" default:"40"`
 	ConnectionPriorityRelay            int `json:"connectionPriorityRelay" xml:"connectionPriorityRelay" default:"50"`
 	ConnectionPriorityUpgradeThreshold int `json:"connectionPriorityUpgradeThreshold" xml:"connectionPriorityUpgradeThreshold" default:"0"`
+	// How long to keep the entries of the change journal of each folder,
+	// and how many of them at most; zero meaning forever or no limit. A
+	// negative retention disables the journal.
+	ChangeJournalRetentionDays int `json:"changeJournalRetentionDays" xml:"changeJournalRetentionDays" default:"30"`
+	ChangeJournalMaxEntries    int `json:"changeJournalMaxEntries" xml:"changeJournalMaxEntries" default:"100000"`
 	// Legacy deprecated
 	DeprecatedUPnPEnabled        bool     `json:"-" xml:"upnpEnabled,omitempty"`        // Deprecated: Do not use.
 	DeprecatedUPnPLeaseM         int      `json:"-" xml:"upnpLeaseMinutes,omitempty"`   // Deprecated: Do not use.
@@ -0,0 +1,464 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	// OverlayDirName is the directory next to the configuration file
+	// holding drop-in configuration fragments.
+	OverlayDirName = "config.d"
+
+	overlayPollInterval = 10 * time.Second
+)
+
+// ErrLocked is returned when a change touches settings owned by the
+// configuration overlay.
+var ErrLocked = errors.New("setting is managed by the configuration overlay")
+
+// An overlay is the set of configuration fragments in the overlay
+// directory, merged over the configuration loaded from disk:
+//
+//	config.d/options.{xml,yaml,yml,json}  options
+//	config.d/folders/*.{xml,yaml,yml,json} one folder each
+//	config.d/devices/*.{xml,yaml,yml,json} one device each
+//
+// The settings given in the fragments are owned by the overlay. They
+// can't be changed other than by Syncthing itself, and are reverted to
+// their base values when the configuration is saved, so that the
+// configuration file only holds what isn't managed by the overlay.
+type overlay struct {
+	dir         string
+	fingerprint string
+
+	options *overlayFragment
+	folders []overlayFragment
+	devices []overlayFragment
+
+	// The values of the owned settings before the overlay was applied. A
+	// nil folder or device was added by the overlay.
+	baseOptions OptionsConfiguration
+	baseFolders map[string]*FolderConfiguration
+	baseDevices map[protocol.DeviceID]*DeviceConfiguration
+}
+
+// An overlayFragment is the value of one fragment, a pointer to a folder,
+// device or options, and the indexes of the fields it sets.
+type overlayFragment struct {
+	file   string
+	value  any
+	fields []int
+}
+
+// readOverlay reads the fragments in the given directory. A missing
+// directory is an empty overlay.
+func readOverlay(dir string) (*overlay, error) {
+	o := &overlay{dir: dir}
+	hash := sha256.New()
+
+	read := func(name string, v any) (*overlayFragment, error) {
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(bs))
+		hash.Write(bs)
+		fields, err := parseFragment(name, bs, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(OverlayDirName, name), err)
+		}
+		return &overlayFragment{file: name, value: v, fields: fields}, nil
+	}
+
+	names, err := fragmentNames(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if strings.TrimSuffix(name, filepath.Ext(name)) != "options" {
+			continue
+		}
+		if o.options != nil {
+			return nil, fmt.Errorf("%s: options are given in %s already", filepath.Join(OverlayDirName, name), o.options.file)
+		}
+		if o.options, err = read(name, new(OptionsConfiguration)); err != nil {
+			return nil, err
+		}
+	}
+
+	names, err = fragmentNames(dir, "folders")
+	if err != nil {
+		return nil, err
+	}
+	seenFolders := make(map[string]string)
+	for _, name := range names {
+		frag, err := read(name, new(FolderConfiguration))
+		if err != nil {
+			return nil, err
+		}
+		id := frag.value.(*FolderConfiguration).ID
+		if id == "" {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(OverlayDirName, name), errFolderIDEmpty)
+		}
+		if other, ok := seenFolders[id]; ok {
+			return nil, fmt.Errorf("%s: folder %q is given in %s already", filepath.Join(OverlayDirName, name), id, other)
+		}
+		seenFolders[id] = name
+		o.folders = append(o.folders, *frag)
+	}
+
+	names, err = fragmentNames(dir, "devices")
+	if err != nil {
+		return nil, err
+	}
+	seenDevices := make(map[protocol.DeviceID]string)
+	for _, name := range names {
+		frag, err := read(name, new(DeviceConfiguration))
+		if err != nil {
+			return nil, err
+		}
+		id := frag.value.(*DeviceConfiguration).DeviceID
+		if id == protocol.EmptyDeviceID {
+			return nil, fmt.Errorf("%s: device without ID", filepath.Join(OverlayDirName, name))
+		}
+		if other, ok := seenDevices[id]; ok {
+			return nil, fmt.Errorf("%s: device %s is given in %s already", filepath.Join(OverlayDirName, name), id, other)
+		}
+		seenDevices[id] = name
+		o.devices = append(o.devices, *frag)
+	}
+
+	o.fingerprint = hex.EncodeToString(hash.Sum(nil))
+	return o, nil
+}
+
+// fragmentNames returns the sorted names of the fragments in the given
+// subdirectory, relative to the overlay directory.
+func fragmentNames(dir, sub string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, sub))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		switch filepath.Ext(e.Name()) {
+		case ".xml", ".yaml", ".yml", ".json":
+			names = append(names, filepath.Join(sub, e.Name()))
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// parseFragment decodes the fragment into v, a pointer to a struct, and
+// returns the indexes of the fields it sets.
+func parseFragment(name string, bs []byte, v any) ([]int, error) {
+	if filepath.Ext(name) == ".xml" {
+		return parseXMLFragment(bs, v)
+	}
+	bs, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeStrict(bs, v); err != nil {
+		return nil, err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &keys); err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(v).Elem()
+	var fields []int
+	for key := range keys {
+		for i := range t.NumField() {
+			if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); strings.EqualFold(name, key) {
+				fields = append(fields, i)
+				break
+			}
+		}
+	}
+	slices.Sort(fields)
+	return fields, nil
+}
+
+// fragmentElements are the XML elements of the fragments, as in the
+// configuration file.
+var fragmentElements = map[reflect.Type]string{
+	reflect.TypeFor[*OptionsConfiguration](): "options",
+	reflect.TypeFor[*FolderConfiguration]():  "folder",
+	reflect.TypeFor[*DeviceConfiguration]():  "device",
+}
+
+// parseXMLFragment decodes a fragment holding a single element, e.g.
+// <folder id="..." path="...">, with the attributes and child elements
+// being the fields set.
+func parseXMLFragment(bs []byte, v any) ([]int, error) {
+	if err := xml.Unmarshal(bs, v); err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]bool)
+	elems := make(map[string]bool)
+	dec := xml.NewDecoder(bytes.NewReader(bs))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				if want := fragmentElements[reflect.TypeOf(v)]; tok.Name.Local != want {
+					return nil, fmt.Errorf("expected <%s> element, got <%s>", want, tok.Name.Local)
+				}
+				for _, attr := range tok.Attr {
+					attrs[attr.Name.Local] = true
+				}
+			case 2:
+				elems[tok.Name.Local] = true
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	t := reflect.TypeOf(v).Elem()
+	var fields []int
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("xml")
+		if tag == "-" || f.Name == "XMLName" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		if isAttr := slices.Contains(strings.Split(opts, ","), "attr"); isAttr && attrs[name] || !isAttr && elems[name] {
+			fields = append(fields, i)
+		}
+	}
+	return fields, nil
+}
+
+// copyFields sets the given fields of dst, a pointer to a struct, from
+// src, a pointer to the same type of struct.
+func copyFields(dst, src any, fields []int) {
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, i := range fields {
+		dv.Field(i).Set(sv.Field(i))
+	}
+}
+
+// apply merges the overlay over the configuration, remembering the base
+// values of the settings it owns.
+func (o *overlay) apply(cfg *Configuration) {
+	if o.options != nil {
+		o.baseOptions = cfg.Options.Copy()
+		opts := o.options.value.(*OptionsConfiguration).Copy()
+		copyFields(&cfg.Options, &opts, o.options.fields)
+	}
+
+	o.baseFolders = make(map[string]*FolderConfiguration, len(o.folders))
+	for _, frag := range o.folders {
+		overlay := frag.value.(*FolderConfiguration).Copy()
+		folder, _, ok := cfg.Folder(overlay.ID)
+		if ok {
+			base := folder.Copy()
+			o.baseFolders[overlay.ID] = &base
+		} else {
+			folder = cfg.Defaults.Folder.Copy()
+			o.baseFolders[overlay.ID] = nil
+		}
+		copyFields(&folder, &overlay, frag.fields)
+		cfg.SetFolder(folder)
+	}
+
+	o.baseDevices = make(map[protocol.DeviceID]*DeviceConfiguration, len(o.devices))
+	for _, frag := range o.devices {
+		overlay := frag.value.(*DeviceConfiguration).Copy()
+		device, _, ok := cfg.Device(overlay.DeviceID)
+		if ok {
+			base := device.Copy()
+			o.baseDevices[overlay.DeviceID] = &base
+		} else {
+			device = cfg.Defaults.Device.Copy()
+			o.baseDevices[overlay.DeviceID] = nil
+		}
+		copyFields(&device, &overlay, frag.fields)
+		cfg.SetDevice(device)
+	}
+}
+
+// revert sets the settings owned by the overlay back to their base values,
+// removing the folders and devices added by it.
+func (o *overlay) revert(cfg *Configuration) {
+	if o.options != nil {
+		opts := o.baseOptions.Copy()
+		copyFields(&cfg.Options, &opts, o.options.fields)
+	}
+
+	for _, frag := range o.folders {
+		id := frag.value.(*FolderConfiguration).ID
+		_, i, ok := cfg.Folder(id)
+		if !ok {
+			continue
+		}
+		base := o.baseFolders[id]
+		if base == nil {
+			cfg.Folders = slices.Delete(cfg.Folders, i, i+1)
+			continue
+		}
+		folder := base.Copy()
+		copyFields(&cfg.Folders[i], &folder, frag.fields)
+	}
+
+	for _, frag := range o.devices {
+		id := frag.value.(*DeviceConfiguration).DeviceID
+		_, i, ok := cfg.Device(id)
+		if !ok {
+			continue
+		}
+		base := o.baseDevices[id]
+		if base == nil {
+			cfg.Devices = slices.Delete(cfg.Devices, i, i+1)
+			continue
+		}
+		device := base.Copy()
+		copyFields(&cfg.Devices[i], &device, frag.fields)
+	}
+}
+
+// lockedPaths returns the settings owned by the overlay, as paths in the
+// format of Diff. A path naming a folder or device locks its existence,
+// other paths the setting and everything below it. All settings of the
+// folders and devices added by the overlay are owned by it, as there is
+// nothing to write them back to on save.
+func (o *overlay) lockedPaths() []string {
+	var paths []string
+	fieldPaths := func(prefix string, v any, fields []int) {
+		t := reflect.TypeOf(v).Elem()
+		for _, i := range fields {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				paths = append(paths, prefix+"."+name)
+			}
+		}
+	}
+	if o.options != nil {
+		fieldPaths("options", o.options.value, o.options.fields)
+	}
+	for _, frag := range o.folders {
+		id := frag.value.(*FolderConfiguration).ID
+		prefix := "folders[" + id + "]"
+		paths = append(paths, prefix)
+		fields := frag.fields
+		if base, ok := o.baseFolders[id]; ok && base == nil {
+			fields = allFields(frag.value)
+		}
+		fieldPaths(prefix, frag.value, fields)
+	}
+	for _, frag := range o.devices {
+		id := frag.value.(*DeviceConfiguration).DeviceID
+		prefix := "devices[" + id.String() + "]"
+		paths = append(paths, prefix)
+		fields := frag.fields
+		if base, ok := o.baseDevices[id]; ok && base == nil {
+			fields = allFields(frag.value)
+		}
+		fieldPaths(prefix, frag.value, fields)
+	}
+	return paths
+}
+
+// allFields returns the indexes of all fields of v, a pointer to a struct.
+func allFields(v any) []int {
+	fields := make([]int, reflect.TypeOf(v).Elem().NumField())
+	for i := range fields {
+		fields[i] = i
+	}
+	return fields
+}
+
+// verify returns an error if the change touches settings owned by the
+// overlay.
+func (o *overlay) verify(from, to Configuration) error {
+	locked := o.lockedPaths()
+	if len(locked) == 0 {
+		return nil
+	}
+	for _, c := range Diff(from, to) {
+		for _, lock := range locked {
+			if isLocked(c.Path, lock) {
+				return fmt.Errorf("%s: %w", c.Path, ErrLocked)
+			}
+		}
+	}
+	// Sharing folders with the devices added by the overlay would be lost
+	// on save, as the devices aren't there when the configuration is
+	// loaded again.
+	for id, base := range o.baseDevices {
+		if base != nil {
+			continue
+		}
+		for _, folder := range to.Folders {
+			dev, ok := folder.Device(id)
+			if !ok {
+				continue
+			}
+			if fromFolder, _, ok := from.Folder(folder.ID); ok {
+				if fromDev, ok := fromFolder.Device(id); ok && fromDev == dev {
+					continue
+				}
+			}
+			return fmt.Errorf("folders[%s].devices[%s]: %w", folder.ID, id, ErrLocked)
+		}
+	}
+	return nil
+}
+
+func isLocked(path, lock string) bool {
+	if path == lock {
+		return true
+	}
+	if strings.HasSuffix(lock, "]") {
+		return false
+	}
+	return strings.HasPrefix(path, lock+".") || strings.HasPrefix(path, lock+"[")
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+func writeOverlayTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			os.Remove(path)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.xml")
+
+	base := New(device1)
+	base.Options.MaxSendKbps = 10
+	base.Options.MaxRecvKbps = 20
+	base.SetFolder(FolderConfiguration{ID: "base", Path: "/base", Label: "Base", RescanIntervalS: 60})
+	var buf bytes.Buffer
+	if err := base.WriteXML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	writeOverlayTestFiles(t, dir, map[string]string{
+		"config.xml":                  buf.String(),
+		"config.d/options.yaml":       "maxSendKbps: 100\n",
+		"config.d/folders/base.xml":   `<folder id="base" label="Managed"></folder>`,
+		"config.d/folders/added.json": `{"id": "added", "path": "/added"}`,
+		"config.d/devices/two.xml":    `<device id="` + device2.String() + `" name="two"><address>tcp://192.0.2.1:22000</address></device>`,
+		"config.d/README":             "ignored",
+	})
+
+	wr, _, err := Load(path, device1, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := wr.(*wrapper)
+	defer startWrapper(w).stop()
+
+	// The overlay is merged over the configuration file.
+	if opts := w.Options(); opts.MaxSendKbps != 100 || opts.MaxRecvKbps != 20 {
+		t.Errorf("Options not merged: send %d, recv %d", opts.MaxSendKbps, opts.MaxRecvKbps)
+	}
+	if f, _ := w.Folder("base"); f.Label != "Managed" || f.Path != "/base" || f.RescanIntervalS != 60 {
+		t.Errorf("Folder not merged: %+v", f)
+	}
+	if f, ok := w.Folder("added"); !ok || f.Path != "/added" || f.RescanIntervalS != base.Defaults.Folder.RescanIntervalS {
+		t.Errorf("Folder not added from the defaults: %+v", f)
+	}
+	if d, ok := w.Device(device2); !ok || d.Name != "two" || !slices.Equal(d.Addresses, []string{"tcp://192.0.2.1:22000"}) {
+		t.Errorf("Device not added: %+v", d)
+	}
+
+	locked := w.LockedPaths()
+	for _, p := range []string{"options.maxSendKbps", "folders[base]", "folders[base].label", "folders[added].path", "devices[" + device2.String() + "].addresses"} {
+		if !slices.Contains(locked, p) {
+			t.Errorf("%s not locked in %v", p, locked)
+		}
+	}
+
+	// Owned settings can't be changed through the API, others can.
+	user := Author{Kind: AuthorKindUser, Name: "alice"}
+	if _, err := w.ModifyAs(user, func(cfg *Configuration) { cfg.Options.MaxSendKbps = 1 }); !errors.Is(err, ErrLocked) {
+		t.Errorf("Changed locked option: %v", err)
+	}
+	if _, err := w.ModifyAs(user, func(cfg *Configuration) {
+		_, i, _ := cfg.Folder("added")
+		cfg.Folders = slices.Delete(cfg.Folders, i, i+1)
+	}); !errors.Is(err, ErrLocked) {
+		t.Errorf("Removed locked folder: %v", err)
+	}
+	waiter, err := w.ModifyAs(user, func(cfg *Configuration) {
+		cfg.Options.MaxRecvKbps = 30
+		_, i, _ := cfg.Folder("base")
+		cfg.Folders[i].RescanIntervalS = 120
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+
+	// Saving writes the base values of owned settings.
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _, err := ReadXML(fd, device1)
+	fd.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Options.MaxSendKbps != 10 || saved.Options.MaxRecvKbps != 30 {
+		t.Errorf("Saved options: send %d, recv %d", saved.Options.MaxSendKbps, saved.Options.MaxRecvKbps)
+	}
+	if f, _, _ := saved.Folder("base"); f.Label != "Base" || f.RescanIntervalS != 120 {
+		t.Errorf("Saved folder: %+v", f)
+	}
+	if _, _, ok := saved.Folder("added"); ok {
+		t.Error("Folder added by the overlay saved")
+	}
+	if _, _, ok := saved.Device(device2); ok {
+		t.Error("Device added by the overlay saved")
+	}
+
+	// Changed fragments are applied again.
+	writeOverlayTestFiles(t, dir, map[string]string{
+		"config.d/options.yaml":       "maxSendKbps: 200\n",
+		"config.d/folders/added.json": "",
+	})
+	w.reloadOverlay().Wait()
+	if kbps := w.Options().MaxSendKbps; kbps != 200 {
+		t.Errorf("Option not reloaded: %d", kbps)
+	}
+	if _, ok := w.Folder("added"); ok {
+		t.Error("Folder removed from the overlay still present")
+	}
+	if f, _ := w.Folder("base"); f.Label != "Managed" || f.RescanIntervalS != 120 {
+		t.Errorf("Folder after reload: %+v", f)
+	}
+
+	// A broken overlay is not applied.
+	writeOverlayTestFiles(t, dir, map[string]string{
+		"config.d/options.yaml": "maxSendKbps: fast\n",
+	})
+	w.reloadOverlay().Wait()
+	if kbps := w.Options().MaxSendKbps; kbps != 200 {
+		t.Errorf("Broken overlay applied: %d", kbps)
+	}
+}
+
+func TestOverlayAddedEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.xml")
+
+	base := New(device1)
+	base.SetFolder(FolderConfiguration{ID: "base", Path: "/base"})
+	var buf bytes.Buffer
+	if err := base.WriteXML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	writeOverlayTestFiles(t, dir, map[string]string{
+		"config.xml":                  buf.String(),
+		"config.d/folders/added.json": `{"id": "added", "path": "/added"}`,
+		"config.d/devices/two.json":   `{"deviceID": "` + device2.String() + `"}`,
+	})
+
+	load := func() *wrapper {
+		t.Helper()
+		wr, _, err := Load(path, device1, events.NoopLogger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wr.(*wrapper)
+	}
+	w := load()
+	s := startWrapper(w)
+
+	// Nothing about the folders and devices added by the overlay would
+	// survive saving, so none of it can be changed.
+	user := Author{Kind: AuthorKindUser, Name: "alice"}
+	locked := map[string]func(cfg *Configuration){
+		"folder label": func(cfg *Configuration) {
+			_, i, _ := cfg.Folder("added")
+			cfg.Folders[i].Label = "Edited"
+		},
+		"device name": func(cfg *Configuration) {
+			_, i, _ := cfg.Device(device2)
+			cfg.Devices[i].Name = "Edited"
+		},
+		"sharing": func(cfg *Configuration) {
+			_, i, _ := cfg.Folder("base")
+			cfg.Folders[i].Devices = append(cfg.Folders[i].Devices, FolderDeviceConfiguration{DeviceID: device2})
+		},
+		"sharing a new folder": func(cfg *Configuration) {
+			cfg.SetFolder(FolderConfiguration{ID: "new", Path: "/new", Devices: []FolderDeviceConfiguration{{DeviceID: device2}}})
+		},
+	}
+	for name, fn := range locked {
+		if _, err := w.ModifyAs(user, fn); !errors.Is(err, ErrLocked) {
+			t.Errorf("Changed %s: %v", name, err)
+		}
+	}
+	waiter, err := w.ModifyAs(user, func(cfg *Configuration) {
+		_, i, _ := cfg.Folder("base")
+		cfg.Folders[i].Label = "Edited"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s.stop()
+
+	w = load()
+	if f, _ := w.Folder("base"); f.Label != "Edited" {
+		t.Errorf("Change lost on save: %+v", f)
+	}
+	if f, _ := w.Folder("added"); f.Label != "" || f.RescanIntervalS != base.Defaults.Folder.RescanIntervalS {
+		t.Errorf("Added folder changed: %+v", f)
+	}
+	if d, _ := w.Device(device2); d.Name != "" {
+		t.Errorf("Added device changed: %+v", d)
+	}
+}
+
+func TestOverlayErrors(t *testing.T) {
+	cases := []map[string]string{
+		{"options.xml": "<options><maxSendKbps>1</maxSendKbps></options>", "options.yaml": "maxSendKbps: 2"},
+		{"options.xml": "<folder></folder>"},
+		{"folders/a.yaml": "path: /a"},
+		{"folders/a.yaml": "id: a\npth: /a"},
+		{"folders/a.yaml": "id: a\npath: /a", "folders/b.json": `{"id": "a", "path": "/b"}`},
+		{"devices/a.xml": `<device name="a"></device>`},
+	}
+	for _, files := range cases {
+		dir := t.TempDir()
+		writeOverlayTestFiles(t, dir, files)
+		if _, err := readOverlay(dir); err == nil {
+			t.Errorf("No error for %v", files)
+		}
+	}
+
+	if o, err := readOverlay(filepath.Join(t.TempDir(), "missing")); err != nil || len(o.lockedPaths()) != 0 {
+		t.Errorf("Missing directory: %v, %v", o, err)
+	}
+}
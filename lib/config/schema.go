@@ -0,0 +1,165 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/structutil"
+)
+
+// maxEnumValue bounds the search for the values of enum types.
+const maxEnumValue = 64
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) of the configuration as
+// used in the REST API, generated from the structs and their tags. Struct
+// types are given as definitions, enums with their values and settings
+// with their defaults.
+var JSONSchema = sync.OnceValue(func() map[string]any {
+	g := schemaGenerator{defs: make(map[string]any)}
+	schema := g.schema(reflect.TypeFor[Configuration]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Syncthing configuration"
+	schema["$defs"] = g.defs
+	return schema
+})
+
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(textMarshalerType):
+		if values := enumValues(t); len(values) > 0 {
+			return map[string]any{"type": "string", "enum": values}
+		}
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t != reflect.TypeFor[Configuration]() {
+			return g.ref(t)
+		}
+		return g.object(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// ref returns a reference to the definition of the struct type, adding it
+// if it's not there yet.
+func (g *schemaGenerator) ref(t reflect.Type) map[string]any {
+	if _, ok := g.defs[t.Name()]; !ok {
+		g.defs[t.Name()] = nil // placeholder against recursion
+		g.defs[t.Name()] = g.object(t)
+	}
+	return map[string]any{"$ref": "#/$defs/" + t.Name()}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	// The defaults are those the configuration gets, in their JSON form.
+	defaults := reflect.New(t)
+	structutil.SetDefaults(defaults.Interface())
+	_ = structutil.FillNilSlices(defaults.Interface())
+
+	props := make(map[string]any)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := g.schema(f.Type)
+		if _, ok := f.Tag.Lookup("default"); ok {
+			if bs, err := json.Marshal(defaults.Elem().Field(i).Interface()); err == nil {
+				var def any
+				if json.Unmarshal(bs, &def) == nil {
+					prop = withDefault(prop, def)
+				}
+			}
+		}
+		props[name] = prop
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// withDefault returns the schema with the default added. References can't
+// have siblings in all drafts, so they are wrapped.
+func withDefault(schema map[string]any, def any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{"allOf": []any{schema}, "default": def}
+	}
+	schema["default"] = def
+	return schema
+}
+
+// enumValues returns the textual values of an integer enum type, found by
+// looking for the values whose text parses back to them.
+func enumValues(t reflect.Type) []string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return nil
+	}
+	if !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	var values []string
+	for i := range maxEnumValue {
+		v := reflect.New(t).Elem()
+		v.SetInt(int64(i))
+		bs, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			continue
+		}
+		parsed := reflect.New(t)
+		if err := parsed.Interface().(encoding.TextUnmarshaler).UnmarshalText(bs); err != nil {
+			continue
+		}
+		if parsed.Elem().Int() == int64(i) {
+			values = append(values, string(bs))
+		}
+	}
+	return values
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	bs, err := json.Marshal(JSONSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]struct {
+				Type    string   `json:"type"`
+				Enum    []string `json:"enum"`
+				Default any      `json:"default"`
+				Minimum *int     `json:"minimum"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(bs, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"version", "folders", "devices", "gui", "options", "defaults"} {
+		if _, ok := schema.Properties[p]; !ok {
+			t.Errorf("Property %s missing", p)
+		}
+	}
+
+	folder := schema.Defs["FolderConfiguration"].Properties
+	if e := folder["order"].Enum; !slices.Equal(e, []string{"random", "alphabetic", "smallestFirst", "largestFirst", "oldestFirst", "newestFirst"}) {
+		t.Errorf("Pull order values %v", e)
+	}
+	if e := folder["blockPullOrder"].Enum; !slices.Equal(e, []string{"standard", "random", "inOrder"}) {
+		t.Errorf("Block pull order values %v", e)
+	}
+	if e := folder["copyRangeMethod"].Enum; !slices.Contains(e, "copy_file_range") || !slices.Contains(e, "standard") {
+		t.Errorf("Copy range method values %v", e)
+	}
+	if p := folder["copyRangeMethod"]; p.Type != "string" || p.Default != "standard" {
+		t.Errorf("Copy range method %+v", p)
+	}
+	if p := folder["rescanIntervalS"]; p.Type != "integer" || p.Default != float64(3600) {
+		t.Errorf("Rescan interval %+v", p)
+	}
+	if p := folder["id"]; p.Type != "string" || p.Default != nil {
+		t.Errorf("Folder ID %+v", p)
+	}
+
+	opts := schema.Defs["OptionsConfiguration"].Properties
+	if d, ok := opts["listenAddresses"].Default.([]any); !ok || len(d) != 1 || d[0] != "default" {
+		t.Errorf("Listen addresses default %v", opts["listenAddresses"].Default)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Credentials in the configuration may be given as references to where
+// their values are kept, instead of the values themselves:
+//
+//	file:/run/secrets/apikey  the contents of the file, without trailing newlines
+//	env:SYNCTHING_APIKEY      the value of the environment variable
+//
+// References are resolved when the configuration is loaded or changed, and
+// written back in place of the values when it is saved.
+const (
+	secretRefFile = "file:"
+	secretRefEnv  = "env:"
+)
+
+// IsSecretReference returns true if the value is a reference to a secret
+// rather than the secret itself.
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretRefFile) || strings.HasPrefix(value, secretRefEnv)
+}
+
+func resolveSecretReference(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretRefFile):
+		bs, err := os.ReadFile(strings.TrimPrefix(ref, secretRefFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(bs), "\r\n"), nil
+	case strings.HasPrefix(ref, secretRefEnv):
+		name := strings.TrimPrefix(ref, secretRefEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	default:
+		return "", errors.New("not a secret reference")
+	}
+}
+
+// referenceFields returns pointers to the credentials in the configuration
+// that may be given as references, by their paths in the format of Diff.
+func referenceFields(cfg *Configuration) map[string]*string {
+	fields := map[string]*string{
+		"gui.password":      &cfg.GUI.Password,
+		"gui.apiKey":        &cfg.GUI.APIKey,
+		"ldap.bindDN":       &cfg.LDAP.BindDN,
+		"oidc.clientSecret": &cfg.OIDC.ClientSecret,
+	}
+	for i := range cfg.GUI.Users {
+		fields["gui.users["+cfg.GUI.Users[i].Name+"].password"] = &cfg.GUI.Users[i].Password
+	}
+	for i := range cfg.GUI.APIKeys {
+		fields["gui.apiKeys["+cfg.GUI.APIKeys[i].Name+"].key"] = &cfg.GUI.APIKeys[i].Key
+	}
+	for i := range cfg.ShareRules {
+		fields["shareRules["+cfg.ShareRules[i].Name+"].encryptionPassword"] = &cfg.ShareRules[i].EncryptionPassword
+	}
+	for i := range cfg.Folders {
+		folder := &cfg.Folders[i]
+		for j := range folder.Devices {
+			fields["folders["+folder.ID+"].devices["+folder.Devices[j].DeviceID.String()+"].encryptionPassword"] = &folder.Devices[j].EncryptionPassword
+		}
+	}
+	return fields
+}
+
+// secretRefs are the credentials of a configuration that were given as
+// references, by path.
+type secretRefs map[string]secretRef
+
+type secretRef struct {
+	ref   string
+	value string
+}
+
+// resolve replaces the references in the configuration with their values,
+// and returns the references in effect for it: those just resolved, and
+// those of s whose values are unchanged. It also returns whether there
+// were references to resolve.
+func (s secretRefs) resolve(cfg *Configuration) (secretRefs, bool, error) {
+	res := make(secretRefs)
+	resolved := false
+	for path, field := range referenceFields(cfg) {
+		if ref, ok := s[path]; ok && *field == ref.value {
+			res[path] = ref
+			continue
+		}
+		if !IsSecretReference(*field) {
+			continue
+		}
+		value, err := resolveSecretReference(*field)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", path, err)
+		}
+		res[path] = secretRef{ref: *field, value: value}
+		*field = value
+		resolved = true
+	}
+	return res, resolved, nil
+}
+
+// restore puts the references back in place of their values.
+func (s secretRefs) restore(cfg *Configuration) {
+	if len(s) == 0 {
+		return
+	}
+	for path, field := range referenceFields(cfg) {
+		if ref, ok := s[path]; ok && *field == ref.value {
+			*field = ref.ref
+		}
+	}
+}
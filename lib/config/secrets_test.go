@@ -0,0 +1,150 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+func TestSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "apikey")
+	if err := os.WriteFile(keyFile, []byte("key-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SYNCTHING_TEST_ENCRYPTION_PASSWORD", "password-from-env")
+
+	cfg := New(device1)
+	cfg.GUI.APIKey = "file:" + keyFile
+	cfg.GUI.Password = "$2a$10$IdIZTxTg/dCNuNEGlmLynOjqg4B1FvDKuIV5e0BB3pnWVHNb8.GSq"
+	cfg.SetDevice(DeviceConfiguration{DeviceID: device2})
+	cfg.SetFolder(FolderConfiguration{
+		ID:   "a",
+		Path: "/a",
+		Devices: []FolderDeviceConfiguration{
+			{DeviceID: device1},
+			{DeviceID: device2, EncryptionPassword: "env:SYNCTHING_TEST_ENCRYPTION_PASSWORD"},
+		},
+	})
+	var buf bytes.Buffer
+	if err := cfg.WriteXML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.xml")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	wr, _, err := Load(path, device1, events.NoopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := startWrapper(wr)
+	defer w.stop()
+
+	// References are resolved at load.
+	if key := w.GUI().APIKey; key != "key-from-file" {
+		t.Errorf("API key %q not read from file", key)
+	}
+	encryptionPassword := func(cfg Configuration) string {
+		folder, _, _ := cfg.Folder("a")
+		dev, _ := folder.Device(device2)
+		return dev.EncryptionPassword
+	}
+	if pw := encryptionPassword(w.RawCopy()); pw != "password-from-env" {
+		t.Errorf("Encryption password %q not read from the environment", pw)
+	}
+
+	// They are shown in place of the values...
+	shown := w.CopyWithSecretReferences()
+	if shown.GUI.APIKey != "file:"+keyFile || encryptionPassword(shown) != "env:SYNCTHING_TEST_ENCRYPTION_PASSWORD" {
+		t.Errorf("References not shown: %q, %q", shown.GUI.APIKey, encryptionPassword(shown))
+	}
+
+	// ... and giving them back changes nothing.
+	waiter, err := w.ModifyAs(Author{Kind: AuthorKindUser}, func(cfg *Configuration) {
+		*cfg = shown
+		cfg.Options.MaxSendKbps = 100
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if key := w.GUI().APIKey; key != "key-from-file" {
+		t.Errorf("API key %q after giving the reference back", key)
+	}
+
+	// Saving writes the references, unless the value was changed.
+	waiter, err = w.Modify(func(cfg *Configuration) {
+		cfg.GUI.Password = "$2a$10$zjS3LsyHddYIuoO0d7PpD.Dj5BV2M8iP.Ruw6gx.tvZqfgz2mDcIi"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(bs)
+	for _, s := range []string{"file:" + keyFile, "env:SYNCTHING_TEST_ENCRYPTION_PASSWORD", "zjS3LsyHddYIuoO0d7PpD"} {
+		if !strings.Contains(saved, s) {
+			t.Errorf("Saved configuration lacks %q", s)
+		}
+	}
+	for _, s := range []string{"key-from-file", "password-from-env"} {
+		if strings.Contains(saved, s) {
+			t.Errorf("Saved configuration contains secret %q", s)
+		}
+	}
+
+	// References given later are resolved too, and must resolve.
+	if _, err := w.Modify(func(cfg *Configuration) {
+		cfg.GUI.APIKey = "env:SYNCTHING_TEST_MISSING"
+	}); err == nil {
+		t.Error("Unresolvable reference accepted")
+	}
+	waiter, err = w.Modify(func(cfg *Configuration) {
+		cfg.OIDC.ClientSecret = "env:SYNCTHING_TEST_ENCRYPTION_PASSWORD"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	if secret := w.OIDC().ClientSecret; secret != "password-from-env" {
+		t.Errorf("Client secret %q not resolved", secret)
+	}
+}
+
+func TestSecretReferenceErrors(t *testing.T) {
+	for _, ref := range []string{"env:SYNCTHING_TEST_MISSING", "file:" + filepath.Join(t.TempDir(), "missing")} {
+		cfg := New(device1)
+		cfg.GUI.APIKey = ref
+		if _, _, err := secretRefs(nil).resolve(&cfg); err == nil {
+			t.Errorf("No error for %s", ref)
+		}
+	}
+}
+
+func TestSetPasswordReference(t *testing.T) {
+	var gui GUIConfiguration
+	if err := gui.SetPassword("file:/run/secrets/gui-password-hash"); err != nil {
+		t.Fatal(err)
+	}
+	if gui.Password != "file:/run/secrets/gui-password-hash" {
+		t.Errorf("Reference hashed: %q", gui.Password)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+var (
+	errShareRuleNameEmpty     = errors.New("share rule has empty name")
+	errShareRuleNameDuplicate = errors.New("share rule has duplicate name")
+	errShareRuleGroupEmpty    = errors.New("share rule lacks a device or folder group")
+	errShareRuleTypeConflict  = errors.New("share rules give conflicting folder types")
+)
+
+// ShareRule shares every folder of a folder group with every device of a
+// device group. The rules are applied whenever the configuration changes,
+// so devices and folders added to the groups are shared as well, and a
+// folder can't be unshared from a device the rules share it with.
+//
+// When this device is in the device group, the folders are of the rule's
+// type. The encryption password is given to the devices the rule shares a
+// folder with that don't have one already; existing passwords are left
+// alone.
+type ShareRule struct {
+	Name               string     `json:"name" xml:"name,attr"`
+	DeviceGroup        string     `json:"deviceGroup" xml:"deviceGroup,attr"`
+	FolderGroup        string     `json:"folderGroup" xml:"folderGroup,attr"`
+	FolderType         FolderType `json:"folderType" xml:"folderType,attr"`
+	EncryptionPassword string     `json:"encryptionPassword" xml:"encryptionPassword,omitempty"`
+}
+
+// Redacted returns a copy of the rule without the encryption password.
+func (r ShareRule) Redacted() ShareRule {
+	r.EncryptionPassword = ""
+	return r
+}
+
+// applyShareRules shares the folders with the devices as the rules say,
+// and sets the types of the folders of this device.
+func (cfg *Configuration) applyShareRules(myID protocol.DeviceID) error {
+	names := make(map[string]bool, len(cfg.ShareRules))
+	for _, rule := range cfg.ShareRules {
+		switch {
+		case rule.Name == "":
+			return errShareRuleNameEmpty
+		case names[rule.Name]:
+			return fmt.Errorf("share rule %q: %w", rule.Name, errShareRuleNameDuplicate)
+		case rule.DeviceGroup == "" || rule.FolderGroup == "":
+			return fmt.Errorf("share rule %q: %w", rule.Name, errShareRuleGroupEmpty)
+		}
+		names[rule.Name] = true
+	}
+
+	for i := range cfg.Folders {
+		folder := &cfg.Folders[i]
+		typeRule := ""
+		for _, rule := range cfg.ShareRules {
+			if folder.Group != rule.FolderGroup {
+				continue
+			}
+			for _, device := range cfg.Devices {
+				if device.Group != rule.DeviceGroup {
+					continue
+				}
+				if device.DeviceID == myID {
+					if typeRule != "" && folder.Type != rule.FolderType {
+						return fmt.Errorf("folder %q: share rules %q and %q: %w", folder.ID, typeRule, rule.Name, errShareRuleTypeConflict)
+					}
+					folder.Type = rule.FolderType
+					typeRule = rule.Name
+					continue
+				}
+				idx := slices.IndexFunc(folder.Devices, func(d FolderDeviceConfiguration) bool { return d.DeviceID == device.DeviceID })
+				if idx < 0 {
+					folder.Devices = append(folder.Devices, FolderDeviceConfiguration{DeviceID: device.DeviceID})
+					idx = len(folder.Devices) - 1
+				}
+				if folder.Devices[idx].EncryptionPassword == "" {
+					folder.Devices[idx].EncryptionPassword = rule.EncryptionPassword
+				}
+			}
+		}
+	}
+
+	return nil
+}
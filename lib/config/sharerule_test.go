@@ -0,0 +1,108 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestShareRules(t *testing.T) {
+	cfg := New(device1)
+	cfg.Devices[0].Group = "workstations"
+	cfg.SetDevices([]DeviceConfiguration{
+		{DeviceID: device2, Group: "workstations"},
+		{DeviceID: device3, Group: "backups", Untrusted: true},
+		{DeviceID: device4},
+	})
+	cfg.SetFolders([]FolderConfiguration{
+		{ID: "a", Path: "/a", Group: "projects", Type: FolderTypeSendOnly},
+		{ID: "b", Path: "/b", Group: "projects", Devices: []FolderDeviceConfiguration{{DeviceID: device3}}},
+		{ID: "c", Path: "/c"},
+	})
+	cfg.ShareRules = []ShareRule{
+		{Name: "work", DeviceGroup: "workstations", FolderGroup: "projects", FolderType: FolderTypeSendReceive},
+		{Name: "backup", DeviceGroup: "backups", FolderGroup: "projects", EncryptionPassword: "secret"},
+	}
+
+	if err := cfg.prepare(device1); err != nil {
+		t.Fatal(err)
+	}
+	w := wrap(filepath.Join(t.TempDir(), "config.xml"), cfg, device1)
+	defer w.stop()
+
+	a, _ := w.Folder("a")
+	if a.Type != FolderTypeSendReceive {
+		t.Errorf("Folder type %v not set by the rule", a.Type)
+	}
+	if ids := a.DeviceIDs(); !slices.Equal(ids, []protocol.DeviceID{device1, device2, device3}) {
+		t.Errorf("Folder a shared with %v", ids)
+	}
+	if dev, _ := a.Device(device3); dev.EncryptionPassword != "secret" {
+		t.Errorf("Encryption password %q not given", dev.EncryptionPassword)
+	}
+	// Folder b was already shared with device3, without a password.
+	if b, _ := w.Folder("b"); !slices.Equal(b.DeviceIDs(), []protocol.DeviceID{device1, device2, device3}) {
+		t.Errorf("Folder b shared with %v", b.DeviceIDs())
+	}
+	cfg.Folders[1].Devices[2].EncryptionPassword = "other"
+	if err := cfg.prepare(device1); err != nil {
+		t.Fatal(err)
+	}
+	if dev, _ := cfg.Folders[1].Device(device3); dev.EncryptionPassword != "other" {
+		t.Errorf("Existing encryption password replaced by %q", dev.EncryptionPassword)
+	}
+	if c, _ := w.Folder("c"); !slices.Equal(c.DeviceIDs(), []protocol.DeviceID{device1}) {
+		t.Errorf("Folder c outside the groups shared with %v", c.DeviceIDs())
+	}
+
+	// Devices and folders added to the groups are shared.
+	waiter, err := w.Modify(func(cfg *Configuration) {
+		cfg.SetDevice(DeviceConfiguration{DeviceID: device4, Group: "workstations"})
+		cfg.SetFolder(FolderConfiguration{ID: "d", Path: "/d", Group: "projects"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	for _, id := range []string{"a", "b", "d"} {
+		if f, _ := w.Folder(id); !slices.Contains(f.DeviceIDs(), device4) {
+			t.Errorf("Folder %s not shared with the added device: %v", id, f.DeviceIDs())
+		}
+	}
+	if d, _ := w.Folder("d"); !slices.Contains(d.DeviceIDs(), device3) {
+		t.Errorf("Added folder not shared: %v", d.DeviceIDs())
+	}
+}
+
+func TestShareRuleErrors(t *testing.T) {
+	cases := []struct {
+		rules []ShareRule
+		err   error
+	}{
+		{[]ShareRule{{DeviceGroup: "d", FolderGroup: "f"}}, errShareRuleNameEmpty},
+		{[]ShareRule{{Name: "a", DeviceGroup: "d", FolderGroup: "f"}, {Name: "a", DeviceGroup: "e", FolderGroup: "f"}}, errShareRuleNameDuplicate},
+		{[]ShareRule{{Name: "a", FolderGroup: "f"}}, errShareRuleGroupEmpty},
+		{[]ShareRule{
+			{Name: "a", DeviceGroup: "d", FolderGroup: "f", FolderType: FolderTypeSendOnly},
+			{Name: "b", DeviceGroup: "d", FolderGroup: "f", FolderType: FolderTypeReceiveOnly},
+		}, errShareRuleTypeConflict},
+	}
+	for _, tc := range cases {
+		cfg := New(device1)
+		cfg.Devices[0].Group = "d"
+		cfg.SetFolder(FolderConfiguration{ID: "a", Path: "/a", Group: "f"})
+		cfg.ShareRules = tc.rules
+		if err := cfg.prepare(device1); !errors.Is(err, tc.err) {
+			t.Errorf("Expected %v for %v, got %v", tc.err, tc.rules, err)
+		}
+	}
+}
@@ -11,8 +11,10 @@ package config
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -68,6 +70,15 @@ type Verifier interface {
 	VerifyConfiguration(from, to Configuration) error
 }
 
+// An Auditor is told about each committed configuration change, and who
+// made it. It is called for subscribing objects that implement it, after
+// verification and before the Committers, in the order the changes are
+// made. The configuration lock is held during the call, so the Auditor must
+// not call back into the Wrapper.
+type Auditor interface {
+	AuditConfiguration(from, to Configuration, author Author)
+}
+
 // Waiter allows to wait for the given config operation to complete.
 type Waiter interface {
 	Wait()
@@ -87,21 +98,27 @@ type ModifyFunction func(*Configuration)
 //
 // Modify allows changing the currently active configuration through the given
 // ModifyFunction. It can be called concurrently: All calls will be queued and
-// called in order.
+// called in order. ModifyAs does the same, for changes made on behalf of
+// the given author rather than by Syncthing itself. ValidateAs checks a
+// configuration as if the author set it, without changing anything.
 type Wrapper interface {
 	ConfigPath() string
 	MyID() protocol.DeviceID
 
 	RawCopy() Configuration
+	CopyWithSecretReferences() Configuration
 	RequiresRestart() bool
 	Save() error
 
 	Modify(ModifyFunction) (Waiter, error)
+	ModifyAs(Author, ModifyFunction) (Waiter, error)
+	ValidateAs(Author, Configuration) error
 	RemoveFolder(id string) (Waiter, error)
 	RemoveDevice(id protocol.DeviceID) (Waiter, error)
 
 	GUI() GUIConfiguration
 	LDAP() LDAPConfiguration
+	OIDC() OIDCConfiguration
 	Options() OptionsConfiguration
 	DefaultIgnores() Ignores
 
@@ -120,6 +137,10 @@ type Wrapper interface {
 	IgnoredDevice(id protocol.DeviceID) bool
 	IgnoredFolder(device protocol.DeviceID, folder string) bool
 
+	AutoAcceptRules() []AutoAcceptRule
+
+	LockedPaths() []string
+
 	Subscribe(c Committer) Configuration
 	Unsubscribe(c Committer)
 
@@ -137,6 +158,10 @@ type wrapper struct {
 	subs   []Committer
 	mut    sync.Mutex
 
+	secrets       secretRefs
+	overlay       *overlay // nil unless loaded from disk
+	overlayFailed string   // fingerprint of the last overlay that failed to apply
+
 	requiresRestart atomic.Bool
 }
 
@@ -145,6 +170,10 @@ type wrapper struct {
 // The returned Wrapper is a suture.Service, thus needs to be started (added to
 // a supervisor).
 func Wrap(path string, cfg Configuration, myID protocol.DeviceID, evLogger events.Logger) Wrapper {
+	return newWrapper(path, cfg, myID, evLogger)
+}
+
+func newWrapper(path string, cfg Configuration, myID protocol.DeviceID, evLogger events.Logger) *wrapper {
 	w := &wrapper{
 		cfg:      cfg,
 		path:     path,
@@ -157,7 +186,9 @@ func Wrap(path string, cfg Configuration, myID protocol.DeviceID, evLogger event
 }
 
 // Load loads an existing file on disk and returns a new configuration
-// wrapper.
+// wrapper. The overlay in the config.d directory next to the file, if any,
+// is merged over it and watched for changes, and secret references are
+// resolved.
 // The returned Wrapper is a suture.Service, thus needs to be started (added to
 // a supervisor).
 func Load(path string, myID protocol.DeviceID, evLogger events.Logger) (Wrapper, int, error) {
@@ -172,7 +203,23 @@ func Load(path string, myID protocol.DeviceID, evLogger events.Logger) (Wrapper,
 		return nil, 0, err
 	}
 
-	return Wrap(path, cfg, myID, evLogger), originalVersion, nil
+	ov, err := readOverlay(filepath.Join(filepath.Dir(path), OverlayDirName))
+	if err != nil {
+		return nil, 0, err
+	}
+	ov.apply(&cfg)
+	if err := cfg.prepare(myID); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", OverlayDirName, err)
+	}
+	secrets, _, err := secretRefs(nil).resolve(&cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	w := newWrapper(path, cfg, myID, evLogger)
+	w.overlay = ov
+	w.secrets = secrets
+	return w, originalVersion, nil
 }
 
 func (w *wrapper) ConfigPath() string {
@@ -218,12 +265,38 @@ func (w *wrapper) RawCopy() Configuration {
 	return w.cfg.Copy()
 }
 
+// CopyWithSecretReferences returns a copy of the configuration with the
+// credentials that were given as references showing those rather than
+// their values.
+func (w *wrapper) CopyWithSecretReferences() Configuration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	cfg := w.cfg.Copy()
+	w.secrets.restore(&cfg)
+	return cfg
+}
+
 func (w *wrapper) Modify(fn ModifyFunction) (Waiter, error) {
-	return w.modifyQueued(fn)
+	return w.modifyQueued(AuthorSystem, fn)
+}
+
+func (w *wrapper) ModifyAs(author Author, fn ModifyFunction) (Waiter, error) {
+	return w.modifyQueued(author, fn)
+}
+
+// ValidateAs runs the configuration through the same preparation and checks
+// as a change made by the author would go through, without applying it.
+func (w *wrapper) ValidateAs(author Author, cfg Configuration) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	cfg = cfg.Copy()
+	_, _, err := w.checkLocked(w.cfg, &cfg, author)
+	return err
 }
 
-func (w *wrapper) modifyQueued(modifyFunc ModifyFunction) (Waiter, error) {
+func (w *wrapper) modifyQueued(author Author, modifyFunc ModifyFunction) (Waiter, error) {
 	e := modifyEntry{
+		author:     author,
 		modifyFunc: modifyFunc,
 		res:        make(chan modifyResult),
 	}
@@ -243,6 +316,12 @@ func (w *wrapper) Serve(ctx context.Context) error {
 	saveTimer := time.NewTimer(0)
 	<-saveTimer.C
 	saveTimerRunning := false
+	var overlayChan <-chan time.Time
+	if w.overlay != nil {
+		overlayTicker := time.NewTicker(overlayPollInterval)
+		defer overlayTicker.Stop()
+		overlayChan = overlayTicker.C
+	}
 	for {
 		select {
 		case e = <-w.queue:
@@ -250,6 +329,11 @@ func (w *wrapper) Serve(ctx context.Context) error {
 			w.serveSave()
 			saveTimerRunning = false
 			continue
+		case <-overlayChan:
+			if err := waitContext(ctx, w.reloadOverlay()); err != nil {
+				return err
+			}
+			continue
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -264,7 +348,7 @@ func (w *wrapper) Serve(ctx context.Context) error {
 		// Check if the config was actually changed at all.
 		w.mut.Lock()
 		if !reflect.DeepEqual(w.cfg, to) {
-			waiter, err = w.replaceLocked(to)
+			waiter, err = w.replaceLocked(to, e.author)
 			if !saveTimerRunning {
 				saveTimer.Reset(minSaveInterval)
 				saveTimerRunning = true
@@ -279,19 +363,58 @@ func (w *wrapper) Serve(ctx context.Context) error {
 
 		// Wait for all subscriber to handle the config change before continuing
 		// to process the next change.
-		done := make(chan struct{})
-		go func() {
-			waiter.Wait()
-			close(done)
-		}()
-		select {
-		case <-done:
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := waitContext(ctx, waiter); err != nil {
+			return err
 		}
 	}
 }
 
+func waitContext(ctx context.Context, waiter Waiter) error {
+	done := make(chan struct{})
+	go func() {
+		waiter.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reloadOverlay replaces the overlay when its fragments have changed.
+func (w *wrapper) reloadOverlay() Waiter {
+	ov, err := readOverlay(w.overlay.dir)
+	if err != nil {
+		if w.overlayFailed != err.Error() {
+			slog.Error("Failed to read configuration overlay", slogutil.Error(err))
+			w.overlayFailed = err.Error()
+		}
+		return noopWaiter{}
+	}
+	if ov.fingerprint == w.overlay.fingerprint || ov.fingerprint == w.overlayFailed {
+		return noopWaiter{}
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	to := w.cfg.Copy()
+	w.overlay.revert(&to)
+	ov.apply(&to)
+	waiter, err := w.replaceLocked(to, AuthorSystem)
+	if err != nil {
+		slog.Error("Failed to apply configuration overlay", slogutil.Error(err))
+		w.overlayFailed = ov.fingerprint
+		return noopWaiter{}
+	}
+	w.overlay = ov
+	w.overlayFailed = ""
+	slog.Info("Applied changed configuration overlay", slogutil.FilePath(ov.dir))
+	return waiter
+}
+
 func (w *wrapper) serveSave() {
 	if w.path == "" {
 		return
@@ -301,12 +424,60 @@ func (w *wrapper) serveSave() {
 	}
 }
 
-func (w *wrapper) replaceLocked(to Configuration) (Waiter, error) {
+func (w *wrapper) replaceLocked(to Configuration, author Author) (Waiter, error) {
 	from := w.cfg
 
-	if err := to.prepare(w.myID); err != nil {
+	secrets, unchanged, err := w.checkLocked(from, &to, author)
+	if err != nil {
 		return noopWaiter{}, err
 	}
+	if unchanged {
+		// Only references to the secrets we have were given.
+		w.secrets = secrets
+		return noopWaiter{}, nil
+	}
+
+	// Auditors see the references, not the secrets.
+	for _, sub := range w.subs {
+		if sub, ok := sub.(Auditor); ok {
+			auditFrom, auditTo := from.Copy(), to.Copy()
+			w.secrets.restore(&auditFrom)
+			secrets.restore(&auditTo)
+			sub.AuditConfiguration(auditFrom, auditTo, author)
+		}
+	}
+
+	w.cfg = to
+	w.secrets = secrets
+
+	w.waiter = w.notifyListeners(from.Copy(), to.Copy())
+
+	return w.waiter, nil
+}
+
+// checkLocked prepares the new configuration and runs it through the
+// checks for replacing the current one: resolving secret references, the
+// overlay locks and the verifiers. It returns the references in effect for
+// the new configuration, and whether it is the current one with only
+// references given for the secrets.
+func (w *wrapper) checkLocked(from Configuration, to *Configuration, author Author) (secretRefs, bool, error) {
+	if err := to.prepare(w.myID); err != nil {
+		return nil, false, err
+	}
+
+	secrets, resolved, err := w.secrets.resolve(to)
+	if err != nil {
+		return nil, false, err
+	}
+	if resolved && reflect.DeepEqual(from, *to) {
+		return secrets, true, nil
+	}
+
+	if w.overlay != nil && author.Kind != AuthorKindSystem {
+		if err := w.overlay.verify(from, *to); err != nil {
+			return nil, false, err
+		}
+	}
 
 	for _, sub := range w.subs {
 		sub, ok := sub.(Verifier)
@@ -316,15 +487,11 @@ func (w *wrapper) replaceLocked(to Configuration) (Waiter, error) {
 		l.Debugln(sub, "verifying configuration")
 		if err := sub.VerifyConfiguration(from.Copy(), to.Copy()); err != nil {
 			l.Debugln(sub, "rejected config:", err)
-			return noopWaiter{}, err
+			return nil, false, err
 		}
 	}
 
-	w.cfg = to
-
-	w.waiter = w.notifyListeners(from.Copy(), to.Copy())
-
-	return w.waiter, nil
+	return secrets, false, nil
 }
 
 func (w *wrapper) notifyListeners(from, to Configuration) Waiter {
@@ -365,7 +532,7 @@ func (w *wrapper) DeviceList() []DeviceConfiguration {
 
 // RemoveDevice removes the device from the configuration
 func (w *wrapper) RemoveDevice(id protocol.DeviceID) (Waiter, error) {
-	return w.modifyQueued(func(cfg *Configuration) {
+	return w.modifyQueued(AuthorSystem, func(cfg *Configuration) {
 		if _, i, ok := cfg.Device(id); ok {
 			cfg.Devices = append(cfg.Devices[:i], cfg.Devices[i+1:]...)
 		}
@@ -398,7 +565,7 @@ func (w *wrapper) FolderList() []FolderConfiguration {
 
 // RemoveFolder removes the folder from the configuration
 func (w *wrapper) RemoveFolder(id string) (Waiter, error) {
-	return w.modifyQueued(func(cfg *Configuration) {
+	return w.modifyQueued(AuthorSystem, func(cfg *Configuration) {
 		if _, i, ok := cfg.Folder(id); ok {
 			cfg.Folders = append(cfg.Folders[:i], cfg.Folders[i+1:]...)
 		}
@@ -432,6 +599,12 @@ func (w *wrapper) LDAP() LDAPConfiguration {
 	return w.cfg.LDAP.Copy()
 }
 
+func (w *wrapper) OIDC() OIDCConfiguration {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.cfg.OIDC.Copy()
+}
+
 // GUI returns the current GUI configuration object.
 func (w *wrapper) GUI() GUIConfiguration {
 	w.mut.Lock()
@@ -446,6 +619,17 @@ func (w *wrapper) DefaultIgnores() Ignores {
 	return w.cfg.Defaults.Ignores.Copy()
 }
 
+// AutoAcceptRules returns the rules for accepting offered folders.
+func (w *wrapper) AutoAcceptRules() []AutoAcceptRule {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	rules := make([]AutoAcceptRule, len(w.cfg.AutoAcceptRules))
+	for i, rule := range w.cfg.AutoAcceptRules {
+		rules[i] = rule.Copy()
+	}
+	return rules
+}
+
 // IgnoredDevice returns whether or not connection attempts from the given
 // device should be silently ignored.
 func (w *wrapper) IgnoredDevice(id protocol.DeviceID) bool {
@@ -511,7 +695,15 @@ func (w *wrapper) Save() error {
 		return err
 	}
 
-	if err := w.cfg.WriteXML(osutil.LineEndingsWriter(fd)); err != nil {
+	// Settings owned by the overlay aren't ours to write, and secrets
+	// given as references are written as such.
+	cfg := w.cfg.Copy()
+	if w.overlay != nil {
+		w.overlay.revert(&cfg)
+	}
+	w.secrets.restore(&cfg)
+
+	if err := cfg.WriteXML(osutil.LineEndingsWriter(fd)); err != nil {
 		l.Debugln("WriteXML:", err)
 		fd.Close()
 		return err
@@ -522,13 +714,27 @@ func (w *wrapper) Save() error {
 		return err
 	}
 
-	w.evLogger.Log(events.ConfigSaved, w.cfg)
+	saved := w.cfg.Copy()
+	w.secrets.restore(&saved)
+	w.evLogger.Log(events.ConfigSaved, saved)
 	return nil
 }
 
+// LockedPaths returns the settings owned by the configuration overlay, which
+// can't be changed through the API, as paths in the format of Diff.
+func (w *wrapper) LockedPaths() []string {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.overlay == nil {
+		return nil
+	}
+	return w.overlay.lockedPaths()
+}
+
 func (w *wrapper) RequiresRestart() bool { return w.requiresRestart.Load() }
 
 type modifyEntry struct {
+	author     Author
 	modifyFunc ModifyFunction
 	res        chan modifyResult
 }
@@ -33,20 +33,22 @@ func (d *relayDialer) Dial(ctx context.Context, id protocol.DeviceID, uri *url.U
 		return internalConn{}, err
 	}
 
-	conn, err := client.JoinSession(ctx, inv)
+	conn, err := client.JoinSession(ctx, uri, inv)
 	if err != nil {
 		return internalConn{}, err
 	}
 
-	err = dialer.SetTCPOptions(conn)
-	if err != nil {
-		conn.Close()
-		return internalConn{}, err
-	}
-
-	err = dialer.SetTrafficClass(conn, d.trafficClass)
-	if err != nil {
-		l.Debugln("Dial (BEP/relay): setting traffic class:", err)
+	if !client.IsQUIC(conn) {
+		err = dialer.SetTCPOptions(conn)
+		if err != nil {
+			conn.Close()
+			return internalConn{}, err
+		}
+
+		err = dialer.SetTrafficClass(conn, d.trafficClass)
+		if err != nil {
+			l.Debugln("Dial (BEP/relay): setting traffic class:", err)
+		}
 	}
 
 	var tc *tls.Conn
@@ -12,6 +12,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -43,10 +44,31 @@ type relayListener struct {
 
 	client client.RelayClient
 	mut    sync.RWMutex
+
+	// peerRelayHosts returns the relay hosts used by our peers; optional.
+	peerRelayHosts func() []string
 }
 
+// maxRelayHints is the maximum number of peer relay hosts passed to a relay
+// pool as hints.
+const maxRelayHints = 8
+
 func (t *relayListener) serve(ctx context.Context) error {
-	clnt, err := client.NewClient(t.uri, t.tlsCfg.Certificates, 10*time.Second)
+	uri := t.uri
+	if strings.HasPrefix(uri.Scheme, "dynamic+") && t.peerRelayHosts != nil {
+		// Ask the pool for relays near both us and our peers.
+		if hosts := t.peerRelayHosts(); len(hosts) > 0 {
+			hinted := *uri
+			q := hinted.Query()
+			for _, host := range hosts {
+				q.Add("near", host)
+			}
+			hinted.RawQuery = q.Encode()
+			uri = &hinted
+		}
+	}
+
+	clnt, err := client.NewClient(uri, t.tlsCfg.Certificates, 10*time.Second)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to listen (relay)", slogutil.Error(err))
 		return err
@@ -76,7 +98,7 @@ func (t *relayListener) handleInvitations(ctx context.Context, clnt client.Relay
 	for {
 		select {
 		case inv := <-invitations:
-			conn, err := client.JoinSession(ctx, inv)
+			conn, err := client.JoinSession(ctx, clnt.URI(), inv)
 			if err != nil {
 				if !errors.Is(err, context.Canceled) {
 					slog.InfoContext(ctx, "Failed to join session", slogutil.Error(err))
@@ -84,14 +106,16 @@ func (t *relayListener) handleInvitations(ctx context.Context, clnt client.Relay
 				continue
 			}
 
-			err = dialer.SetTCPOptions(conn)
-			if err != nil {
-				slog.DebugContext(ctx, "Failed to set TCP options", slogutil.Error(err))
-			}
+			if !client.IsQUIC(conn) {
+				err = dialer.SetTCPOptions(conn)
+				if err != nil {
+					slog.DebugContext(ctx, "Failed to set TCP options", slogutil.Error(err))
+				}
 
-			err = dialer.SetTrafficClass(conn, t.cfg.Options().TrafficClass)
-			if err != nil {
-				slog.DebugContext(ctx, "Failed to set traffic class", slogutil.Error(err))
+				err = dialer.SetTrafficClass(conn, t.cfg.Options().TrafficClass)
+				if err != nil {
+					slog.DebugContext(ctx, "Failed to set traffic class", slogutil.Error(err))
+				}
 			}
 
 			var tc *tls.Conn
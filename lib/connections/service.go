@@ -44,6 +44,7 @@ import (
 	"github.com/syncthing/syncthing/lib/svcutil"
 
 	// Registers NAT service providers
+	_ "github.com/syncthing/syncthing/lib/pcp"
 	_ "github.com/syncthing/syncthing/lib/pmp"
 	_ "github.com/syncthing/syncthing/lib/upnp"
 )
@@ -724,6 +725,46 @@ func (s *service) resolveDeviceAddrs(ctx context.Context, cfg config.DeviceConfi
 	return stringutil.UniqueTrimmedStrings(addrs)
 }
 
+// peerRelayHosts returns the hosts of the relays our peers are reachable
+// over, for use as hints when picking a relay from a pool. A relay close
+// to both us and our peers is better than one close to just us. Only
+// configured and already discovered addresses are considered, as this
+// must not wait for discovery lookups.
+func (s *service) peerRelayHosts() []string {
+	var cache map[protocol.DeviceID]discover.CacheEntry
+	if s.discoverer != nil {
+		cache = s.discoverer.Cache()
+	}
+
+	var hosts []string
+	for id, cfg := range s.cfg.Devices() {
+		if id == s.myID || cfg.Paused {
+			continue
+		}
+		addrs := slices.Clone(cfg.Addresses)
+		if slices.Contains(addrs, "dynamic") {
+			addrs = append(addrs, cache[id].Addresses...)
+		}
+		for _, addr := range addrs {
+			uri, err := url.Parse(addr)
+			if err != nil || uri.Scheme != "relay" {
+				continue
+			}
+			host, _, err := net.SplitHostPort(uri.Host)
+			if err != nil {
+				continue
+			}
+			if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() && !slices.Contains(hosts, host) {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) >= maxRelayHints {
+			break
+		}
+	}
+	return hosts
+}
+
 type lanChecker struct {
 	cfg config.Wrapper
 }
@@ -800,6 +841,9 @@ func (s *service) createListener(factory listenerFactory, uri *url.URL) bool {
 	slog.Debug("Starting listener", "uri", uri)
 
 	listener := factory.New(uri, s.cfg, s.tlsCfg, s.conns, s.natService, s.registry, s.lanChecker)
+	if rl, ok := listener.(*relayListener); ok {
+		rl.peerRelayHosts = s.peerRelayHosts
+	}
 	listener.OnAddressesChanged(s.logListenAddressesChangedEvent)
 
 	// Retrying a listener many times in rapid succession is unlikely to help,
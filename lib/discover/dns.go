@@ -0,0 +1,170 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	dnsScheme            = "dns"
+	dnsDevicePlaceholder = "{device}"
+	dnsZonePlaceholder   = "{zone}"
+	dnsDefaultTemplate   = dnsDevicePlaceholder + "." + dnsZonePlaceholder
+	dnsNotFoundCacheTime = 5 * time.Minute
+)
+
+// The dnsResolver is the subset of *net.Resolver that we use, broken out
+// for testability.
+type dnsResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// A dnsClient is a lookup-only Finder that resolves device addresses from
+// records published in a DNS zone. It is configured as a global discovery
+// server with the "dns" scheme, i.e. "dns://sync.example.com". For each
+// lookup the device ID is substituted into a name template, by default
+// "{device}.{zone}", which can be overridden with the "name" query
+// parameter, i.e. "dns://example.com/?name={device}._syncthing.{zone}".
+//
+// Addresses are read from TXT records at that name, where each string is a
+// whitespace separated list of addresses in the usual URL form
+// ("tcp://192.0.2.42:22000", "quic://...", "relay://..."). SRV records for
+// "_syncthing._tcp" and "_syncthing._udp" under the same name are
+// translated into "tcp://" and "quic://" addresses respectively.
+type dnsClient struct {
+	server   string
+	zone     string
+	template string
+	resolver dnsResolver
+}
+
+func NewDNS(server string) (Finder, error) {
+	zone, template, err := parseDNSOptions(server)
+	if err != nil {
+		return nil, err
+	}
+	return &dnsClient{
+		server:   server,
+		zone:     zone,
+		template: template,
+		resolver: net.DefaultResolver,
+	}, nil
+}
+
+// isDNSDiscovery returns true if the given global discovery server string
+// refers to DNS based discovery.
+func isDNSDiscovery(server string) bool {
+	return strings.HasPrefix(server, dnsScheme+"://")
+}
+
+// parseDNSOptions returns the zone and name template for the given
+// "dns://" discovery server string.
+func parseDNSOptions(server string) (zone, template string, err error) {
+	p, err := url.Parse(server)
+	if err != nil {
+		return "", "", err
+	}
+	if p.Scheme != dnsScheme {
+		return "", "", errors.New("unsupported scheme " + p.Scheme)
+	}
+
+	zone = strings.Trim(strings.ToLower(p.Hostname()), ".")
+	if zone == "" {
+		return "", "", errors.New("missing DNS zone")
+	}
+
+	template = p.Query().Get("name")
+	if template == "" {
+		template = dnsDefaultTemplate
+	}
+	if !strings.Contains(template, dnsDevicePlaceholder) {
+		return "", "", fmt.Errorf("name template %q lacks %s", template, dnsDevicePlaceholder)
+	}
+
+	return zone, template, nil
+}
+
+// nameFor returns the DNS name at which the records for the given device
+// are expected.
+func (c *dnsClient) nameFor(device protocol.DeviceID) string {
+	name := strings.ReplaceAll(c.template, dnsZonePlaceholder, c.zone)
+	// DNS names are case insensitive; the canonical device ID is exactly
+	// 63 characters long and thus fits in a single label.
+	return strings.ReplaceAll(name, dnsDevicePlaceholder, strings.ToLower(device.String()))
+}
+
+// Lookup returns the list of addresses where the given device is available
+func (c *dnsClient) Lookup(ctx context.Context, device protocol.DeviceID) (addresses []string, err error) {
+	name := c.nameFor(device)
+
+	txts, txtErr := c.resolver.LookupTXT(ctx, name)
+	if txtErr == nil {
+		for _, txt := range txts {
+			for _, addr := range strings.Fields(txt) {
+				if _, err := url.Parse(addr); err != nil {
+					slog.DebugContext(ctx, "Ignoring invalid address in TXT record", "name", name, "address", addr, slogutil.Error(err))
+					continue
+				}
+				addresses = append(addresses, addr)
+			}
+		}
+	}
+
+	srvErr := txtErr
+	for _, srv := range []struct{ proto, scheme string }{{"tcp", "tcp"}, {"udp", "quic"}} {
+		_, recs, err := c.resolver.LookupSRV(ctx, "syncthing", srv.proto, name)
+		if err != nil {
+			continue
+		}
+		srvErr = nil
+		for _, rec := range recs {
+			host := strings.TrimSuffix(rec.Target, ".")
+			addresses = append(addresses, fmt.Sprintf("%s://%s", srv.scheme, net.JoinHostPort(host, strconv.Itoa(int(rec.Port)))))
+		}
+	}
+
+	if txtErr != nil && srvErr != nil {
+		slog.DebugContext(ctx, "dnsClient.Lookup", "name", name, slogutil.Error(txtErr))
+		var dnsErr *net.DNSError
+		if errors.As(txtErr, &dnsErr) && dnsErr.IsNotFound {
+			return nil, &lookupError{
+				msg:      txtErr.Error(),
+				cacheFor: dnsNotFoundCacheTime,
+			}
+		}
+		return nil, txtErr
+	}
+
+	return addresses, nil
+}
+
+func (*dnsClient) Error() error {
+	// There is no background activity to fail.
+	return nil
+}
+
+func (c *dnsClient) String() string {
+	return "dns@" + c.server
+}
+
+func (*dnsClient) Cache() map[protocol.DeviceID]CacheEntry {
+	// The dnsClient doesn't do caching; the manager does that for us.
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discover
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestParseDNSOptions(t *testing.T) {
+	testcases := []struct {
+		in       string
+		zone     string
+		template string
+		ok       bool
+	}{
+		{"dns://sync.example.com", "sync.example.com", dnsDefaultTemplate, true},
+		{"dns://Sync.Example.com./", "sync.example.com", dnsDefaultTemplate, true},
+		{"dns://example.com/?name={device}._syncthing.{zone}", "example.com", "{device}._syncthing.{zone}", true},
+		{"dns://example.com/?name=foo.{zone}", "", "", false},
+		{"dns:///", "", "", false},
+		{"https://example.com/", "", "", false},
+	}
+
+	for _, tc := range testcases {
+		zone, template, err := parseDNSOptions(tc.in)
+		if (err == nil) != tc.ok {
+			t.Errorf("Unexpected error state %v for %v", err, tc.in)
+			continue
+		}
+		if zone != tc.zone || template != tc.template {
+			t.Errorf("Incorrect result %q, %q for %v", zone, template, tc.in)
+		}
+	}
+}
+
+type fakeResolver struct {
+	txt map[string][]string
+	srv map[string][]*net.SRV
+}
+
+func (r *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txts, ok := r.txt[name]; ok {
+		return txts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	cname := "_" + service + "._" + proto + "." + name
+	if recs, ok := r.srv[cname]; ok {
+		return cname, recs, nil
+	}
+	return "", nil, &net.DNSError{Err: "no such host", Name: cname, IsNotFound: true}
+}
+
+func TestDNSLookup(t *testing.T) {
+	dev := protocol.LocalDeviceID
+	name := strings.ToLower(dev.String()) + ".sync.example.com"
+
+	f, err := NewDNS("dns://sync.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := f.(*dnsClient)
+	c.resolver = &fakeResolver{
+		txt: map[string][]string{
+			name: {"tcp://192.0.2.42:22000 quic://192.0.2.42:22000", "relay://192.0.2.43:22067"},
+		},
+		srv: map[string][]*net.SRV{
+			"_syncthing._tcp." + name: {{Target: "host.example.com.", Port: 22001}},
+		},
+	}
+
+	addrs, err := c.Lookup(context.Background(), dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"quic://192.0.2.42:22000",
+		"relay://192.0.2.43:22067",
+		"tcp://192.0.2.42:22000",
+		"tcp://host.example.com:22001",
+	}
+	slices.Sort(addrs)
+	if !slices.Equal(addrs, expected) {
+		t.Errorf("Incorrect addresses %v != %v", addrs, expected)
+	}
+
+	// An unknown device results in a cacheable error.
+	_, err = c.Lookup(context.Background(), protocol.EmptyDeviceID)
+	var cerr cachedError
+	if !errors.As(err, &cerr) || cerr.CacheFor() != dnsNotFoundCacheTime {
+		t.Errorf("Expected cacheable not-found error, got %v", err)
+	}
+}
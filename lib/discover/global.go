@@ -72,7 +72,9 @@ type serverOptions struct {
 	insecure   bool   // don't check certificate
 	noAnnounce bool   // don't announce
 	noLookup   bool   // don't use for lookups
+	private    bool   // present our certificate for lookups
 	id         string // expected server device ID
+	token      string // access token issued for our device ID
 }
 
 // A lookupError is any other error but with a cache validity time attached.
@@ -110,7 +112,7 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 	} else {
 		dialContext = dialer.DialContext
 	}
-	var announceClient httpClient = &contextClient{&http.Client{
+	var announceClient httpClient = &contextClient{Client: &http.Client{
 		Timeout: requestTimeout,
 		Transport: http2EnabledTransport(&http.Transport{
 			DialContext:       dialContext,
@@ -123,14 +125,21 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 				ClientSessionCache: tls.NewLRUClientSessionCache(0),
 			},
 		}),
-	}}
+	}, token: opts.token}
 	if opts.id != "" {
 		announceClient = newIDCheckingHTTPClient(announceClient, devID)
 	}
 
 	// The http.Client used for queries. We don't need to present our
-	// certificate here, so lets not include it. May be insecure if requested.
-	var queryClient httpClient = &contextClient{&http.Client{
+	// certificate here unless the server is private and requires it, so
+	// lets not include it otherwise. An access token is only valid together
+	// with the certificate of the device it was issued for. May be insecure
+	// if requested.
+	var queryCerts []tls.Certificate
+	if opts.private || opts.token != "" {
+		queryCerts = []tls.Certificate{cert}
+	}
+	var queryClient httpClient = &contextClient{Client: &http.Client{
 		Timeout: requestTimeout,
 		Transport: http2EnabledTransport(&http.Transport{
 			DialContext:     dialer.DialContext,
@@ -138,11 +147,12 @@ func NewGlobal(server string, cert tls.Certificate, addrList AddressLister, evLo
 			IdleConnTimeout: time.Second,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: opts.insecure,
+				Certificates:       queryCerts,
 				MinVersion:         tls.VersionTLS12,
 				ClientSessionCache: tls.NewLRUClientSessionCache(0),
 			},
 		}),
-	}}
+	}, token: opts.token}
 	if opts.id != "" {
 		queryClient = newIDCheckingHTTPClient(queryClient, devID)
 	}
@@ -340,6 +350,8 @@ func parseOptions(dsn string) (server string, opts serverOptions, err error) {
 	opts.insecure = opts.id != "" || queryBool(q, "insecure")
 	opts.noAnnounce = queryBool(q, "noannounce")
 	opts.noLookup = queryBool(q, "nolookup")
+	opts.private = queryBool(q, "private")
+	opts.token = q.Get("token")
 
 	// Check for disallowed combinations
 	if p.Scheme == "http" {
@@ -447,6 +459,7 @@ func (e *errorHolder) Error() error {
 
 type contextClient struct {
 	*http.Client
+	token string // optional bearer token
 }
 
 func (c *contextClient) Get(ctx context.Context, url string) (*http.Response, error) {
@@ -454,6 +467,7 @@ func (c *contextClient) Get(ctx context.Context, url string) (*http.Response, er
 	if err != nil {
 		return nil, err
 	}
+	c.setAuthorization(req)
 	return c.Client.Do(req)
 }
 
@@ -463,9 +477,16 @@ func (c *contextClient) Post(ctx context.Context, url, ctype string, data io.Rea
 		return nil, err
 	}
 	req.Header.Set("Content-Type", ctype)
+	c.setAuthorization(req)
 	return c.Client.Do(req)
 }
 
+func (c *contextClient) setAuthorization(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
 func globalDiscoveryIdentity(addr string) string {
 	return "global discovery server " + addr
 }
@@ -34,6 +34,7 @@ func TestParseOptions(t *testing.T) {
 		{"https://example.com/?insecure=yes", "https://example.com/", serverOptions{insecure: true}},
 		{"https://example.com/?insecure=false&noannounce", "https://example.com/", serverOptions{noAnnounce: true}},
 		{"https://example.com/?id=abc", "https://example.com/", serverOptions{id: "abc", insecure: true}},
+		{"https://example.com/?private&token=s3cret", "https://example.com/", serverOptions{private: true, token: "s3cret"}},
 	}
 
 	for _, tc := range testcases {
@@ -258,6 +258,19 @@ func (m *manager) CommitConfiguration(_, to config.Configuration) (handled bool)
 			if _, ok := m.finders[identity]; ok {
 				continue
 			}
+			if isDNSDiscovery(srv) {
+				dd, err := NewDNS(srv)
+				if err != nil {
+					slog.Warn("Failed to initialize DNS discovery", slogutil.Error(err))
+					continue
+				}
+
+				// DNS lookups get the same cache treatment as global
+				// discovery servers.
+				m.addLocked(identity, dd, 5*time.Minute, time.Minute)
+				continue
+			}
+
 			gd, err := NewGlobal(srv, m.cert, m.addressLister, m.evLogger, m.registry)
 			if err != nil {
 				slog.Warn("Failed to initialize global discovery", slogutil.Error(err))
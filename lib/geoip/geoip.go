@@ -38,8 +38,18 @@ type Provider struct {
 // GeoLite2-City database. The database will be stored in the given
 // directory (which should exist) and refreshed every 7 days.
 func NewGeoLite2CityProvider(ctx context.Context, accountID int, licenseKey string, directory string) (*Provider, error) {
+	return newProvider(ctx, "GeoLite2-City", accountID, licenseKey, directory)
+}
+
+// NewGeoLite2ASNProvider returns a new GeoIP2 database provider for the
+// GeoLite2-ASN database, otherwise like NewGeoLite2CityProvider.
+func NewGeoLite2ASNProvider(ctx context.Context, accountID int, licenseKey string, directory string) (*Provider, error) {
+	return newProvider(ctx, "GeoLite2-ASN", accountID, licenseKey, directory)
+}
+
+func newProvider(ctx context.Context, edition string, accountID int, licenseKey string, directory string) (*Provider, error) {
 	p := &Provider{
-		edition:         "GeoLite2-City",
+		edition:         edition,
 		accountID:       accountID,
 		licenseKey:      licenseKey,
 		refreshInterval: 7 * 24 * time.Hour,
@@ -64,6 +74,17 @@ func (p *Provider) City(ip net.IP) (*geoip2.City, error) {
 	return p.db.City(ip)
 }
 
+func (p *Provider) ASN(ip net.IP) (*geoip2.ASN, error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.db == nil {
+		return nil, errors.New("database not open")
+	}
+
+	return p.db.ASN(ip)
+}
+
 // Serve downloads the GeoIP2 database and keeps it up to date. It will return
 // when the context is canceled.
 func (p *Provider) Serve(ctx context.Context) error {
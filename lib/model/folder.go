@@ -1304,6 +1304,7 @@ func (f *folder) updateLocalsFromScanning(fs []protocol.FileInfo) error {
 		return err
 	}
 	f.emitDiskChangeEvents(fs, events.LocalChangeDetected)
+	f.recordChanges(fs, "local")
 	return nil
 }
 
@@ -1312,6 +1313,7 @@ func (f *folder) updateLocalsFromPulling(fs []protocol.FileInfo) error {
 		return err
 	}
 	f.emitDiskChangeEvents(fs, events.RemoteChangeDetected)
+	f.recordChanges(fs, "remote")
 	return nil
 }
 
@@ -1353,18 +1355,7 @@ func (f *folder) emitDiskChangeEvents(fs []protocol.FileInfo, typeOfEvent events
 			continue
 		}
 
-		objType := "file"
-		action := "modified"
-
-		if file.IsDeleted() {
-			action = "deleted"
-		}
-
-		if file.IsSymlink() {
-			objType = "symlink"
-		} else if file.IsDirectory() {
-			objType = "dir"
-		}
+		action, objType := diskChange(file)
 
 		// Two different events can be fired here based on what EventType is passed into function
 		f.evLogger.Log(typeOfEvent, map[string]string{
@@ -1379,6 +1370,72 @@ func (f *folder) emitDiskChangeEvents(fs []protocol.FileInfo, typeOfEvent events
 	}
 }
 
+// diskChange returns the action and the type of object of the change to
+// the file, as given in events and the change journal.
+func diskChange(file protocol.FileInfo) (action, objType string) {
+	action = "modified"
+	if file.IsDeleted() {
+		action = "deleted"
+	}
+
+	objType = "file"
+	if file.IsSymlink() {
+		objType = "symlink"
+	} else if file.IsDirectory() {
+		objType = "dir"
+	}
+	return action, objType
+}
+
+// recordChanges adds the changes to the change journal, and prunes it
+// according to the retention settings. Failing that isn't fatal to the
+// update, so errors are only logged.
+func (f *folder) recordChanges(fs []protocol.FileInfo, origin string) {
+	opts := f.model.cfg.Options()
+	if opts.ChangeJournalRetentionDays < 0 {
+		return
+	}
+
+	devices := make(map[protocol.ShortID]protocol.DeviceID, len(f.Devices)+1)
+	devices[f.shortID] = f.model.id
+	for _, dev := range f.Devices {
+		devices[dev.DeviceID.Short()] = dev.DeviceID
+	}
+
+	now := time.Now()
+	entries := make([]db.JournalEntry, 0, len(fs))
+	for _, file := range fs {
+		if file.IsInvalid() {
+			continue
+		}
+		action, objType := diskChange(file)
+		entries = append(entries, db.JournalEntry{
+			Time:    now,
+			Name:    file.Name,
+			Action:  action,
+			Type:    objType,
+			Origin:  origin,
+			Device:  devices[file.ModifiedBy],
+			Version: file.Version.String(),
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := f.db.AppendJournal(f.folderID, entries); err != nil {
+		f.sl.Warn("Failed to record changes in journal", slogutil.Error(err))
+		return
+	}
+	var before time.Time
+	if opts.ChangeJournalRetentionDays > 0 {
+		before = now.AddDate(0, 0, -opts.ChangeJournalRetentionDays)
+	}
+	if err := f.db.PruneJournal(f.folderID, before, opts.ChangeJournalMaxEntries); err != nil {
+		f.sl.Warn("Failed to prune change journal", slogutil.Error(err))
+	}
+}
+
 func (f *folder) handleForcedRescans(ctx context.Context) error {
 	f.forcedRescanPathsMut.Lock()
 	paths := make([]string, 0, len(f.forcedRescanPaths))
@@ -13,6 +13,8 @@ import (
 
 	"github.com/d4l3k/messagediff"
 
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/itererr"
 	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/fs"
@@ -183,3 +185,88 @@ func TestSetPlatformData(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestChangeJournal(t *testing.T) {
+	m, f := setupSendReceiveFolder(t)
+
+	scanned := protocol.FileInfo{Name: "scanned", Version: protocol.Vector{}.Update(myID.Short()), ModifiedBy: myID.Short()}
+	pulled := protocol.FileInfo{Name: "dir", Type: protocol.FileInfoTypeDirectory, Version: protocol.Vector{}.Update(device1.Short()), ModifiedBy: device1.Short()}
+	invalid := protocol.FileInfo{Name: "invalid", Version: protocol.Vector{}.Update(device1.Short()), LocalFlags: protocol.FlagLocalIgnored}
+	must(t, f.updateLocalsFromScanning([]protocol.FileInfo{scanned}))
+	pulled.SetDeleted(device1.Short())
+	must(t, f.updateLocalsFromPulling([]protocol.FileInfo{pulled, invalid}))
+
+	entries, err := itererr.Collect(m.ChangeJournal(f.ID, db.JournalFilter{}))
+	must(t, err)
+	if len(entries) != 2 {
+		t.Fatal("expected two entries, got", entries)
+	}
+	if e := entries[0]; e.Name != "scanned" || e.Action != "modified" || e.Type != "file" || e.Origin != "local" || e.Device != myID || e.Version != scanned.Version.String() {
+		t.Error("unexpected entry for scanned file:", e)
+	}
+	if e := entries[1]; e.Name != "dir" || e.Action != "deleted" || e.Type != "dir" || e.Origin != "remote" || e.Device != device1 {
+		t.Error("unexpected entry for pulled dir:", e)
+	}
+
+	// Filtered by device
+	entries, err = itererr.Collect(m.ChangeJournal(f.ID, db.JournalFilter{Device: device1}))
+	must(t, err)
+	if len(entries) != 1 || entries[0].Name != "dir" {
+		t.Error("expected only the pulled dir, got", entries)
+	}
+
+	// Nothing is recorded with the journal disabled
+	waiter, err := m.cfg.Modify(func(cfg *config.Configuration) {
+		cfg.Options.ChangeJournalRetentionDays = -1
+	})
+	must(t, err)
+	waiter.Wait()
+	must(t, f.updateLocalsFromScanning([]protocol.FileInfo{{Name: "other", Version: protocol.Vector{}.Update(myID.Short())}}))
+	entries, err = itererr.Collect(m.ChangeJournal(f.ID, db.JournalFilter{}))
+	must(t, err)
+	if len(entries) != 2 {
+		t.Error("expected no new entries, got", entries)
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	local := []protocol.FileInfo{
+		{Name: "docs", Type: protocol.FileInfoTypeDirectory, Version: protocol.Vector{}.Update(myID.Short())},
+		{Name: filepath.Join("docs", "Annual-Report.pdf"), Size: 10, Version: protocol.Vector{}.Update(myID.Short())},
+		{Name: "ignored-report.tmp", Version: protocol.Vector{}.Update(myID.Short()), LocalFlags: protocol.FlagLocalIgnored},
+	}
+	m, f := setupSendReceiveFolder(t, local...)
+	remote := []protocol.FileInfo{
+		{Name: filepath.Join("docs", "Annual-Report.pdf"), Size: 10, Version: protocol.Vector{}.Update(myID.Short()), Sequence: 1},
+		{Name: "report-draft.txt", Size: 20, Version: protocol.Vector{}.Update(device1.Short()), Sequence: 2},
+		{Name: "ignored-report.tmp", Version: protocol.Vector{}.Update(device1.Short()), Sequence: 3},
+	}
+	must(t, m.sdb.Update(f.ID, device1, remote))
+
+	results, err := m.SearchFiles("", "report", 0)
+	must(t, err)
+	expected := []SearchResult{
+		{Name: filepath.Join("docs", "Annual-Report.pdf"), LocalState: "synced", Availability: []protocol.DeviceID{device1}},
+		{Name: "ignored-report.tmp", LocalState: "ignored", Availability: []protocol.DeviceID{device1}},
+		{Name: "report-draft.txt", LocalState: "needed", Availability: []protocol.DeviceID{device1}},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %+v", len(expected), results)
+	}
+	for i, res := range results {
+		exp := expected[i]
+		if res.Folder != f.ID || res.Name != exp.Name || res.LocalState != exp.LocalState || !slices.Equal(res.Availability, exp.Availability) {
+			t.Errorf("result %d: got %+v, expected %+v", i, res, exp)
+		}
+	}
+
+	// Searching a single folder, with a limit, and an unknown one
+	results, err = m.SearchFiles(f.ID, "annual report", 1)
+	must(t, err)
+	if len(results) != 1 || results[0].Name != expected[0].Name {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if _, err := m.SearchFiles("nonexistent", "report", 0); err == nil {
+		t.Error("expected an error for an unknown folder")
+	}
+}
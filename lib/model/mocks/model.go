@@ -51,12 +51,37 @@ type Model struct {
 		result1 []model.Availability
 		result2 error
 	}
+	BackupDatabaseStub        func(string) error
+	backupDatabaseMutex       sync.RWMutex
+	backupDatabaseArgsForCall []struct {
+		arg1 string
+	}
+	backupDatabaseReturns struct {
+		result1 error
+	}
+	backupDatabaseReturnsOnCall map[int]struct {
+		result1 error
+	}
 	BringToFrontStub        func(string, string)
 	bringToFrontMutex       sync.RWMutex
 	bringToFrontArgsForCall []struct {
 		arg1 string
 		arg2 string
 	}
+	ChangeJournalStub        func(string, db.JournalFilter) (iter.Seq[db.JournalEntry], func() error)
+	changeJournalMutex       sync.RWMutex
+	changeJournalArgsForCall []struct {
+		arg1 string
+		arg2 db.JournalFilter
+	}
+	changeJournalReturns struct {
+		result1 iter.Seq[db.JournalEntry]
+		result2 func() error
+	}
+	changeJournalReturnsOnCall map[int]struct {
+		result1 iter.Seq[db.JournalEntry]
+		result2 func() error
+	}
 	ClosedStub        func(protocol.Connection, error)
 	closedMutex       sync.RWMutex
 	closedArgsForCall []struct {
@@ -601,6 +626,21 @@ type Model struct {
 	scanFoldersReturnsOnCall map[int]struct {
 		result1 map[string]error
 	}
+	SearchFilesStub        func(string, string, int) ([]model.SearchResult, error)
+	searchFilesMutex       sync.RWMutex
+	searchFilesArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}
+	searchFilesReturns struct {
+		result1 []model.SearchResult
+		result2 error
+	}
+	searchFilesReturnsOnCall map[int]struct {
+		result1 []model.SearchResult
+		result2 error
+	}
 	SequenceStub        func(string, protocol.DeviceID) (int64, error)
 	sequenceMutex       sync.RWMutex
 	sequenceArgsForCall []struct {
@@ -838,6 +878,67 @@ func (fake *Model) AvailabilityReturnsOnCall(i int, result1 []model.Availability
 	}{result1, result2}
 }
 
+func (fake *Model) BackupDatabase(arg1 string) error {
+	fake.backupDatabaseMutex.Lock()
+	ret, specificReturn := fake.backupDatabaseReturnsOnCall[len(fake.backupDatabaseArgsForCall)]
+	fake.backupDatabaseArgsForCall = append(fake.backupDatabaseArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.BackupDatabaseStub
+	fakeReturns := fake.backupDatabaseReturns
+	fake.recordInvocation("BackupDatabase", []interface{}{arg1})
+	fake.backupDatabaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Model) BackupDatabaseCallCount() int {
+	fake.backupDatabaseMutex.RLock()
+	defer fake.backupDatabaseMutex.RUnlock()
+	return len(fake.backupDatabaseArgsForCall)
+}
+
+func (fake *Model) BackupDatabaseCalls(stub func(string) error) {
+	fake.backupDatabaseMutex.Lock()
+	defer fake.backupDatabaseMutex.Unlock()
+	fake.BackupDatabaseStub = stub
+}
+
+func (fake *Model) BackupDatabaseArgsForCall(i int) string {
+	fake.backupDatabaseMutex.RLock()
+	defer fake.backupDatabaseMutex.RUnlock()
+	argsForCall := fake.backupDatabaseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Model) BackupDatabaseReturns(result1 error) {
+	fake.backupDatabaseMutex.Lock()
+	defer fake.backupDatabaseMutex.Unlock()
+	fake.BackupDatabaseStub = nil
+	fake.backupDatabaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Model) BackupDatabaseReturnsOnCall(i int, result1 error) {
+	fake.backupDatabaseMutex.Lock()
+	defer fake.backupDatabaseMutex.Unlock()
+	fake.BackupDatabaseStub = nil
+	if fake.backupDatabaseReturnsOnCall == nil {
+		fake.backupDatabaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.backupDatabaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Model) BringToFront(arg1 string, arg2 string) {
 	fake.bringToFrontMutex.Lock()
 	fake.bringToFrontArgsForCall = append(fake.bringToFrontArgsForCall, struct {
@@ -871,6 +972,71 @@ func (fake *Model) BringToFrontArgsForCall(i int) (string, string) {
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *Model) ChangeJournal(arg1 string, arg2 db.JournalFilter) (iter.Seq[db.JournalEntry], func() error) {
+	fake.changeJournalMutex.Lock()
+	ret, specificReturn := fake.changeJournalReturnsOnCall[len(fake.changeJournalArgsForCall)]
+	fake.changeJournalArgsForCall = append(fake.changeJournalArgsForCall, struct {
+		arg1 string
+		arg2 db.JournalFilter
+	}{arg1, arg2})
+	stub := fake.ChangeJournalStub
+	fakeReturns := fake.changeJournalReturns
+	fake.recordInvocation("ChangeJournal", []interface{}{arg1, arg2})
+	fake.changeJournalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Model) ChangeJournalCallCount() int {
+	fake.changeJournalMutex.RLock()
+	defer fake.changeJournalMutex.RUnlock()
+	return len(fake.changeJournalArgsForCall)
+}
+
+func (fake *Model) ChangeJournalCalls(stub func(string, db.JournalFilter) (iter.Seq[db.JournalEntry], func() error)) {
+	fake.changeJournalMutex.Lock()
+	defer fake.changeJournalMutex.Unlock()
+	fake.ChangeJournalStub = stub
+}
+
+func (fake *Model) ChangeJournalArgsForCall(i int) (string, db.JournalFilter) {
+	fake.changeJournalMutex.RLock()
+	defer fake.changeJournalMutex.RUnlock()
+	argsForCall := fake.changeJournalArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Model) ChangeJournalReturns(result1 iter.Seq[db.JournalEntry], result2 func() error) {
+	fake.changeJournalMutex.Lock()
+	defer fake.changeJournalMutex.Unlock()
+	fake.ChangeJournalStub = nil
+	fake.changeJournalReturns = struct {
+		result1 iter.Seq[db.JournalEntry]
+		result2 func() error
+	}{result1, result2}
+}
+
+func (fake *Model) ChangeJournalReturnsOnCall(i int, result1 iter.Seq[db.JournalEntry], result2 func() error) {
+	fake.changeJournalMutex.Lock()
+	defer fake.changeJournalMutex.Unlock()
+	fake.ChangeJournalStub = nil
+	if fake.changeJournalReturnsOnCall == nil {
+		fake.changeJournalReturnsOnCall = make(map[int]struct {
+			result1 iter.Seq[db.JournalEntry]
+			result2 func() error
+		})
+	}
+	fake.changeJournalReturnsOnCall[i] = struct {
+		result1 iter.Seq[db.JournalEntry]
+		result2 func() error
+	}{result1, result2}
+}
+
 func (fake *Model) Closed(arg1 protocol.Connection, arg2 error) {
 	fake.closedMutex.Lock()
 	fake.closedArgsForCall = append(fake.closedArgsForCall, struct {
@@ -3479,6 +3645,72 @@ func (fake *Model) ScanFoldersReturnsOnCall(i int, result1 map[string]error) {
 	}{result1}
 }
 
+func (fake *Model) SearchFiles(arg1 string, arg2 string, arg3 int) ([]model.SearchResult, error) {
+	fake.searchFilesMutex.Lock()
+	ret, specificReturn := fake.searchFilesReturnsOnCall[len(fake.searchFilesArgsForCall)]
+	fake.searchFilesArgsForCall = append(fake.searchFilesArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.SearchFilesStub
+	fakeReturns := fake.searchFilesReturns
+	fake.recordInvocation("SearchFiles", []interface{}{arg1, arg2, arg3})
+	fake.searchFilesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Model) SearchFilesCallCount() int {
+	fake.searchFilesMutex.RLock()
+	defer fake.searchFilesMutex.RUnlock()
+	return len(fake.searchFilesArgsForCall)
+}
+
+func (fake *Model) SearchFilesCalls(stub func(string, string, int) ([]model.SearchResult, error)) {
+	fake.searchFilesMutex.Lock()
+	defer fake.searchFilesMutex.Unlock()
+	fake.SearchFilesStub = stub
+}
+
+func (fake *Model) SearchFilesArgsForCall(i int) (string, string, int) {
+	fake.searchFilesMutex.RLock()
+	defer fake.searchFilesMutex.RUnlock()
+	argsForCall := fake.searchFilesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *Model) SearchFilesReturns(result1 []model.SearchResult, result2 error) {
+	fake.searchFilesMutex.Lock()
+	defer fake.searchFilesMutex.Unlock()
+	fake.SearchFilesStub = nil
+	fake.searchFilesReturns = struct {
+		result1 []model.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Model) SearchFilesReturnsOnCall(i int, result1 []model.SearchResult, result2 error) {
+	fake.searchFilesMutex.Lock()
+	defer fake.searchFilesMutex.Unlock()
+	fake.SearchFilesStub = nil
+	if fake.searchFilesReturnsOnCall == nil {
+		fake.searchFilesReturnsOnCall = make(map[int]struct {
+			result1 []model.SearchResult
+			result2 error
+		})
+	}
+	fake.searchFilesReturnsOnCall[i] = struct {
+		result1 []model.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Model) Sequence(arg1 string, arg2 protocol.DeviceID) (int64, error) {
 	fake.sequenceMutex.Lock()
 	ret, specificReturn := fake.sequenceReturnsOnCall[len(fake.sequenceArgsForCall)]
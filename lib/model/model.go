@@ -105,6 +105,8 @@ type Model interface {
 	Sequence(folder string, device protocol.DeviceID) (int64, error)
 	AllGlobalFiles(folder string) (iter.Seq[db.FileMetadata], func() error)
 	RemoteSequences(folder string) (map[protocol.DeviceID]int64, error)
+	ChangeJournal(folder string, filter db.JournalFilter) (iter.Seq[db.JournalEntry], func() error)
+	BackupDatabase(dir string) error
 
 	NeedFolderFiles(folder string, page, perpage int) ([]protocol.FileInfo, []protocol.FileInfo, []protocol.FileInfo, error)
 	RemoteNeedFolderFiles(folder string, device protocol.DeviceID, page, perpage int) ([]protocol.FileInfo, error)
@@ -128,6 +130,7 @@ type Model interface {
 	DismissPendingFolder(device protocol.DeviceID, folder string) error
 
 	GlobalDirectoryTree(folder, prefix string, levels int, dirsOnly bool) ([]*TreeEntry, error)
+	SearchFiles(folder, query string, limit int) ([]SearchResult, error)
 
 	RequestGlobal(ctx context.Context, deviceID protocol.DeviceID, folder, name string, blockNo int, offset int64, size int, hash []byte, fromTemporary bool) ([]byte, error)
 }
@@ -988,6 +991,14 @@ func (m *model) RemoteSequences(folder string) (map[protocol.DeviceID]int64, err
 	return m.sdb.RemoteSequences(folder)
 }
 
+func (m *model) ChangeJournal(folder string, filter db.JournalFilter) (iter.Seq[db.JournalEntry], func() error) {
+	return m.sdb.AllJournalEntries(folder, filter)
+}
+
+func (m *model) BackupDatabase(dir string) error {
+	return m.sdb.Backup(dir)
+}
+
 func (m *model) FolderProgressBytesCompleted(folder string) int64 {
 	return m.progressEmitter.BytesCompleted(folder)
 }
@@ -1259,13 +1270,21 @@ func (m *model) ClusterConfig(conn protocol.Connection, cm *protocol.ClusterConf
 	}
 
 	// Needs to happen outside of the mut, as can cause CommitConfiguration
-	if deviceCfg.AutoAcceptFolders {
+	if deviceCfg.AutoAcceptFolders || len(m.cfg.AutoAcceptRules()) > 0 {
 		w, _ := m.cfg.Modify(func(cfg *config.Configuration) {
 			changedFcfg := make(map[string]config.FolderConfiguration)
 			haveFcfg := cfg.FolderMap()
 			for _, folder := range cm.Folders {
+				rule, matched := config.MatchAutoAcceptRule(cfg.AutoAcceptRules, folder.ID, folder.Label, deviceCfg)
+				if !matched && !deviceCfg.AutoAcceptFolders {
+					continue
+				}
+				var rulePtr *config.AutoAcceptRule
+				if matched {
+					rulePtr = &rule
+				}
 				from, ok := haveFcfg[folder.ID]
-				if to, changed := m.handleAutoAccepts(deviceID, folder, ccDeviceInfos[folder.ID], from, ok, cfg.Defaults.Folder); changed {
+				if to, changed := m.handleAutoAccepts(deviceCfg, folder, ccDeviceInfos[folder.ID], from, ok, cfg.Defaults.Folder, rulePtr); changed {
 					changedFcfg[folder.ID] = to
 				}
 			}
@@ -1761,30 +1780,52 @@ func (*model) handleDeintroductions(introducerCfg config.DeviceConfiguration, fo
 }
 
 // handleAutoAccepts handles adding and sharing folders for devices that have
-// AutoAcceptFolders set to true.
-func (m *model) handleAutoAccepts(deviceID protocol.DeviceID, folder protocol.Folder, ccDeviceInfos *clusterConfigDeviceInfo, cfg config.FolderConfiguration, haveCfg bool, defaultFolderCfg config.FolderConfiguration) (config.FolderConfiguration, bool) {
+// AutoAcceptFolders set to true, or as the matching auto-accept rule says.
+func (m *model) handleAutoAccepts(deviceCfg config.DeviceConfiguration, folder protocol.Folder, ccDeviceInfos *clusterConfigDeviceInfo, cfg config.FolderConfiguration, haveCfg bool, defaultFolderCfg config.FolderConfiguration, rule *config.AutoAcceptRule) (config.FolderConfiguration, bool) {
+	deviceID := deviceCfg.DeviceID
+	encrypted := len(ccDeviceInfos.remote.EncryptionPasswordToken) > 0 || len(ccDeviceInfos.local.EncryptionPasswordToken) > 0
 	if !haveCfg {
-		defaultPathFs := fs.NewFilesystem(defaultFolderCfg.FilesystemType.ToFS(), defaultFolderCfg.Path)
-		var pathAlternatives []string
-		if alt := fs.SanitizePath(folder.Label); alt != "" {
-			pathAlternatives = append(pathAlternatives, alt)
+		if rule != nil && rule.ReceiveEncrypted != encrypted {
+			slog.Info("Not auto-accepting folder as the rule and the remote disagree on encryption", folder.LogAttr(), deviceID.LogAttr(), slog.String("rule", rule.Name), slog.Bool("encrypted", encrypted))
+			return config.FolderConfiguration{}, false
 		}
-		if alt := fs.SanitizePath(folder.ID); alt != "" {
-			pathAlternatives = append(pathAlternatives, alt)
+
+		// Paths are relative to the root, the default folder path unless
+		// the rule gives one elsewhere.
+		root := defaultFolderCfg.Path
+		var pathAlternatives []string
+		if rule != nil && rule.Path != "" {
+			path, err := rule.FolderPath(folder.ID, folder.Label, deviceCfg)
+			if err != nil {
+				slog.Error("Failed to auto-accept folder", folder.LogAttr(), deviceID.LogAttr(), slog.String("rule", rule.Name), slogutil.Error(err))
+				return config.FolderConfiguration{}, false
+			}
+			if filepath.IsAbs(path) || strings.HasPrefix(path, "~") {
+				root, path = filepath.Dir(path), filepath.Base(path)
+			}
+			pathAlternatives = append(pathAlternatives, path)
+		} else {
+			if alt := fs.SanitizePath(folder.Label); alt != "" {
+				pathAlternatives = append(pathAlternatives, alt)
+			}
+			if alt := fs.SanitizePath(folder.ID); alt != "" {
+				pathAlternatives = append(pathAlternatives, alt)
+			}
 		}
 		if len(pathAlternatives) == 0 {
 			slog.Error("Failed to auto-accept folder due to lack of path alternatives", folder.LogAttr(), deviceID.LogAttr())
 			return config.FolderConfiguration{}, false
 		}
+		rootFs := fs.NewFilesystem(defaultFolderCfg.FilesystemType.ToFS(), root)
 		for _, path := range pathAlternatives {
 			// Make sure the folder path doesn't already exist.
-			if _, err := defaultPathFs.Lstat(path); !fs.IsNotExist(err) {
+			if _, err := rootFs.Lstat(path); !fs.IsNotExist(err) {
 				continue
 			}
 
 			// Attempt to create it to make sure it does, now.
-			fullPath := filepath.Join(defaultFolderCfg.Path, path)
-			if err := defaultPathFs.MkdirAll(path, fs.ModePerm); err != nil {
+			fullPath := filepath.Join(root, path)
+			if err := rootFs.MkdirAll(path, fs.ModePerm); err != nil {
 				slog.Error("Failed to create path for auto-accepted folder", folder.LogAttr(), slogutil.FilePath(fullPath), slogutil.Error(err))
 				continue
 			}
@@ -1794,7 +1835,7 @@ func (m *model) handleAutoAccepts(deviceID protocol.DeviceID, folder protocol.Fo
 				DeviceID: deviceID,
 			})
 
-			if len(ccDeviceInfos.remote.EncryptionPasswordToken) > 0 || len(ccDeviceInfos.local.EncryptionPasswordToken) > 0 {
+			if encrypted {
 				fcfg.Type = config.FolderTypeReceiveEncrypted
 				// Override the user-configured defaults, as normally done by the GUI
 				fcfg.FSWatcherEnabled = false
@@ -1807,6 +1848,12 @@ func (m *model) handleAutoAccepts(deviceID protocol.DeviceID, folder protocol.Fo
 				fcfg.Versioning.Reset()
 				// Other necessary settings are ensured by FolderConfiguration itself
 			} else {
+				if rule != nil {
+					fcfg.Type = rule.FolderType
+					if rule.Versioning.Type != "" {
+						fcfg.Versioning = rule.Versioning
+					}
+				}
 				ignores := m.cfg.DefaultIgnores()
 				if err := m.setIgnores(fcfg, ignores.Lines); err != nil {
 					slog.Error("Failed to apply default ignores to auto-accepted folder", folder.LogAttr(), slogutil.FilePath(fullPath), slogutil.Error(err))
@@ -1824,12 +1871,12 @@ func (m *model) handleAutoAccepts(deviceID protocol.DeviceID, folder protocol.Fo
 			return config.FolderConfiguration{}, false
 		}
 		if cfg.Type == config.FolderTypeReceiveEncrypted {
-			if len(ccDeviceInfos.remote.EncryptionPasswordToken) == 0 && len(ccDeviceInfos.local.EncryptionPasswordToken) == 0 {
+			if !encrypted {
 				slog.Info("Failed to auto-accept device on existing folder as the remote wants to send us unencrypted data, but the folder type is receive-encrypted", folder.LogAttr(), deviceID.LogAttr())
 				return config.FolderConfiguration{}, false
 			}
 		} else {
-			if len(ccDeviceInfos.remote.EncryptionPasswordToken) > 0 || len(ccDeviceInfos.local.EncryptionPasswordToken) > 0 {
+			if encrypted {
 				slog.Info("Failed to auto-accept device on existing folder as the remote wants to send us encrypted data, but the folder type is not receive-encrypted", folder.LogAttr(), deviceID.LogAttr())
 				return config.FolderConfiguration{}, false
 			}
@@ -2796,6 +2843,99 @@ func (m *model) GlobalDirectoryTree(folder, prefix string, levels int, dirsOnly
 	return root.Children, nil
 }
 
+// SearchResult is a file found by SearchFiles. The availability is the
+// remote devices having the global version of the file, and the local state
+// is one of "synced", "needed", "ignored", "unsupported", "changed" (for
+// receive only folders) and "missing" (for files neither present nor
+// needed).
+type SearchResult struct {
+	Folder       string              `json:"folder"`
+	FolderLabel  string              `json:"folderLabel"`
+	Name         string              `json:"name"`
+	ModTime      time.Time           `json:"modTime"`
+	Size         int64               `json:"size"`
+	Type         string              `json:"type"`
+	Availability []protocol.DeviceID `json:"availability"`
+	LocalState   string              `json:"localState"`
+}
+
+// SearchFiles returns the global files of the folder, or of all folders if
+// it's empty, that have a name with words starting with each of the words
+// of the query, up to the limit in total if it's positive.
+func (m *model) SearchFiles(folder, query string, limit int) ([]SearchResult, error) {
+	m.mut.RLock()
+	folders := make([]config.FolderConfiguration, 0, len(m.folderCfgs))
+	for id, cfg := range m.folderCfgs {
+		if folder == "" || id == folder {
+			folders = append(folders, cfg)
+		}
+	}
+	m.mut.RUnlock()
+	if folder != "" && len(folders) == 0 {
+		return nil, ErrFolderMissing
+	}
+	slices.SortFunc(folders, func(a, b config.FolderConfiguration) int { return strings.Compare(a.ID, b.ID) })
+
+	results := make([]SearchResult, 0)
+	for _, fcfg := range folders {
+		remaining := 0
+		if limit > 0 {
+			remaining = limit - len(results)
+			if remaining <= 0 {
+				break
+			}
+		}
+		files, err := itererr.Collect(m.sdb.SearchGlobalFiles(fcfg.ID, query, remaining))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsInvalid() {
+				continue
+			}
+			availability, err := m.sdb.GetGlobalAvailability(fcfg.ID, f.Name)
+			if err != nil {
+				return nil, err
+			}
+			state, err := m.localSearchState(fcfg.ID, f)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, SearchResult{
+				Folder:       fcfg.ID,
+				FolderLabel:  fcfg.Label,
+				Name:         f.Name,
+				ModTime:      f.ModTime(),
+				Size:         f.Size,
+				Type:         f.Type.String(),
+				Availability: availability,
+				LocalState:   state,
+			})
+		}
+	}
+	return results, nil
+}
+
+func (m *model) localSearchState(folder string, global db.FileMetadata) (string, error) {
+	local, ok, err := m.sdb.GetDeviceFile(folder, protocol.LocalDeviceID, global.Name)
+	switch {
+	case err != nil:
+		return "", err
+	case ok && local.IsIgnored():
+		return "ignored", nil
+	case ok && local.IsUnsupported():
+		return "unsupported", nil
+	case global.LocalFlags&protocol.FlagLocalNeeded != 0:
+		return "needed", nil
+	case !ok:
+		return "missing", nil
+	case local.IsReceiveOnlyChanged():
+		return "changed", nil
+	default:
+		return "synced", nil
+	}
+}
+
 func (m *model) GetFolderVersions(folder string) (map[string][]versioner.FileVersion, error) {
 	m.mut.RLock()
 	err := m.checkFolderRunningRLocked(folder)
@@ -1505,6 +1505,75 @@ func TestAutoAcceptEnc(t *testing.T) {
 	}
 }
 
+func TestAutoAcceptRules(t *testing.T) {
+	tcfg := defaultAutoAcceptCfg.Copy()
+	tcfg.Devices[1].AutoAcceptFolders = false
+	tcfg.Devices[2].AutoAcceptFolders = false
+	tcfg.Devices[2].Group = "team"
+	tcfg.AutoAcceptRules = []config.AutoAcceptRule{{
+		Name:        "projects",
+		FolderID:    "proj-.*",
+		DeviceGroup: "team",
+		Path:        "projects/%FOLDER_LABEL%/files",
+		FolderType:  config.FolderTypeReceiveOnly,
+		Versioning:  config.VersioningConfiguration{Type: "trashcan"},
+	}}
+	m, cancel := newState(t, tcfg)
+	defer cleanupModel(m)
+	defer cancel()
+
+	clusterConfig := func(deviceID protocol.DeviceID, cm *protocol.ClusterConfig) {
+		conn := newFakeConnection(deviceID, m)
+		m.AddConnection(conn, protocol.Hello{})
+		m.ClusterConfig(conn, cm)
+	}
+
+	// Offered by a device outside the group.
+	clusterConfig(device1, createClusterConfig(device1, "proj-a"))
+	if _, ok := m.cfg.Folder("proj-a"); ok {
+		t.Error("Folder from a device outside the group accepted")
+	}
+
+	// Not matching the folder ID.
+	clusterConfig(device2, createClusterConfig(device2, "other"))
+	if _, ok := m.cfg.Folder("other"); ok {
+		t.Error("Folder not matching the rule accepted")
+	}
+
+	// Sent encrypted, without the rule accepting that.
+	cc := createClusterConfig(device2, "proj-enc")
+	cc.Folders[0].Devices[1].EncryptionPasswordToken = []byte("token")
+	clusterConfig(device2, cc)
+	if _, ok := m.cfg.Folder("proj-enc"); ok {
+		t.Error("Encrypted folder accepted")
+	}
+
+	// With a label leading out of the rule's directory.
+	cc = createClusterConfig(device2, "proj-up")
+	cc.Folders[0].Label = ".."
+	clusterConfig(device2, cc)
+	if _, ok := m.cfg.Folder("proj-up"); ok {
+		t.Error("Folder outside the rule's directory accepted")
+	}
+
+	cc = createClusterConfig(device2, "proj-b")
+	cc.Folders[0].Label = "B"
+	clusterConfig(device2, cc)
+	fcfg, ok := m.cfg.Folder("proj-b")
+	if !ok {
+		t.Fatal("Folder matching the rule not accepted")
+	}
+	if !fcfg.SharedWith(device2) {
+		t.Error("Folder not shared with the offering device")
+	}
+	if !strings.HasSuffix(fcfg.Path, filepath.Join("projects", "B", "files")) {
+		t.Errorf("Folder path %q not from the template", fcfg.Path)
+	}
+	if fcfg.Type != config.FolderTypeReceiveOnly || fcfg.Versioning.Type != "trashcan" {
+		t.Errorf("Folder type %v, versioning %q not from the rule", fcfg.Type, fcfg.Versioning.Type)
+	}
+}
+
 func changeIgnores(t *testing.T, m *testModel, expected []string) {
 	arrEqual := func(a, b []string) bool {
 		if len(a) != len(b) {
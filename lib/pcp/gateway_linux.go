@@ -0,0 +1,56 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build linux
+
+package pcp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// ipv6Gateways returns the next hops of the IPv6 default routes.
+func ipv6Gateways() ([]net.IPAddr, error) {
+	fd, err := os.Open("/proc/net/ipv6_route")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return parseIPv6Routes(fd)
+}
+
+// parseIPv6Routes parses the /proc/net/ipv6_route format, returning the
+// next hops of default routes (destination ::/0).
+func parseIPv6Routes(r io.Reader) ([]net.IPAddr, error) {
+	var res []net.IPAddr
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		// dest destlen src srclen nexthop metric refcnt use flags iface
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 10 {
+			continue
+		}
+		if fields[1] != "00" || strings.Trim(fields[0], "0") != "" {
+			continue
+		}
+		nh, err := hex.DecodeString(fields[4])
+		if err != nil || len(nh) != net.IPv6len {
+			continue
+		}
+		ip := net.IP(nh)
+		if ip.IsUnspecified() {
+			continue
+		}
+		// The zone is also how we find the interface to open pinholes on.
+		res = append(res, net.IPAddr{IP: ip, Zone: fields[9]})
+	}
+	return res, sc.Err()
+}
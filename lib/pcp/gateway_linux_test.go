@@ -0,0 +1,28 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build linux
+
+package pcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIPv6Routes(t *testing.T) {
+	routes := `fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003     eth0
+00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001       lo
+`
+	gws, err := parseIPv6Routes(strings.NewReader(routes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gws) != 1 || gws[0].String() != "fe80::1%eth0" {
+		t.Errorf("unexpected gateways %v", gws)
+	}
+}
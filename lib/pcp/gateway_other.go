@@ -0,0 +1,19 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build !linux
+
+package pcp
+
+import (
+	"errors"
+	"net"
+)
+
+// ipv6Gateways returns the next hops of the IPv6 default routes.
+func ipv6Gateways() ([]net.IPAddr, error) {
+	return nil, errors.New("IPv6 gateway discovery not supported on this platform")
+}
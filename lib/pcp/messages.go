@@ -0,0 +1,168 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package pcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/nat"
+)
+
+const (
+	version = 2
+
+	opAnnounce = 0
+	opMap      = 1
+	opResponse = 0x80 // R bit
+
+	headerLen    = 24
+	mapLen       = 36
+	nonceLen     = 12
+	maxPacketLen = 1100
+
+	initialRetransmit = 250 * time.Millisecond
+	maxRetransmit     = 4 * time.Second
+)
+
+// Result codes, RFC 6887 section 7.4
+var resultCodes = map[byte]string{
+	1:  "UNSUPP_VERSION",
+	2:  "NOT_AUTHORIZED",
+	3:  "MALFORMED_REQUEST",
+	4:  "UNSUPP_OPCODE",
+	5:  "UNSUPP_OPTION",
+	6:  "MALFORMED_OPTION",
+	7:  "NETWORK_FAILURE",
+	8:  "NO_RESOURCES",
+	9:  "UNSUPP_PROTOCOL",
+	10: "USER_EX_QUOTA",
+	11: "CANNOT_PROVIDE_EXTERNAL",
+	12: "ADDRESS_MISMATCH",
+	13: "EXCESSIVE_REMOTE_PEERS",
+}
+
+var errUnrelated = errors.New("unrelated packet")
+
+// ResultError is returned when the server responds with a non-success
+// result code.
+type ResultError struct {
+	Code byte
+}
+
+func (e *ResultError) Error() string {
+	if name, ok := resultCodes[e.Code]; ok {
+		return "PCP error " + name
+	}
+	return fmt.Sprintf("PCP error %d", e.Code)
+}
+
+func protocolNumber(protocol nat.Protocol) byte {
+	switch protocol {
+	case nat.TCP:
+		return 6
+	case nat.UDP:
+		return 17
+	default:
+		return 0
+	}
+}
+
+// putIP writes the IP as a 16 byte address, IPv4 addresses in IPv4-mapped
+// IPv6 form.
+func putIP(bs []byte, ip net.IP) {
+	if ip == nil {
+		ip = net.IPv6zero
+	}
+	copy(bs[:16], ip.To16())
+}
+
+// getIP reads a 16 byte address, returning IPv4-mapped addresses as four
+// byte IPv4 addresses.
+func getIP(bs []byte) net.IP {
+	ip := make(net.IP, 16)
+	copy(ip, bs[:16])
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+func putHeader(bs []byte, opcode byte, lifetime time.Duration, clientIP net.IP) {
+	bs[0] = version
+	bs[1] = opcode
+	binary.BigEndian.PutUint32(bs[4:], uint32(lifetime/time.Second))
+	putIP(bs[8:], clientIP)
+}
+
+func newAnnounceRequest(clientIP net.IP) []byte {
+	bs := make([]byte, headerLen)
+	putHeader(bs, opAnnounce, 0, clientIP)
+	return bs
+}
+
+type mapRequest struct {
+	nonce        [nonceLen]byte
+	protocol     byte
+	clientIP     net.IP
+	internalPort int
+	externalPort int
+	externalIP   net.IP
+	lifetime     time.Duration
+}
+
+func (r *mapRequest) marshal() []byte {
+	bs := make([]byte, headerLen+mapLen)
+	putHeader(bs, opMap, r.lifetime, r.clientIP)
+	m := bs[headerLen:]
+	copy(m, r.nonce[:])
+	m[12] = r.protocol
+	binary.BigEndian.PutUint16(m[16:], uint16(r.internalPort))
+	binary.BigEndian.PutUint16(m[18:], uint16(r.externalPort))
+	putIP(m[20:], r.externalIP)
+	return bs
+}
+
+type mapResponse struct {
+	nonce        [nonceLen]byte
+	lifetime     time.Duration
+	externalPort int
+	externalIP   net.IP
+}
+
+func parseMapResponse(bs []byte) (*mapResponse, error) {
+	if len(bs) < headerLen+mapLen {
+		return nil, errors.New("short MAP response")
+	}
+	resp := &mapResponse{
+		lifetime: time.Duration(binary.BigEndian.Uint32(bs[4:])) * time.Second,
+	}
+	m := bs[headerLen:]
+	copy(resp.nonce[:], m)
+	resp.externalPort = int(binary.BigEndian.Uint16(m[18:]))
+	resp.externalIP = getIP(m[20:])
+	return resp, nil
+}
+
+// checkResponse verifies that bs is a response to a request with the given
+// opcode, returning errUnrelated if it's not a response to us at all and a
+// *ResultError if the server indicates failure.
+func checkResponse(bs []byte, opcode byte) error {
+	if len(bs) < headerLen || bs[1] != opcode|opResponse {
+		return errUnrelated
+	}
+	if bs[0] != version {
+		return &ResultError{Code: 1}
+	}
+	if code := bs[3]; code != 0 {
+		return &ResultError{Code: code}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package pcp implements port mapping and IPv6 firewall pinholes using the
+// Port Control Protocol (RFC 6887).
+package pcp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/nat"
+	"github.com/syncthing/syncthing/lib/netutil"
+	"github.com/syncthing/syncthing/lib/osutil"
+)
+
+func init() {
+	nat.Register(Discover)
+}
+
+const serverPort = 5351
+
+func Discover(ctx context.Context, renewal, timeout time.Duration) []nat.Device {
+	var devices []nat.Device
+
+	// IPv4 port mappings are requested from the default gateway.
+	if ip, err := netutil.Gateway(); err != nil {
+		slog.DebugContext(ctx, "Failed to discover gateway", slogutil.Error(err))
+	} else if ip != nil && !ip.IsUnspecified() {
+		server := &net.UDPAddr{IP: ip, Port: serverPort}
+		if dev := probe(ctx, server, renewal, timeout); dev != nil {
+			devices = append(devices, dev)
+		}
+	}
+
+	// IPv6 pinholes are requested from the IPv6 default router, over IPv6.
+	routers, err := ipv6Gateways()
+	if err != nil {
+		slog.DebugContext(ctx, "Failed to discover IPv6 gateways", slogutil.Error(err))
+	}
+	for _, router := range routers {
+		server := &net.UDPAddr{IP: router.IP, Zone: router.Zone, Port: serverPort}
+		if dev := probe(ctx, server, renewal, timeout); dev != nil {
+			devices = append(devices, dev)
+		}
+	}
+
+	return devices
+}
+
+// probe returns a device for the given server address if it responds to a
+// PCP ANNOUNCE request, or nil.
+func probe(ctx context.Context, server *net.UDPAddr, renewal, timeout time.Duration) *device {
+	dev := newDevice(server, renewal, timeout)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(timeoutCtx, "udp", server.String())
+	if err != nil {
+		slog.DebugContext(ctx, "Failed to dial PCP server", slog.String("server", server.String()), slogutil.Error(err))
+		return nil
+	}
+	conn.Close()
+	dev.localIP, err = osutil.IPFromAddr(conn.LocalAddr())
+	if err != nil {
+		slog.DebugContext(ctx, "Failed to lookup local IP", slogutil.Error(err))
+		return nil
+	}
+
+	// Try contacting the server, if it does not respond, assume it does not
+	// speak PCP.
+	if _, err := dev.exchange(timeoutCtx, nil, newAnnounceRequest(dev.localIP)); err != nil {
+		slog.DebugContext(ctx, "PCP server did not respond", slog.String("server", server.String()), slogutil.Error(err))
+		return nil
+	}
+
+	slog.DebugContext(ctx, "Discovered PCP server", slog.String("server", server.String()))
+	return dev
+}
+
+type mappingKey struct {
+	protocol nat.Protocol
+	ip       string
+	port     int
+}
+
+type device struct {
+	server  *net.UDPAddr
+	renewal time.Duration
+	timeout time.Duration
+	localIP net.IP
+
+	mut        sync.Mutex
+	nonces     map[mappingKey][nonceLen]byte // mapping nonces, kept for renewals
+	externalIP net.IP                        // as assigned in the last IPv4 mapping
+}
+
+func newDevice(server *net.UDPAddr, renewal, timeout time.Duration) *device {
+	return &device{
+		server:  server,
+		renewal: renewal,
+		timeout: timeout,
+		nonces:  make(map[mappingKey][nonceLen]byte),
+	}
+}
+
+func (d *device) ID() string {
+	return "PCP@" + (&net.IPAddr{IP: d.server.IP, Zone: d.server.Zone}).String()
+}
+
+func (d *device) GetLocalIPv4Address() net.IP {
+	if d.isIPv6() {
+		return nil
+	}
+	return d.localIP
+}
+
+func (d *device) SupportsIPVersion(version nat.IPVersion) bool {
+	// Devices reached over IPv6 only open pinholes, devices reached over
+	// IPv4 only do port mappings.
+	if d.isIPv6() {
+		return version == nat.IPv6Only
+	}
+	return version == nat.IPvAny || version == nat.IPv4Only
+}
+
+func (d *device) AddPortMapping(ctx context.Context, protocol nat.Protocol, internalPort, externalPort int, _ string, duration time.Duration) (int, error) {
+	if d.isIPv6() {
+		return 0, errors.New("port mappings are unsupported on IPv6 PCP servers")
+	}
+	resp, err := d.requestMapping(ctx, protocol, d.localIP, internalPort, externalPort, net.IPv4zero, duration)
+	if err != nil {
+		return 0, err
+	}
+	d.mut.Lock()
+	d.externalIP = resp.externalIP
+	d.mut.Unlock()
+	return resp.externalPort, nil
+}
+
+func (d *device) AddPinhole(ctx context.Context, protocol nat.Protocol, intAddr nat.Address, duration time.Duration) ([]net.IP, error) {
+	if !d.isIPv6() {
+		return nil, errors.New("adding IPv6 pinholes is unsupported on IPv4 PCP servers")
+	}
+
+	if !intAddr.IP.IsUnspecified() {
+		// We have an explicit listener address; pinhole only that.
+		if intAddr.IP.To4() != nil {
+			slog.DebugContext(ctx, "Listener is IPv4; not using gateway", "id", d.ID())
+			return nil, nil
+		}
+		if err := d.addPinholeForIP(ctx, protocol, intAddr.IP, intAddr.Port, duration); err != nil {
+			return nil, err
+		}
+		return []net.IP{intAddr.IP}, nil
+	}
+
+	// Otherwise, try to get a pinhole for all global IPs on the interface
+	// towards the router, since we are listening on all.
+	intf, err := net.InterfaceByName(d.server.Zone)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := netutil.InterfaceAddrsByInterface(intf)
+	if err != nil {
+		return nil, err
+	}
+
+	var returnErr error
+	var successfulIPs []net.IP
+	for _, addr := range addrs {
+		ip, _, err := net.ParseCIDR(addr.String())
+		if err != nil {
+			slog.WarnContext(ctx, "Couldn't parse interface address", slogutil.Address(addr), slogutil.Error(err))
+			continue
+		}
+
+		// Note that IsGlobalUnicast allows ULAs.
+		if ip.To4() != nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+			continue
+		}
+
+		if err := d.addPinholeForIP(ctx, protocol, ip, intAddr.Port, duration); err != nil {
+			slog.WarnContext(ctx, "Couldn't add pinhole", slogutil.Address(ip), slog.Int("port", intAddr.Port), slog.Any("protocol", protocol), slogutil.Error(err))
+			returnErr = err
+		} else {
+			successfulIPs = append(successfulIPs, ip)
+		}
+	}
+
+	if len(successfulIPs) > 0 {
+		// (Maybe partial) success, we added a pinhole for at least one GUA.
+		return successfulIPs, nil
+	}
+	return nil, returnErr
+}
+
+func (d *device) addPinholeForIP(ctx context.Context, protocol nat.Protocol, ip net.IP, port int, duration time.Duration) error {
+	// A pinhole is a mapping where the external address equals the
+	// internal address.
+	resp, err := d.requestMapping(ctx, protocol, ip, port, port, ip, duration)
+	if err != nil {
+		return err
+	}
+	if resp.externalPort != port {
+		return fmt.Errorf("pinhole assigned unexpected port %d", resp.externalPort)
+	}
+	return nil
+}
+
+func (d *device) GetExternalIPv4Address(context.Context) (net.IP, error) {
+	// PCP has no separate operation to get the external address; it is
+	// returned in the mapping response.
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	if d.externalIP == nil {
+		return net.IPv4zero, errors.New("no external address known")
+	}
+	return d.externalIP, nil
+}
+
+func (d *device) isIPv6() bool {
+	return d.server.IP.To4() == nil
+}
+
+// requestMapping sends a MAP request for the given internal address and
+// returns the response. The mapping nonce is retained so that subsequent
+// requests for the same mapping are recognized as renewals.
+func (d *device) requestMapping(ctx context.Context, protocol nat.Protocol, clientIP net.IP, internalPort, externalPort int, externalIP net.IP, duration time.Duration) (*mapResponse, error) {
+	// PCP says that if duration is 0, the mapping is actually removed.
+	// Swap the zero with the renewal value, which should make the lease for
+	// the exact amount of time between the calls.
+	if duration == 0 {
+		duration = d.renewal
+	}
+
+	key := mappingKey{protocol: protocol, ip: clientIP.String(), port: internalPort}
+	d.mut.Lock()
+	nonce, ok := d.nonces[key]
+	if !ok {
+		_, _ = rand.Read(nonce[:])
+		d.nonces[key] = nonce
+	}
+	d.mut.Unlock()
+
+	req := &mapRequest{
+		nonce:        nonce,
+		protocol:     protocolNumber(protocol),
+		clientIP:     clientIP,
+		internalPort: internalPort,
+		externalPort: externalPort,
+		externalIP:   externalIP,
+		lifetime:     duration,
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	var laddr *net.UDPAddr
+	if d.isIPv6() {
+		// The server verifies that the client address in the request
+		// matches the source address of the packet.
+		laddr = &net.UDPAddr{IP: clientIP, Zone: d.server.Zone}
+	}
+	bs, err := d.exchange(timeoutCtx, laddr, req.marshal())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := parseMapResponse(bs)
+	if err != nil {
+		return nil, err
+	}
+	if resp.nonce != nonce {
+		return nil, errors.New("mapping nonce mismatch")
+	}
+	return resp, nil
+}
+
+// exchange sends the request to the server and returns the first
+// successful response with the matching opcode. The request is retransmitted
+// with exponential backoff until the context expires.
+func (d *device) exchange(ctx context.Context, laddr *net.UDPAddr, req []byte) ([]byte, error) {
+	conn, err := net.DialUDP("udp", laddr, d.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	buf := make([]byte, maxPacketLen)
+	interval := initialRetransmit
+	for {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(interval))
+
+		for {
+			n, err := conn.Read(buf)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var nerr net.Error
+			if errors.As(err, &nerr) && nerr.Timeout() {
+				break // retransmit
+			} else if err != nil {
+				return nil, err
+			}
+			if err := checkResponse(buf[:n], req[1]); err != nil {
+				if errors.Is(err, errUnrelated) {
+					continue
+				}
+				return nil, err
+			}
+			return buf[:n], nil
+		}
+
+		interval = min(2*interval, maxRetransmit)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package pcp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/nat"
+)
+
+// A responder is a minimal in-process PCP server. It maps each request to
+// externalIP, on the suggested port or nextPort if none was suggested, and
+// verifies that the client address matches the packet source.
+type responder struct {
+	conn       *net.UDPConn
+	externalIP net.IP
+	nextPort   int
+	result     byte
+	requests   chan []byte
+}
+
+func newResponder(t *testing.T, network, addr string, externalIP net.IP) *responder {
+	t.Helper()
+	conn, err := net.ListenUDP(network, &net.UDPAddr{IP: net.ParseIP(addr)})
+	if err != nil {
+		t.Skip("cannot listen:", err)
+	}
+	r := &responder{
+		conn:       conn,
+		externalIP: externalIP,
+		nextPort:   40000,
+		requests:   make(chan []byte, 16),
+	}
+	t.Cleanup(func() { conn.Close() })
+	go r.serve()
+	return r
+}
+
+func (r *responder) serve() {
+	buf := make([]byte, maxPacketLen)
+	for {
+		n, src, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := append([]byte(nil), buf[:n]...)
+		r.requests <- req
+
+		resp := make([]byte, n)
+		copy(resp, req)
+		resp[1] |= opResponse
+		resp[3] = r.result
+		clear(resp[8:headerLen])
+		if !getIP(req[8:]).Equal(src.IP) {
+			resp[3] = 12 // ADDRESS_MISMATCH
+		}
+		if req[1] == opMap && n >= headerLen+mapLen {
+			m := resp[headerLen:]
+			if binary.BigEndian.Uint16(m[18:]) == 0 {
+				binary.BigEndian.PutUint16(m[18:], uint16(r.nextPort))
+			}
+			if ip := getIP(m[20:]); ip.IsUnspecified() {
+				putIP(m[20:], r.externalIP)
+			}
+		}
+		_, _ = r.conn.WriteToUDP(resp, src)
+	}
+}
+
+func (r *responder) addr() *net.UDPAddr {
+	return r.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestPortMapping(t *testing.T) {
+	r := newResponder(t, "udp4", "127.0.0.1", net.ParseIP("192.0.2.42"))
+	ctx := context.Background()
+
+	dev := probe(ctx, r.addr(), time.Minute, time.Second)
+	if dev == nil {
+		t.Fatal("expected device")
+	}
+	<-r.requests // announce
+
+	if !dev.SupportsIPVersion(nat.IPv4Only) || dev.SupportsIPVersion(nat.IPv6Only) {
+		t.Error("IPv4 device should only support IPv4")
+	}
+	if !dev.GetLocalIPv4Address().Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Error("unexpected local address", dev.GetLocalIPv4Address())
+	}
+
+	port, err := dev.AddPortMapping(ctx, nat.TCP, 22000, 0, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 40000 {
+		t.Error("unexpected external port", port)
+	}
+	first := <-r.requests
+	if lifetime := binary.BigEndian.Uint32(first[4:]); lifetime != 60 {
+		t.Error("zero duration should be replaced by renewal, got", lifetime)
+	}
+	if first[headerLen+12] != 6 {
+		t.Error("unexpected protocol number", first[headerLen+12])
+	}
+
+	ip, err := dev.GetExternalIPv4Address(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("192.0.2.42")) {
+		t.Error("unexpected external address", ip)
+	}
+
+	// A renewal uses the same nonce.
+	if _, err := dev.AddPortMapping(ctx, nat.TCP, 22000, port, "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	renewal := <-r.requests
+	if string(first[headerLen:headerLen+nonceLen]) != string(renewal[headerLen:headerLen+nonceLen]) {
+		t.Error("renewal should reuse the mapping nonce")
+	}
+
+	// Errors are reported with their result code.
+	r.result = 8
+	_, err = dev.AddPortMapping(ctx, nat.UDP, 22000, 0, "", time.Hour)
+	var rerr *ResultError
+	if !errors.As(err, &rerr) || !strings.Contains(err.Error(), "NO_RESOURCES") {
+		t.Error("expected NO_RESOURCES error, got", err)
+	}
+}
+
+func TestPinhole(t *testing.T) {
+	r := newResponder(t, "udp6", "::1", nil)
+	ctx := context.Background()
+
+	dev := probe(ctx, r.addr(), time.Minute, time.Second)
+	if dev == nil {
+		t.Fatal("expected device")
+	}
+	<-r.requests // announce
+
+	if dev.SupportsIPVersion(nat.IPv4Only) || !dev.SupportsIPVersion(nat.IPv6Only) {
+		t.Error("IPv6 device should only support IPv6")
+	}
+
+	ips, err := dev.AddPinhole(ctx, nat.TCP, nat.Address{IP: net.IPv6loopback, Port: 22000}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.IPv6loopback) {
+		t.Error("unexpected pinhole addresses", ips)
+	}
+	req := <-r.requests
+	if port := binary.BigEndian.Uint16(req[headerLen+18:]); port != 22000 {
+		t.Error("pinhole should request the internal port, got", port)
+	}
+
+	if _, err := dev.AddPortMapping(ctx, nat.TCP, 22000, 0, "", time.Hour); err == nil {
+		t.Error("port mapping should fail on IPv6 device")
+	}
+}
+
+func TestNoServer(t *testing.T) {
+	// Nothing listens here, so the probe should time out and return nil.
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	if dev := probe(context.Background(), addr, time.Minute, 500*time.Millisecond); dev != nil {
+		t.Error("expected no device")
+	}
+}
@@ -78,7 +78,12 @@ func (c *dynamicClient) serve(ctx context.Context) error {
 		addrs = append(addrs, ruri.String())
 	}
 
-	for _, addr := range relayAddressesOrder(ctx, addrs) {
+	// If we gave the pool hints about where we and our peers are, it
+	// returns the relays ranked by proximity, and we keep that order for
+	// relays with similar latency.
+	ranked := uri.Query().Has("near")
+
+	for _, addr := range relayAddressesOrder(ctx, addrs, ranked) {
 		select {
 		case <-ctx.Done():
 			l.Debugln(c, "stopping")
@@ -138,9 +143,10 @@ type dynamicAnnouncement struct {
 
 // relayAddressesOrder checks the latency to each relay, rounds latency down to
 // the closest 50ms, and puts them in buckets of 50ms latency ranges. Then
-// shuffles each bucket, and returns all addresses starting with the ones from
-// the lowest latency bucket, ending with the highest latency bucket.
-func relayAddressesOrder(ctx context.Context, input []string) []string {
+// shuffles each bucket, unless the input is ranked, and returns all addresses
+// starting with the ones from the lowest latency bucket, ending with the
+// highest latency bucket.
+func relayAddressesOrder(ctx context.Context, input []string, ranked bool) []string {
 	buckets := make(map[int][]string)
 
 	for _, relay := range input {
@@ -162,7 +168,9 @@ func relayAddressesOrder(ctx context.Context, input []string) []string {
 
 	var ids []int
 	for id, bucket := range buckets {
-		rand.Shuffle(bucket)
+		if !ranked {
+			rand.Shuffle(bucket)
+		}
 		ids = append(ids, id)
 	}
 
@@ -18,6 +18,8 @@ import (
 	"github.com/syncthing/syncthing/lib/relay/protocol"
 )
 
+const sessionJoinTimeout = 10 * time.Second
+
 type incorrectResponseCodeErr struct {
 	code int32
 	msg  string
@@ -32,20 +34,10 @@ func GetInvitationFromRelay(ctx context.Context, uri *url.URL, id syncthingproto
 		return protocol.SessionInvitation{}, fmt.Errorf("unsupported relay scheme: %v", uri.Scheme)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-	rconn, err := dialer.DialContext(ctx, "tcp", uri.Host)
+	conn, err := dialRelay(ctx, uri, configForCerts(certs), timeout)
 	if err != nil {
 		return protocol.SessionInvitation{}, err
 	}
-
-	conn := tls.Client(rconn, configForCerts(certs))
-	conn.SetDeadline(time.Now().Add(timeout))
-
-	if err := performHandshakeAndValidation(conn, uri); err != nil {
-		return protocol.SessionInvitation{}, err
-	}
-
 	defer conn.Close()
 
 	request := protocol.ConnectRequest{
@@ -76,12 +68,15 @@ func GetInvitationFromRelay(ctx context.Context, uri *url.URL, id syncthingproto
 	}
 }
 
-func JoinSession(ctx context.Context, invitation protocol.SessionInvitation) (net.Conn, error) {
+// JoinSession connects to the session given in the invitation, received
+// from the relay with the given URI. The session is joined over QUIC if the
+// relay advertises it, falling back to TCP.
+func JoinSession(ctx context.Context, uri *url.URL, invitation protocol.SessionInvitation) (net.Conn, error) {
 	addr := net.JoinHostPort(net.IP(invitation.Address).String(), strconv.Itoa(int(invitation.Port)))
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, sessionJoinTimeout)
 	defer cancel()
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	conn, err := dialSession(ctx, uri, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +85,7 @@ func JoinSession(ctx context.Context, invitation protocol.SessionInvitation) (ne
 		Key: invitation.Key,
 	}
 
-	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	conn.SetDeadline(time.Now().Add(sessionJoinTimeout))
 	err = protocol.WriteMessage(conn, request)
 	if err != nil {
 		return nil, err
@@ -114,6 +109,38 @@ func JoinSession(ctx context.Context, invitation protocol.SessionInvitation) (ne
 	}
 }
 
+func dialSession(ctx context.Context, uri *url.URL, addr string) (net.Conn, error) {
+	if uri != nil && quicAdvertised(uri) {
+		// The session stream itself is not authenticated, the devices
+		// run their own TLS over it. QUIC requires TLS regardless, and we
+		// might as well verify that we're talking to the right relay.
+		cfg := &tls.Config{
+			NextProtos:         []string{protocol.SessionProtocolName},
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS13,
+		}
+		// Leave time for falling back to TCP, in case UDP is blocked.
+		quicCtx, cancel := context.WithTimeout(ctx, sessionJoinTimeout/2)
+		conn, err := dialQUIC(quicCtx, addr, cfg)
+		cancel()
+		if err == nil {
+			err = validateConnectionState(conn.ConnectionState(), uri, protocol.SessionProtocolName)
+			if err == nil {
+				return conn, nil
+			}
+			conn.Close()
+		}
+		l.Debugf("Could not join session at %s over QUIC, falling back to TCP: %s", addr, err)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// quicAdvertised returns true if the relay URI says that the relay accepts
+// QUIC connections on the same address.
+func quicAdvertised(uri *url.URL) bool {
+	return uri.Query().Get("quic") == "1"
+}
+
 func TestRelay(ctx context.Context, uri *url.URL, certs []tls.Certificate, sleep, timeout time.Duration, times int) error {
 	id := syncthingprotocol.NewDeviceID(certs[0].Certificate[0])
 	c, err := NewClient(uri, certs, timeout)
@@ -0,0 +1,33 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build !noquic
+// +build !noquic
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/syncthing/syncthing/lib/relay/quicconn"
+)
+
+func dialQUIC(ctx context.Context, addr string, tlsCfg *tls.Config) (tlsConn, error) {
+	conn, err := quicconn.Dial(ctx, addr, tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// IsQUIC returns true if the connection is a relay connection over QUIC,
+// as opposed to TCP.
+func IsQUIC(conn net.Conn) bool {
+	_, ok := conn.(*quicconn.Conn)
+	return ok
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build !noquic
+// +build !noquic
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	syncthingprotocol "github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/relay/protocol"
+	"github.com/syncthing/syncthing/lib/relay/quicconn"
+	"github.com/syncthing/syncthing/lib/tlsutil"
+)
+
+// testRelayCert returns a certificate and device ID for a test relay.
+func testRelayCert(t *testing.T) (tls.Certificate, syncthingprotocol.DeviceID) {
+	t.Helper()
+	cert, err := tlsutil.NewCertificateInMemory("relay", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, syncthingprotocol.NewDeviceID(cert.Certificate[0])
+}
+
+// testQUICRelay starts a QUIC listener answering session join requests,
+// returning its address.
+func testQUICRelay(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	list, err := quic.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{protocol.ProtocolName, protocol.SessionProtocolName},
+		MinVersion:   tls.VersionTLS13,
+	}, quicconn.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { list.Close() })
+
+	go func() {
+		for {
+			qc, err := list.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				conn, err := quicconn.Accept(context.Background(), qc)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if _, err := protocol.ReadMessage(conn); err != nil {
+					return
+				}
+				_ = protocol.WriteMessage(conn, protocol.ResponseSuccess)
+			}()
+		}
+	}()
+
+	return list.Addr().String()
+}
+
+func testRelayURI(t *testing.T, addr string, id syncthingprotocol.DeviceID) *url.URL {
+	t.Helper()
+	uri, err := url.Parse(fmt.Sprintf("relay://%s/?quic=1&id=%s", addr, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uri
+}
+
+func TestDialRelayQUIC(t *testing.T) {
+	relayCert, relayID := testRelayCert(t)
+	clientCert, _ := testRelayCert(t)
+	addr := testQUICRelay(t, relayCert)
+
+	conn, err := dialRelay(context.Background(), testRelayURI(t, addr, relayID), configForCerts([]tls.Certificate{clientCert}), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if !IsQUIC(conn) {
+		t.Error("expected a QUIC connection")
+	}
+
+	// A relay with the wrong ID is refused, and as there's nothing
+	// listening on TCP the fallback fails as well.
+	_, err = dialRelay(context.Background(), testRelayURI(t, addr, syncthingprotocol.LocalDeviceID), configForCerts([]tls.Certificate{clientCert}), 5*time.Second)
+	if err == nil {
+		t.Error("expected error for wrong relay ID")
+	}
+}
+
+func TestDialRelayTCPFallback(t *testing.T) {
+	relayCert, relayID := testRelayCert(t)
+	clientCert, _ := testRelayCert(t)
+
+	// Only TCP is listening, despite QUIC being advertised.
+	list, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{relayCert},
+		NextProtos:   []string{protocol.ProtocolName},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer list.Close()
+	go func() {
+		for {
+			conn, err := list.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				time.Sleep(time.Second)
+				conn.Close()
+			}()
+		}
+	}()
+
+	conn, err := dialRelay(context.Background(), testRelayURI(t, list.Addr().String(), relayID), configForCerts([]tls.Certificate{clientCert}), 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if IsQUIC(conn) {
+		t.Error("expected a TCP connection")
+	}
+	if _, ok := conn.(*tls.Conn); !ok {
+		t.Errorf("expected TLS connection, got %T", conn)
+	}
+}
+
+func TestJoinSessionQUIC(t *testing.T) {
+	relayCert, relayID := testRelayCert(t)
+	addr := testQUICRelay(t, relayCert)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invitation := protocol.SessionInvitation{
+		Key:     []byte("key"),
+		Address: net.ParseIP(host).To4(),
+		Port:    uint16(portNum),
+	}
+	conn, err := JoinSession(context.Background(), testRelayURI(t, addr, relayID), invitation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if !IsQUIC(conn) {
+		t.Error("expected a QUIC connection")
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build noquic
+// +build noquic
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+)
+
+func dialQUIC(context.Context, string, *tls.Config) (tlsConn, error) {
+	return nil, errors.New("QUIC disabled at build time")
+}
+
+// IsQUIC returns true if the connection is a relay connection over QUIC,
+// as opposed to TCP.
+func IsQUIC(net.Conn) bool {
+	return false
+}
@@ -29,7 +29,7 @@ type staticClient struct {
 	messageTimeout time.Duration
 	connectTimeout time.Duration
 
-	conn  *tls.Conn
+	conn  net.Conn
 	token string
 }
 
@@ -139,37 +139,73 @@ func (c *staticClient) connect(ctx context.Context) error {
 		return fmt.Errorf("unsupported relay scheme: %v", c.uri.Scheme)
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, c.connectTimeout)
-	defer cancel()
-	tcpConn, err := dialer.DialContext(timeoutCtx, "tcp", c.uri.Host)
+	conn, err := dialRelay(ctx, c.uri, c.config, c.connectTimeout)
 	if err != nil {
 		return err
 	}
 
+	c.conn = conn
+	return nil
+}
+
+// A tlsConn is a connection with TLS state, over TCP or QUIC.
+type tlsConn interface {
+	net.Conn
+	ConnectionState() tls.ConnectionState
+}
+
+// dialRelay connects to the relay protocol port, over QUIC if the relay
+// advertises it and falling back to TLS over TCP. The returned connection
+// has a deadline set timeout into the future.
+func dialRelay(ctx context.Context, uri *url.URL, cfg *tls.Config, timeout time.Duration) (net.Conn, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Copy the TLS config and set the server name we're connecting to. In
 	// many cases this will be an IP address, in which case it's a no-op. In
 	// other cases it will be a hostname, which will cause the TLS stack to
 	// send SNI.
-	cfg := c.config
-	if host, _, err := net.SplitHostPort(c.uri.Host); err == nil {
+	if host, _, err := net.SplitHostPort(uri.Host); err == nil {
 		cfg = cfg.Clone()
 		cfg.ServerName = host
 	}
 
+	if quicAdvertised(uri) {
+		// Leave time for falling back to TCP, in case UDP is blocked.
+		quicCtx, cancel := context.WithTimeout(timeoutCtx, timeout/2)
+		conn, err := dialQUIC(quicCtx, uri.Host, cfg)
+		cancel()
+		if err == nil {
+			err = validateConnectionState(conn.ConnectionState(), uri, protocol.ProtocolName)
+			if err == nil {
+				err = conn.SetDeadline(time.Now().Add(timeout))
+			}
+			if err == nil {
+				return conn, nil
+			}
+			conn.Close()
+		}
+		l.Debugf("Could not connect to relay %s over QUIC, falling back to TCP: %s", uri, err)
+	}
+
+	tcpConn, err := dialer.DialContext(timeoutCtx, "tcp", uri.Host)
+	if err != nil {
+		return nil, err
+	}
+
 	conn := tls.Client(tcpConn, cfg)
 
-	if err := conn.SetDeadline(time.Now().Add(c.connectTimeout)); err != nil {
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
 		conn.Close()
-		return err
+		return nil, err
 	}
 
-	if err := performHandshakeAndValidation(conn, c.uri); err != nil {
+	if err := performHandshakeAndValidation(conn, uri); err != nil {
 		conn.Close()
-		return err
+		return nil, err
 	}
 
-	c.conn = conn
-	return nil
+	return conn, nil
 }
 
 func (c *staticClient) disconnect() {
@@ -208,8 +244,13 @@ func performHandshakeAndValidation(conn *tls.Conn, uri *url.URL) error {
 		return err
 	}
 
-	cs := conn.ConnectionState()
-	if cs.NegotiatedProtocol != protocol.ProtocolName {
+	return validateConnectionState(conn.ConnectionState(), uri, protocol.ProtocolName)
+}
+
+// validateConnectionState checks the negotiated protocol and, if the relay
+// URI carries one, the relay device ID.
+func validateConnectionState(cs tls.ConnectionState, uri *url.URL, proto string) error {
+	if cs.NegotiatedProtocol != proto {
 		return errors.New("protocol negotiation error")
 	}
 
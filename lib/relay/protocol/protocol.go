@@ -11,6 +11,10 @@ import (
 const (
 	magic        = 0x9E79BC40
 	ProtocolName = "bep-relay"
+
+	// SessionProtocolName is negotiated for session connections over
+	// QUIC, where all connections are TLS.
+	SessionProtocolName = "bep-relay-session"
 )
 
 var (
@@ -0,0 +1,119 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package quicconn implements the relay connections over QUIC, shared by
+// the relay server and client. Each QUIC connection carries a single
+// stream, for either the relay protocol or a session.
+package quicconn
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// closeTimeout is how long Close waits for the other side to close the
+// connection before closing it forcibly. Can be overridden for testing.
+var closeTimeout = 5 * time.Second
+
+// Config is the QUIC configuration used for relay connections on both
+// sides.
+var Config = &quic.Config{
+	MaxIdleTimeout:  30 * time.Second,
+	KeepAlivePeriod: 15 * time.Second,
+}
+
+// Conn is a net.Conn made of the single stream on a QUIC connection.
+type Conn struct {
+	conn   *quic.Conn
+	stream *quic.Stream
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+// New returns a Conn for the given connection and its stream.
+func New(conn *quic.Conn, stream *quic.Stream) *Conn {
+	return &Conn{conn: conn, stream: stream}
+}
+
+// Dial connects to the given address and opens the stream.
+func Dial(ctx context.Context, addr string, tlsCfg *tls.Config) (*Conn, error) {
+	conn, err := quic.DialAddr(ctx, addr, tlsCfg, Config)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(1, err.Error())
+		return nil, err
+	}
+	return New(conn, stream), nil
+}
+
+// Accept waits for the stream on an accepted connection, closing the
+// connection if none is opened.
+func Accept(ctx context.Context, conn *quic.Conn) (*Conn, error) {
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(1, "no stream")
+		return nil, err
+	}
+	return New(conn, stream), nil
+}
+
+func (c *Conn) Read(bs []byte) (int, error) {
+	return c.stream.Read(bs)
+}
+
+func (c *Conn) Write(bs []byte) (int, error) {
+	return c.stream.Write(bs)
+}
+
+// Close closes the stream, and the connection once the other side has
+// closed it or after a timeout.
+func (c *Conn) Close() error {
+	err := c.stream.Close()
+	timeout := closeTimeout
+	// Closing the connection right away would discard anything not yet
+	// received by the other side, such as a final message. Give the other
+	// side a moment to read it and close the connection itself.
+	go func() {
+		select {
+		case <-c.conn.Context().Done():
+		case <-time.After(timeout):
+		}
+		_ = c.conn.CloseWithError(0, "closing")
+	}()
+	return err
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.stream.SetDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.stream.SetWriteDeadline(t)
+}
+
+// ConnectionState returns the TLS state of the QUIC connection.
+func (c *Conn) ConnectionState() tls.ConnectionState {
+	return c.conn.ConnectionState().TLS
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2026 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package quicconn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	"github.com/syncthing/syncthing/lib/tlsutil"
+)
+
+const testProto = "test-proto"
+
+// testPair returns the client and server side of a connection.
+func testPair(t *testing.T) (*Conn, *Conn) {
+	t.Helper()
+	cert, err := tlsutil.NewCertificateInMemory("quicconn", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := quic.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{testProto},
+		MinVersion:   tls.VersionTLS13,
+	}, Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { list.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	accepted := make(chan *Conn, 1)
+	go func() {
+		defer close(accepted)
+		qc, err := list.Accept(ctx)
+		if err != nil {
+			return
+		}
+		conn, err := Accept(ctx, qc)
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}()
+
+	client, err := Dial(ctx, list.Addr().String(), &tls.Config{
+		NextProtos:         []string{testProto},
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS13,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stream only reaches the other side once something is written
+	// on it.
+	if _, err := client.Write([]byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	if server == nil {
+		t.Fatal("failed to accept")
+	}
+	if _, err := io.ReadFull(server, make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestConn(t *testing.T) {
+	client, server := testPair(t)
+
+	if state := client.ConnectionState(); state.NegotiatedProtocol != testProto || len(state.PeerCertificates) != 1 {
+		t.Error("unexpected connection state", state.NegotiatedProtocol, len(state.PeerCertificates))
+	}
+	if client.LocalAddr().(*net.UDPAddr).Port != server.RemoteAddr().(*net.UDPAddr).Port {
+		t.Errorf("address mismatch %v != %v", client.LocalAddr(), server.RemoteAddr())
+	}
+
+	for _, pair := range [][2]net.Conn{{client, server}, {server, client}} {
+		if _, err := pair[0].Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(pair[1], buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "hello" {
+			t.Errorf("read %q", buf)
+		}
+	}
+
+	if err := client.SetReadDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var nerr net.Error
+	if _, err := client.Read(make([]byte, 1)); !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Error("expected timeout, got", err)
+	}
+}
+
+func TestCloseDeliversPendingData(t *testing.T) {
+	oldTimeout := closeTimeout
+	closeTimeout = 500 * time.Millisecond
+	defer func() { closeTimeout = oldTimeout }()
+
+	client, server := testPair(t)
+
+	// A final message written right before closing still reaches the
+	// other side, followed by EOF.
+	if _, err := server.Write([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "bye" {
+		t.Errorf("read %q", bs)
+	}
+
+	// The connection itself is closed once the timeout has passed.
+	select {
+	case <-server.conn.Context().Done():
+	case <-client.conn.Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Error("connection not closed")
+	}
+}